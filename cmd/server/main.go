@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"expvar"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -12,6 +13,7 @@ import (
 	"task-traker/internal/config"
 	httpHandler "task-traker/internal/delivery/http"
 	"task-traker/internal/delivery/telegramHandler"
+	"task-traker/internal/events"
 	"task-traker/internal/repository"
 	"task-traker/internal/service"
 	"task-traker/pkg/telegram"
@@ -49,10 +51,17 @@ func main() {
 	config.SetConfigLevel(*conf, programLevel)
 
 	// Инициализируем телеграм бот
-	bot, err := telegram.NewClient(conf.TelegramToken)
+	client, err := telegram.NewClient(conf.TelegramToken)
 	if err != nil {
 		slog.Error("bot authorization error", "error", err)
 	}
+	// Все исходящие сообщения идут через очередь с лимитами телеграма.
+	// Очередь останавливается после бота, чтобы он успел ответить на
+	// уже принятые апдейты.
+	bot := telegram.NewQueue(client, telegram.DefaultLimits)
+	queueCtx, stopQueue := context.WithCancel(context.Background())
+	defer stopQueue()
+	go bot.Run(queueCtx)
 
 	//Инициализируем базу данных
 	db, err := repository.InitDB(ctx)
@@ -64,40 +73,76 @@ func main() {
 
 	redisRepo := repository.NewRedisRepo(os.Getenv("REDIS_ADDR"))
 
+	// Шина событий для потоков /events и /ws; через Redis события
+	// доходят до клиентов, подключенных к другим репликам
+	bus := events.NewBus(redisRepo)
+	go func() {
+		if err := bus.Run(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("Event bus stopped", "error", err)
+		}
+	}()
+
+	// Вебхуки пользователей получают те же события; доставки и повторы
+	// идут в фоне и не задерживают изменение задач
+	webhooks := service.NewWebhookDispatcher(db)
+	go webhooks.Run(ctx)
+
 	// Создаем сервис и передаём ему репозиторий.
 	taskService := service.TaskService{
-		Repo:  db,
-		Redis: redisRepo,
+		Repo:     db,
+		Redis:    redisRepo,
+		Events:   bus,
+		Webhooks: webhooks,
 	}
 
 	// Запуск воркера уведомлений
 	go func() {
 		slog.Info("Starting a background notification worker")
-		taskService.StartNotificationWorker(ctx, bot)
+		taskService.StartNotificationWorker(ctx, bot.Bulk())
 	}()
 
-	telegramHandler := telegramHandler.Handler{
-		Bot:         bot,
-		TaskService: &taskService,
-		Sessions:    make(map[int64]*telegramHandler.UserSession),
+	// Обработчики апдейтов не ждут отправки ответов: чат, у которого
+	// исчерпан лимит, не задерживает другие чаты своего воркера
+	telegramHandler := telegramHandler.NewHandler(bot.Async(), &taskService)
+	telegramHandler.Workers = conf.TelegramWorkers
+
+	httpH := httpHandler.NewHandler(&taskService)
+	httpH.TrustProxy = conf.TrustProxy
+	httpH.WSOrigins = conf.WSOrigins
+	router := httpH.InitRouter()
+	if conf.TelegramMode == config.ModeWebhook {
+		// Апдейты телеграм принимаются тем же HTTP сервером, что и API
+		router, err = httpH.MountWebhook(router, conf.WebhookPath, telegramHandler.WebhookHandler(conf.WebhookSecret))
+		if err != nil {
+			slog.Error("failed to mount telegram webhook", "error", err)
+			os.Exit(1)
+		}
 	}
+
 	// Запуск телеграм бота
+	botDone := make(chan struct{})
 	go func() {
-		slog.Info("Starting a telegram bot")
-		err = telegramHandler.Start(ctx)
+		defer close(botDone)
+		slog.Info("Starting a telegram bot", "mode", conf.TelegramMode)
+		var err error
+		if conf.TelegramMode == config.ModeWebhook {
+			err = telegramHandler.StartWebhook(ctx, conf.WebhookURL, conf.WebhookSecret)
+		} else {
+			err = telegramHandler.Start(ctx)
+		}
 		if err != nil {
 			slog.Error("Telegram answer", "error", err)
 		}
 	}()
 
-	httpH := httpHandler.NewHandler(&taskService)
 	addr := os.Getenv("HTTP_ADDR")
 	srv := &http.Server{
 		Addr:         addr,
-		Handler:      httpH.InitRouter(),
+		Handler:      router,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
 	}
+	srv.RegisterOnShutdown(httpH.CloseStreams)
 
 	go func() {
 		text := fmt.Sprintf("Запуск HTTP сервера на %s", addr)
@@ -108,6 +153,22 @@ func main() {
 		}
 	}()
 
+	// Метрики expvar отдаются отдельным сервером, а не публичным API:
+	// в них есть командная строка процесса и состояние памяти
+	if conf.DebugAddr != "" {
+		debugMux := http.NewServeMux()
+		debugMux.Handle("GET /debug/vars", expvar.Handler())
+		debugSrv := &http.Server{Addr: conf.DebugAddr, Handler: debugMux, ReadTimeout: 10 * time.Second}
+		defer debugSrv.Close()
+		go func() {
+			slog.Info("Starting a debug server", "addr", conf.DebugAddr)
+			err := debugSrv.ListenAndServe()
+			if err != nil && err != http.ErrServerClosed {
+				slog.Error("Debug server error", "error", err)
+			}
+		}()
+	}
+
 	<-ctx.Done()
 	slog.Info("Shutting down gracefully...")
 	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
@@ -116,5 +177,11 @@ func main() {
 	if err != nil {
 		slog.Error("Server forced to shutdown", "error", err)
 	}
+	// Ждем, пока бот обработает уже принятые апдейты, а очередь отправит
+	// ответы на них
+	<-botDone
+	if err := bot.Flush(shutdownCtx); err != nil {
+		slog.Error("Telegram send queue not flushed", "error", err)
+	}
 	slog.Info("App exited")
 }
@@ -4,14 +4,14 @@ go 1.25.3
 
 require (
 	github.com/go-telegram-bot-api/telegram-bot-api/v5 v5.5.1
+	github.com/google/uuid v1.6.0
 	github.com/joho/godotenv v1.5.1
+	github.com/redis/go-redis/v9 v9.17.2
 )
 
 require (
 	github.com/cespare/xxhash/v2 v2.3.0 // indirect
 	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
-	github.com/google/uuid v1.6.0 // indirect
-	github.com/redis/go-redis/v9 v9.17.2 // indirect
 	github.com/yuin/gopher-lua v1.1.1 // indirect
 )
 
@@ -34,6 +34,8 @@ require (
 
 require (
 	github.com/alicebob/miniredis/v2 v2.36.0
+	github.com/coder/websocket v1.8.14
 	github.com/jackc/pgx/v5 v5.8.0
-	golang.org/x/text v0.33.0 // indirect
+	github.com/swaggest/swgui v1.8.5
+	golang.org/x/text v0.33.0
 )
@@ -3,13 +3,39 @@ package config
 import (
 	"fmt"
 	"log/slog"
+	"net/url"
 	"os"
+	"regexp"
+	"strconv"
 	"strings"
 )
 
+// Режимы получения апдейтов телеграм.
+const (
+	ModePolling = "polling"
+	ModeWebhook = "webhook"
+)
+
+// DefaultWebhookPath - путь для апдейтов телеграм, если в TELEGRAM_WEBHOOK_URL его нет.
+const DefaultWebhookPath = "/telegram/webhook"
+
 type Config struct {
-	TelegramToken string
-	LogLevel      string
+	TelegramToken   string
+	LogLevel        string
+	TelegramWorkers int
+	TelegramMode    string
+	// WebhookURL - публичный адрес, на который телеграм отправляет апдейты в режиме webhook.
+	WebhookURL    string
+	WebhookPath   string
+	WebhookSecret string
+	// TrustProxy - API стоит за прокси, и адрес клиента берется из X-Forwarded-For.
+	TrustProxy bool
+	// DebugAddr - адрес служебного сервера с /debug/vars. Пустой - сервер
+	// не запускается. Наружу его открывать нельзя.
+	DebugAddr string
+	// WSOrigins - шаблоны Origin (path.Match, без схемы), с которых браузер
+	// может открыть /ws со страницы другого сайта. Пустой - только тот же хост.
+	WSOrigins []string
 }
 
 func New() (*Config, error) {
@@ -21,12 +47,69 @@ func New() (*Config, error) {
 	if loglevel == "" {
 		loglevel = "info"
 	}
-	return &Config{
-		TelegramToken: tg_token,
-		LogLevel:      loglevel,
-	}, nil
+	workers := 0
+	if w := os.Getenv("TELEGRAM_WORKERS"); w != "" {
+		n, err := strconv.Atoi(w)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("environment variable \"TELEGRAM_WORKERS\" must be a positive number")
+		}
+		workers = n
+	}
+	conf := &Config{
+		TelegramToken:   tg_token,
+		LogLevel:        loglevel,
+		TelegramWorkers: workers,
+		TelegramMode:    strings.ToLower(os.Getenv("TELEGRAM_MODE")),
+		DebugAddr:       os.Getenv("DEBUG_ADDR"),
+	}
+	for o := range strings.SplitSeq(os.Getenv("WS_ORIGINS"), ",") {
+		if o = strings.TrimSpace(o); o != "" {
+			conf.WSOrigins = append(conf.WSOrigins, o)
+		}
+	}
+	if p := os.Getenv("TRUST_PROXY"); p != "" {
+		trust, err := strconv.ParseBool(p)
+		if err != nil {
+			return nil, fmt.Errorf("environment variable \"TRUST_PROXY\" must be true or false")
+		}
+		conf.TrustProxy = trust
+	}
+	switch conf.TelegramMode {
+	case "", ModePolling:
+		conf.TelegramMode = ModePolling
+	case ModeWebhook:
+		if err := conf.loadWebhook(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown TELEGRAM_MODE %q, expected %q or %q", conf.TelegramMode, ModePolling, ModeWebhook)
+	}
+	return conf, nil
 }
 
+func (c *Config) loadWebhook() error {
+	c.WebhookURL = os.Getenv("TELEGRAM_WEBHOOK_URL")
+	u, err := url.Parse(c.WebhookURL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("environment variable \"TELEGRAM_WEBHOOK_URL\" must be an https URL")
+	}
+	// На корне адреса работает API, поэтому апдейты принимаются на отдельном пути
+	if u.Path == "" || u.Path == "/" {
+		u.Path = DefaultWebhookPath
+		c.WebhookURL = u.String()
+	}
+	c.WebhookPath = u.Path
+
+	// Телеграм допускает в секрете 1-256 символов A-Z, a-z, 0-9, _ и -
+	c.WebhookSecret = os.Getenv("TELEGRAM_WEBHOOK_SECRET")
+	if !webhookSecretRe.MatchString(c.WebhookSecret) {
+		return fmt.Errorf("environment variable \"TELEGRAM_WEBHOOK_SECRET\" must be 1-256 characters of A-Z, a-z, 0-9, _ or -")
+	}
+	return nil
+}
+
+var webhookSecretRe = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+
 func SetConfigLevel(conf Config, programmLevel *slog.LevelVar) {
 	level := strings.ToUpper(conf.LogLevel)
 	if level != "INFO" {
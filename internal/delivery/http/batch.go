@@ -0,0 +1,70 @@
+package httpHandler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"task-traker/internal/service"
+)
+
+type batchRequest struct {
+	// Mode - atomic (по умолчанию) или best_effort.
+	Mode       service.BatchMode `json:"mode"`
+	Operations []service.BatchOp `json:"operations"`
+}
+
+type batchResponse struct {
+	Mode service.BatchMode `json:"mode"`
+	// Committed - изменения сохранены. В режиме atomic false, если
+	// какая-то операция не удалась.
+	Committed bool          `json:"committed"`
+	Results   []batchResult `json:"results"`
+}
+
+type batchResult struct {
+	Index  int                 `json:"index"`
+	Status service.BatchStatus `json:"status"`
+	ID     int                 `json:"id,omitempty"`
+	Error  *Problem            `json:"error,omitempty"`
+}
+
+// batchTasks выполняет до service.MaxBatchSize операций create, update,
+// delete и complete в одной транзакции и отдает итог каждой.
+func (h *Handler) batchTasks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(userIDKey).(int64)
+	if !ok {
+		slog.Error("UserID not found in context")
+		httpError(w, r, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var req batchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpError(w, r, "JSON decode error", http.StatusBadRequest)
+		return
+	}
+	if req.Mode == "" {
+		req.Mode = service.BatchAtomic
+	}
+
+	results, err := h.service.Batch(r.Context(), userID, req.Mode, req.Operations)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	resp := batchResponse{Mode: req.Mode, Committed: true, Results: make([]batchResult, len(results))}
+	for i, res := range results {
+		resp.Results[i] = batchResult{Index: i, Status: res.Status, ID: res.ID}
+		if res.Err != nil {
+			p := problemFor(r, res.Err)
+			p.Type, p.Title = "about:blank", http.StatusText(p.Status)
+			resp.Results[i].Error = &p
+		}
+		if req.Mode == service.BatchAtomic && res.Status != service.BatchOK {
+			resp.Committed = false
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		slog.Error("JSON encode error", "error", err)
+	}
+}
@@ -0,0 +1,104 @@
+package httpHandler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"task-traker/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchTasks(t *testing.T) {
+	api := newTestAPI(t)
+	existing := &domain.Task{UserID: testUserID, Title: "Купить хлеб", Deadline: time.Now().Add(time.Hour)}
+	require.NoError(t, api.repo.Create(context.Background(), existing))
+	deadline := time.Now().Add(2 * time.Hour).Format("02.01.2006 15:04")
+	body := fmt.Sprintf(`{"mode":"%%s","operations":[
+		{"op":"create","title":"Позвонить маме","deadline":"%s"},
+		{"op":"complete","id":%d},
+		{"op":"delete","id":999}
+	]}`, deadline, existing.ID)
+
+	t.Run("atomic", func(t *testing.T) {
+		w := api.do("POST", "/tasks/batch", fmt.Sprintf(body, "atomic"))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp batchResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		assert.False(t, resp.Committed)
+		require.Len(t, resp.Results, 3)
+		assert.Equal(t, "rolled_back", string(resp.Results[0].Status))
+		assert.Equal(t, "rolled_back", string(resp.Results[1].Status))
+		assert.Equal(t, "failed", string(resp.Results[2].Status))
+		require.NotNil(t, resp.Results[2].Error)
+		assert.Equal(t, http.StatusNotFound, resp.Results[2].Error.Status)
+		assert.Equal(t, "Task not found", resp.Results[2].Error.Detail)
+
+		tasks := api.repo.Tasks()
+		require.Len(t, tasks, 1)
+		assert.Nil(t, tasks[0].CompletedAt)
+	})
+
+	t.Run("best effort", func(t *testing.T) {
+		w := api.do("POST", "/tasks/batch", fmt.Sprintf(body, "best_effort"))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp batchResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		assert.True(t, resp.Committed)
+		assert.Equal(t, "ok", string(resp.Results[0].Status))
+		assert.NotZero(t, resp.Results[0].ID)
+		assert.Equal(t, "ok", string(resp.Results[1].Status))
+		assert.Equal(t, "failed", string(resp.Results[2].Status))
+		assert.Len(t, api.repo.Tasks(), 2)
+	})
+}
+
+func TestBatchTasks_InvalidRequest(t *testing.T) {
+	api := newTestAPI(t)
+
+	w := api.do("POST", "/tasks/batch", `{"mode":"sometimes","operations":[]}`)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	fields := []string{}
+	for _, f := range decodeProblem(t, w).Errors {
+		fields = append(fields, f.Field)
+	}
+	assert.Equal(t, []string{"mode", "operations"}, fields)
+}
+
+// failingCompleteRepo - репозиторий, в котором Complete падает, как при сбое базы.
+type failingCompleteRepo struct {
+	domain.TaskRepository
+}
+
+func (r failingCompleteRepo) Complete(context.Context, int64, int) error {
+	return errors.New("connection refused")
+}
+
+func (r failingCompleteRepo) InTx(ctx context.Context, fn func(domain.TaskRepository) error) error {
+	return r.TaskRepository.InTx(ctx, func(repo domain.TaskRepository) error {
+		return fn(failingCompleteRepo{repo})
+	})
+}
+
+func TestBatchTasks_DatabaseError(t *testing.T) {
+	api := newTestAPI(t)
+	existing := &domain.Task{UserID: testUserID, Title: "Купить хлеб", Deadline: time.Now().Add(time.Hour)}
+	require.NoError(t, api.repo.Create(context.Background(), existing))
+	api.service.Repo = failingCompleteRepo{api.repo}
+
+	body := fmt.Sprintf(`{"mode":"best_effort","operations":[{"op":"complete","id":%d}]}`, existing.ID)
+	w := api.do("POST", "/tasks/batch", body)
+
+	// Сбой базы - 500, а не committed:false с ошибкой операции
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "Internal Server Error", decodeProblem(t, w).Detail)
+	assert.Nil(t, api.repo.Tasks()[0].CompletedAt)
+}
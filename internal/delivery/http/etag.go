@@ -0,0 +1,85 @@
+package httpHandler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"task-traker/internal/domain"
+)
+
+// taskETag - ETag задачи: ее версия, которая растет при каждом изменении.
+func taskETag(task domain.Task) string {
+	return `"` + strconv.Itoa(task.Version) + `"`
+}
+
+// bodyETag - ETag ответа по его содержимому; так помечаются списки.
+func bodyETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches сообщает, подходит ли etag под список из If-Match или
+// If-None-Match; "*" подходит к любому. При слабом сравнении (If-None-Match)
+// префикс W/ не учитывается, при сильном (If-Match) слабые ETag не подходят.
+func etagMatches(header, etag string, weak bool) bool {
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if strings.HasPrefix(tag, "W/") {
+			if !weak {
+				continue
+			}
+			tag = tag[2:]
+		}
+		if tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// writeConditional отдает JSON body с заголовком ETag, а если клиент
+// прислал этот ETag в If-None-Match - 304 без тела.
+func writeConditional(w http.ResponseWriter, r *http.Request, etag string, body []byte) {
+	w.Header().Set("ETag", etag)
+	// Ответ зависит от токена: общим кешам его хранить нельзя, а браузер
+	// каждый раз сверяет свою копию по ETag
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if match := r.Header.Get("If-None-Match"); match != "" && etagMatches(match, etag, true) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+// taskPrecondition проверяет If-Match запроса, который меняет задачу id,
+// и возвращает версию, которую запрос может изменить: 0 - заголовка нет
+// и подойдет любая. Если ETag задачи другой, отвечает 412 с текущим ETag.
+func (h *Handler) taskPrecondition(w http.ResponseWriter, r *http.Request, userID int64, id int) (int, bool) {
+	match := r.Header.Get("If-Match")
+	if match == "" {
+		return 0, true
+	}
+	task, err := h.service.GetTask(r.Context(), userID, id)
+	if err != nil {
+		writeError(w, r, err)
+		return 0, false
+	}
+	if !etagMatches(match, taskETag(task), false) {
+		w.Header().Set("ETag", taskETag(task))
+		preconditionFailed(w, r)
+		return 0, false
+	}
+	return task.Version, true
+}
+
+// preconditionFailed отвечает 412: задачу изменили после того, как клиент
+// ее получил.
+func preconditionFailed(w http.ResponseWriter, r *http.Request) {
+	httpError(w, r, "Task was modified, fetch it again", http.StatusPreconditionFailed)
+}
@@ -0,0 +1,161 @@
+package httpHandler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"task-traker/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createOwnTask(t *testing.T, api *testAPI) domain.Task {
+	t.Helper()
+	task := domain.Task{UserID: testUserID, Title: "Купить хлеб", Deadline: time.Now().Add(time.Hour)}
+	require.NoError(t, api.repo.Create(context.Background(), &task))
+	return task
+}
+
+func TestGetTask_ETag(t *testing.T) {
+	api := newTestAPI(t)
+	task := createOwnTask(t, api)
+	target := fmt.Sprintf("/tasks/%d", task.ID)
+
+	w := api.do("GET", target, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+	assert.Equal(t, "private, no-cache", w.Header().Get("Cache-Control"))
+	var got domain.Task
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+	assert.Equal(t, "Купить хлеб", got.Title)
+	assert.Equal(t, 1, got.Version)
+
+	for _, match := range []string{`"1"`, `W/"1"`, `"7", "1"`, "*"} {
+		w = api.do("GET", target, "", "If-None-Match", match)
+		assert.Equal(t, http.StatusNotModified, w.Code, match)
+		assert.Empty(t, w.Body.String())
+		assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+	}
+
+	require.NoError(t, api.service.CompleteTask(context.Background(), testUserID, task.ID))
+	w = api.do("GET", target, "", "If-None-Match", `"1"`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+
+	w = api.do("GET", "/tasks/999", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestGetTasks_NotModified(t *testing.T) {
+	api := newTestAPI(t)
+	task := createOwnTask(t, api)
+
+	w := api.do("GET", "/tasks", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	w = api.do("GET", "/tasks", "", "If-None-Match", etag)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	// Другие параметры - другая страница
+	w = api.do("GET", "/tasks?status=completed", "", "If-None-Match", etag)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	task.Title = "Купить батон"
+	require.NoError(t, api.service.UpdateTask(context.Background(), &task))
+	w = api.do("GET", "/tasks", "", "If-None-Match", etag)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+}
+
+func TestPatchTask_IfMatch(t *testing.T) {
+	api := newTestAPI(t)
+	task := createOwnTask(t, api)
+	target := fmt.Sprintf("/tasks/%d", task.ID)
+
+	w := api.do("PATCH", target, `{"title":"Купить батон","priority":1}`, "If-Match", `"1"`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+	var got domain.Task
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+	assert.Equal(t, "Купить батон", got.Title)
+	assert.Equal(t, domain.PriorityHigh, got.Priority)
+	assert.Equal(t, 2, got.Version)
+
+	// Второй клиент правит устаревшую копию
+	w = api.do("PATCH", target, `{"title":"Купить молоко"}`, "If-Match", `"1"`, "Accept-Language", "ru")
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+	assert.Equal(t, "Задача изменилась, загрузите ее заново", decodeProblem(t, w).Detail)
+	assert.Equal(t, "Купить батон", api.repo.Tasks()[0].Title)
+
+	// Слабый ETag не подходит к If-Match
+	w = api.do("PATCH", target, `{"title":"Купить молоко"}`, "If-Match", `W/"2"`)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	// Без If-Match изменение применяется к последней версии
+	w = api.do("PATCH", target, `{"project":" дом "}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+	assert.Equal(t, "дом", api.repo.Tasks()[0].Project)
+
+	w = api.do("PATCH", target, `{"title":" "}`, "If-Match", "*")
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	w = api.do("PATCH", target, `{"title":`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestPatchTask_OnlyOwn(t *testing.T) {
+	api := newTestAPI(t)
+	foreign := domain.Task{UserID: 7, Title: "Чужая задача", Deadline: time.Now().Add(time.Hour)}
+	require.NoError(t, api.repo.Create(context.Background(), &foreign))
+
+	w := api.do("PATCH", fmt.Sprintf("/tasks/%d", foreign.ID), `{"title":"Моя задача"}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = api.do("PATCH", fmt.Sprintf("/tasks/%d", foreign.ID), `{"title":"Моя задача"}`, "If-Match", `"1"`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "Чужая задача", api.repo.Tasks()[0].Title)
+}
+
+func TestDeleteTask_IfMatch(t *testing.T) {
+	api := newTestAPI(t)
+	task := createOwnTask(t, api)
+	target := fmt.Sprintf("/tasks/%d", task.ID)
+	require.NoError(t, api.service.CompleteTask(context.Background(), testUserID, task.ID))
+
+	w := api.do("DELETE", target, "", "If-Match", `"1"`)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+	require.Len(t, api.repo.Tasks(), 1)
+
+	w = api.do("DELETE", target, "", "If-Match", `"2"`)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, api.repo.Tasks())
+}
+
+func TestETagMatches(t *testing.T) {
+	tests := []struct {
+		header string
+		weak   bool
+		want   bool
+	}{
+		{`"3"`, false, true},
+		{`"1", "3"`, false, true},
+		{`"1","3"`, true, true},
+		{`W/"3"`, false, false},
+		{`W/"3"`, true, true},
+		{`"33"`, true, false},
+		{`3`, true, false},
+		{"*", false, true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, etagMatches(tt.header, `"3"`, tt.weak), "%s weak=%v", tt.header, tt.weak)
+	}
+}
@@ -1,25 +1,44 @@
 package httpHandler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
+	"task-traker/internal/domain"
 	"task-traker/internal/service"
+	"time"
 )
 
 type Handler struct {
 	service *service.TaskService
+	// Limits - лимиты запросов, по умолчанию DefaultRateLimits.
+	Limits RateLimits
+	// TrustProxy - API работает за обратным прокси, и адрес клиента
+	// берется из X-Forwarded-For.
+	TrustProxy bool
+	// WSOrigins - шаблоны Origin других сайтов, которым разрешено
+	// открывать /ws. Запросы с того же хоста разрешены всегда.
+	WSOrigins []string
+	// streams отменяется в CloseStreams и завершает потоки событий.
+	streams     context.Context
+	stopStreams context.CancelFunc
 }
 
 type contextKey string
 
 const userIDKey contextKey = "user_id"
 
+// CreateTaskRequest - тело POST /tasks. Владелец задачи - пользователь
+// из токена; UserID необязателен и, если указан, должен с ним совпадать.
 type CreateTaskRequest struct {
-	UserID   int64  `json:"user_id"`
+	UserID   int64  `json:"user_id,omitempty"`
 	Title    string `json:"title"`
 	Deadline string `json:"deadline"`
+	Project  string `json:"project"`
 }
 
 type loginRequest struct {
@@ -28,63 +47,129 @@ type loginRequest struct {
 }
 
 func NewHandler(s *service.TaskService) *Handler {
+	streams, stop := context.WithCancel(context.Background())
 	return &Handler{
-		service: s,
+		service:     s,
+		Limits:      DefaultRateLimits,
+		streams:     streams,
+		stopStreams: stop,
 	}
 }
 
 func (h *Handler) InitRouter() http.Handler {
 	mux := http.NewServeMux()
 
-	mux.Handle("GET /tasks", h.authMiddleware(http.HandlerFunc(h.getTasks)))
-	mux.Handle("POST /tasks", h.authMiddleware(http.HandlerFunc(h.createTask)))
-	mux.Handle("DELETE /tasks/{id}", h.authMiddleware(http.HandlerFunc(h.deleteTasks)))
-	mux.HandleFunc("POST /login", h.login)
-	mux.HandleFunc("POST /auth/refresh", h.Refresh)
-	mux.Handle("POST /logout", h.authMiddleware(http.HandlerFunc(h.Logout)))
-
+	for _, rt := range h.routes() {
+		handler := rt.handler
+		if rt.idempotent {
+			handler = h.idempotent(handler)
+		}
+		if rt.auth {
+			handler = h.authMiddleware(h.limitUser(handler))
+		}
+		mux.Handle(rt.method+" "+rt.path, handler)
+	}
 
-	return LoggingMiddleware(mux)
+	return LoggingMiddleware(LanguageMiddleware(h.limitIP(mux)))
 }
 
+// getTasks отдает страницу задач пользователя. Параметры: status
+// (active, completed, all), due_after, due_before (RFC 3339 или ГГГГ-ММ-ДД),
+// q, tag, project, sort (deadline, -deadline, created, -created), limit
+// и cursor - next_cursor предыдущей страницы. ETag страницы считается
+// по ее содержимому, с ним в If-None-Match неизменная страница - 304.
 func (h *Handler) getTasks(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(userIDKey).(int64)
 	if !ok {
 		slog.Error("UserID not found in context")
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		httpError(w, r, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	q := r.URL.Query()
+	filter := domain.TaskFilter{
+		UserID:  userID,
+		Status:  domain.TaskStatus(q.Get("status")),
+		Query:   q.Get("q"),
+		Tag:     q.Get("tag"),
+		Project: q.Get("project"),
+		Sort:    domain.TaskSort(q.Get("sort")),
+	}
+	var err error
+	if filter.DueAfter, err = parseTimeParam(q.Get("due_after")); err != nil {
+		paramError(w, r, "due_after", "Invalid due_after, expected RFC 3339 or YYYY-MM-DD")
 		return
 	}
-	tasks, err := h.service.Repo.GetTasksByUserID(r.Context(), userID)
+	if filter.DueBefore, err = parseTimeParam(q.Get("due_before")); err != nil {
+		paramError(w, r, "due_before", "Invalid due_before, expected RFC 3339 or YYYY-MM-DD")
+		return
+	}
+	if v := q.Get("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil || filter.Limit <= 0 {
+			paramError(w, r, "limit", "Invalid limit")
+			return
+		}
+	}
+
+	page, err := h.service.ListTasks(r.Context(), filter, q.Get("cursor"))
 	if err != nil {
-		slog.Error("HTTP getTasks error", "error", err)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(tasks)
+	body, err := json.Marshal(page)
 	if err != nil {
 		slog.Error("JSON encode error", "error", err)
+		httpError(w, r, "Internal Server Error", http.StatusInternalServerError)
+		return
 	}
+	writeConditional(w, r, bodyETag(body), body)
+}
+
+// parseTimeParam разбирает время в формате RFC 3339 или дату ГГГГ-ММ-ДД
+// (начало дня). Пустая строка - нулевое время. Сроки задач хранятся в UTC.
+func parseTimeParam(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(statsDateLayout, v); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	return t.UTC(), err
 }
 
 func (h *Handler) createTask(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(userIDKey).(int64)
+	if !ok {
+		slog.Error("UserID not found in context")
+		httpError(w, r, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 	var req CreateTaskRequest
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
 		slog.Error("JSON decode error", "error", err)
-		http.Error(w, "JSON decode error", http.StatusBadRequest)
+		httpError(w, r, "JSON decode error", http.StatusBadRequest)
 		return
 	}
 
-	if req.Title == "" || req.UserID == 0 || req.Deadline == "" {
-		http.Error(w, "Title and UserID are required", http.StatusBadRequest)
+	if req.UserID != 0 && req.UserID != userID {
+		slog.Warn("Task create for another user rejected", "user_id", userID, "body_user_id", req.UserID)
+		httpError(w, r, "Tasks can only be created for the authenticated user", http.StatusForbidden)
 		return
 	}
 
-	err = h.service.CreateTask(r.Context(), req.UserID, req.Title, req.Deadline)
+	deadline, err := service.ParseDeadline(req.Deadline)
+	if err == nil {
+		err = h.service.AddTask(r.Context(), &domain.Task{
+			UserID:       userID,
+			Title:        req.Title,
+			Deadline:     deadline,
+			RemindBefore: domain.DefaultRemindBefore,
+			Project:      strings.TrimSpace(req.Project),
+		})
+	}
 	if err != nil {
-		slog.Error("Service create task error", "error", err)
-		http.Error(w, "Failed to create task", http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -92,16 +177,136 @@ func (h *Handler) createTask(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(`{"status":"created"}`))
 }
 
+// statsDateLayout - формат дат from и to в GET /stats.
+const statsDateLayout = "2006-01-02"
+
+// getStats отдает статистику за дни с from по to включительно. По умолчанию
+// to - сегодня, а from - за 27 дней до to (четыре недели).
+func (h *Handler) getStats(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(userIDKey).(int64)
+	if !ok {
+		slog.Error("UserID not found in context")
+		httpError(w, r, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	to := service.Now().Truncate(24 * time.Hour)
+	if v := r.URL.Query().Get("to"); v != "" {
+		var err error
+		if to, err = time.Parse(statsDateLayout, v); err != nil {
+			paramError(w, r, "to", "Invalid date, expected YYYY-MM-DD")
+			return
+		}
+	}
+	from := to.AddDate(0, 0, -27)
+	if v := r.URL.Query().Get("from"); v != "" {
+		var err error
+		if from, err = time.Parse(statsDateLayout, v); err != nil {
+			paramError(w, r, "from", "Invalid date, expected YYYY-MM-DD")
+			return
+		}
+	}
+
+	stats, err := h.service.Stats(r.Context(), userID, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		slog.Error("JSON encode error", "error", err)
+	}
+}
+
+// getTask отдает задачу с ETag - ее версией. С этим ETag в If-None-Match
+// неизменная задача - 304, а в If-Match при PATCH и DELETE он защищает
+// от перезаписи чужих изменений.
+func (h *Handler) getTask(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(userIDKey).(int64)
+	if !ok {
+		slog.Error("UserID not found in context")
+		httpError(w, r, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		paramError(w, r, "id", "Invalid task ID")
+		return
+	}
+	task, err := h.service.GetTask(r.Context(), userID, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	body, err := json.Marshal(task)
+	if err != nil {
+		slog.Error("JSON encode error", "error", err)
+		httpError(w, r, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	writeConditional(w, r, taskETag(task), body)
+}
+
+// patchTask меняет переданные поля задачи и отдает ее с новым ETag.
+// С If-Match задача меняется, только если ее ETag не изменился, иначе - 412.
+func (h *Handler) patchTask(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(userIDKey).(int64)
+	if !ok {
+		slog.Error("UserID not found in context")
+		httpError(w, r, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		paramError(w, r, "id", "Invalid task ID")
+		return
+	}
+	var patch service.TaskPatch
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		httpError(w, r, "JSON decode error", http.StatusBadRequest)
+		return
+	}
+	version, ok := h.taskPrecondition(w, r, userID, id)
+	if !ok {
+		return
+	}
+	task, err := h.service.PatchTask(r.Context(), userID, id, version, patch)
+	if errors.Is(err, domain.ErrVersionConflict) && version != 0 {
+		preconditionFailed(w, r)
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.Header().Set("ETag", taskETag(task))
+	writeJSON(w, http.StatusOK, task)
+}
+
+// deleteTasks удаляет задачу. С If-Match задача удаляется, только если
+// ее ETag не изменился, иначе - 412.
 func (h Handler) deleteTasks(w http.ResponseWriter, r *http.Request) {
-	idStr := r.PathValue("id")
-	if idStr == "" {
-		http.Error(w, "Missing task ID", http.StatusBadRequest)
+	userID, ok := r.Context().Value(userIDKey).(int64)
+	if !ok {
+		slog.Error("UserID not found in context")
+		httpError(w, r, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		paramError(w, r, "id", "Invalid task ID")
+		return
+	}
+	version, ok := h.taskPrecondition(w, r, userID, id)
+	if !ok {
+		return
+	}
+	err = h.service.DeleteTaskVersion(r.Context(), userID, id, version)
+	if errors.Is(err, domain.ErrVersionConflict) && version != 0 {
+		preconditionFailed(w, r)
 		return
 	}
-	err := h.service.Repo.DeleteByID(r.Context(), idStr)
 	if err != nil {
-		slog.Error("Failed to delete task", "id", idStr, "error", err)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -110,14 +315,14 @@ func (h Handler) deleteTasks(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) login(w http.ResponseWriter, r *http.Request) {
     var req loginRequest
     if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        http.Error(w, "invalid request body", http.StatusBadRequest)
+        httpError(w, r, "invalid request body", http.StatusBadRequest)
         return
     }
 
     tokenPair, err := h.service.Login(r.Context(), req.UserID, req.Code)
     if err != nil {
         slog.Warn("Failed login attempt", "user_id", req.UserID, "error", err)
-        http.Error(w, "Invalid or expired code", http.StatusUnauthorized)
+        writeError(w, r, err)
         return
     }
     w.Header().Set("Content-Type", "application/json")
@@ -130,13 +335,13 @@ func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
 		RefreshToken string `json:"refresh_token"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		httpError(w, r, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	tokens, err := h.service.Refresh(r.Context(), input.RefreshToken)
 	if err != nil {
-		http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+		writeError(w, r, err)
 		return
 	}
 
@@ -156,7 +361,7 @@ func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
 
 	err := h.service.Logout(r.Context(), accessToken, input.RefreshToken)
 	if err != nil {
-		http.Error(w, "Logout failed", http.StatusInternalServerError)
+		httpError(w, r, "Logout failed", http.StatusInternalServerError)
 		return
 	}
 
@@ -0,0 +1,224 @@
+package httpHandler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"task-traker/internal/domain"
+	"task-traker/internal/repository"
+	"task-traker/internal/repository/repotest"
+	"task-traker/internal/service"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testUserID = 42
+
+type testAPI struct {
+	handler http.Handler
+	service *service.TaskService
+	repo    *repotest.MemoryRepo
+	redis   *miniredis.Miniredis
+	token   string
+}
+
+func newTestAPI(t *testing.T) *testAPI {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	repo := repotest.NewMemoryRepo()
+	s := &service.TaskService{Repo: repo, Redis: repository.NewRedisRepo(mr.Addr())}
+	token, err := s.GenerateToken(testUserID, time.Minute)
+	require.NoError(t, err)
+	return &testAPI{handler: NewHandler(s).InitRouter(), service: s, repo: repo, redis: mr, token: token}
+}
+
+// do выполняет запрос с токеном тестового пользователя.
+func (a *testAPI) do(method, target, body string, header ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.Header.Set("Authorization", "Bearer "+a.token)
+	for i := 0; i+1 < len(header); i += 2 {
+		r.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	a.handler.ServeHTTP(w, r)
+	return w
+}
+
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) Problem {
+	t.Helper()
+	assert.Equal(t, problemContentType, w.Header().Get("Content-Type"))
+	var p Problem
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&p))
+	assert.Equal(t, "about:blank", p.Type)
+	assert.Equal(t, w.Code, p.Status)
+	assert.Equal(t, http.StatusText(w.Code), p.Title)
+	return p
+}
+
+func TestCreateTask_ValidationProblem(t *testing.T) {
+	api := newTestAPI(t)
+	past := time.Now().Add(-time.Hour).Format("02.01.2006 15:04")
+
+	w := api.do("POST", "/tasks", `{"user_id":42,"title":"Купить хлеб","deadline":"`+past+`"}`)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	p := decodeProblem(t, w)
+	assert.Equal(t, "/tasks", p.Instance)
+	assert.Equal(t, []ProblemField{{Field: "deadline", Detail: "the deadline must not be in the past"}}, p.Errors)
+	assert.Empty(t, api.repo.Tasks())
+}
+
+func TestCreateTask_BadDeadlineTranslated(t *testing.T) {
+	api := newTestAPI(t)
+
+	w := api.do("POST", "/tasks", `{"user_id":42,"title":"Купить хлеб","deadline":"завтра"}`, "Accept-Language", "ru")
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, "ru", w.Header().Get("Content-Language"))
+	p := decodeProblem(t, w)
+	assert.Equal(t, "Запрос не прошел проверку", p.Detail)
+	require.Len(t, p.Errors, 1)
+	assert.Equal(t, "deadline", p.Errors[0].Field)
+}
+
+func TestCreateTask_MalformedJSON(t *testing.T) {
+	api := newTestAPI(t)
+
+	w := api.do("POST", "/tasks", `{"title":`)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	p := decodeProblem(t, w)
+	assert.Equal(t, "JSON decode error", p.Detail)
+}
+
+func TestGetTasks_ParamProblems(t *testing.T) {
+	api := newTestAPI(t)
+
+	tests := []struct {
+		query string
+		code  int
+		field string
+	}{
+		{"?due_after=tomorrow", http.StatusBadRequest, "due_after"},
+		{"?limit=-1", http.StatusBadRequest, "limit"},
+		{"?status=archived", http.StatusUnprocessableEntity, "status"},
+		{"?cursor=garbage", http.StatusUnprocessableEntity, "cursor"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			w := api.do("GET", "/tasks"+tt.query, "")
+
+			assert.Equal(t, tt.code, w.Code)
+			p := decodeProblem(t, w)
+			require.Len(t, p.Errors, 1)
+			assert.Equal(t, tt.field, p.Errors[0].Field)
+			assert.NotEmpty(t, p.Errors[0].Detail)
+		})
+	}
+}
+
+func TestGetTasks_KeepsRemindedUntilCompleted(t *testing.T) {
+	api := newTestAPI(t)
+	ctx := context.Background()
+	task := domain.Task{UserID: testUserID, Title: "Купить хлеб", Deadline: time.Now().Add(time.Hour)}
+	require.NoError(t, api.repo.Create(ctx, &task))
+	require.NoError(t, api.repo.MarkAsNotified(ctx, task.ID))
+
+	titles := func(query string) []string {
+		w := api.do("GET", "/tasks"+query, "")
+		require.Equal(t, http.StatusOK, w.Code)
+		var page struct{ Tasks []domain.Task }
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&page))
+		var res []string
+		for _, t := range page.Tasks {
+			res = append(res, t.Title)
+		}
+		return res
+	}
+	assert.Equal(t, []string{"Купить хлеб"}, titles(""))
+
+	require.NoError(t, api.service.CompleteTask(ctx, testUserID, task.ID))
+	assert.Empty(t, titles(""))
+	assert.Equal(t, []string{"Купить хлеб"}, titles("?status=completed"))
+}
+
+func TestUnauthorizedProblem(t *testing.T) {
+	api := newTestAPI(t)
+	api.token = "broken"
+
+	w := api.do("GET", "/tasks", "")
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	p := decodeProblem(t, w)
+	assert.Equal(t, "Unauthorized", p.Detail)
+}
+
+func TestLogin_WrongCode(t *testing.T) {
+	api := newTestAPI(t)
+	require.NoError(t, api.redis.Set("otp:42", "123456"))
+
+	w := api.do("POST", "/login", `{"user_id":42,"code":"000000"}`)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	p := decodeProblem(t, w)
+	assert.Equal(t, "invalid or expired code", p.Detail)
+}
+
+func TestCreateTask_OwnerFromToken(t *testing.T) {
+	api := newTestAPI(t)
+	deadline := time.Now().Add(time.Hour).Format("02.01.2006 15:04")
+
+	w := api.do("POST", "/tasks", `{"title":"Купить хлеб","deadline":"`+deadline+`"}`)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	tasks := api.repo.Tasks()
+	require.Len(t, tasks, 1)
+	assert.Equal(t, int64(testUserID), tasks[0].UserID)
+}
+
+func TestCreateTask_RejectsOtherUser(t *testing.T) {
+	api := newTestAPI(t)
+	deadline := time.Now().Add(time.Hour).Format("02.01.2006 15:04")
+
+	w := api.do("POST", "/tasks", `{"user_id":7,"title":"Чужая задача","deadline":"`+deadline+`"}`)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	decodeProblem(t, w)
+	assert.Empty(t, api.repo.Tasks())
+}
+
+func TestDeleteTask_OnlyOwn(t *testing.T) {
+	api := newTestAPI(t)
+	ctx := context.Background()
+	foreign := &domain.Task{UserID: 7, Title: "Чужая задача", Deadline: time.Now().Add(time.Hour)}
+	own := &domain.Task{UserID: testUserID, Title: "Своя задача", Deadline: time.Now().Add(time.Hour)}
+	require.NoError(t, api.repo.Create(ctx, foreign))
+	require.NoError(t, api.repo.Create(ctx, own))
+
+	w := api.do("DELETE", fmt.Sprintf("/tasks/%d", foreign.ID), "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "Task not found", decodeProblem(t, w).Detail)
+
+	w = api.do("DELETE", fmt.Sprintf("/tasks/%d", own.ID), "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	tasks := api.repo.Tasks()
+	require.Len(t, tasks, 1)
+	assert.Equal(t, foreign.ID, tasks[0].ID)
+}
+
+func TestDeleteTask_InvalidID(t *testing.T) {
+	api := newTestAPI(t)
+
+	w := api.do("DELETE", "/tasks/abc", "")
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, []ProblemField{{Field: "id", Detail: "Invalid task ID"}}, decodeProblem(t, w).Errors)
+}
@@ -0,0 +1,182 @@
+package httpHandler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"task-traker/internal/repository"
+	"time"
+)
+
+const (
+	// idempotencyHeader - заголовок с ключом идемпотентности запроса.
+	idempotencyHeader = "Idempotency-Key"
+	// idempotencyTTL - сколько хранится ответ на запрос с ключом.
+	idempotencyTTL = 24 * time.Hour
+	// idempotencyPendingTTL - сколько ключ занят выполняющимся запросом.
+	// Запрос короче WriteTimeout сервера, а если процесс упадет посреди
+	// запроса, ключ освободится сам, не дожидаясь idempotencyTTL.
+	idempotencyPendingTTL = time.Minute
+	// maxIdempotencyKey - наибольшая длина ключа.
+	maxIdempotencyKey = 255
+	// maxIdempotentBody - наибольший размер тела запроса с ключом.
+	maxIdempotentBody = 1 << 20
+)
+
+// idempotencyRecord - запрос с ключом идемпотентности и ответ на него.
+// Status == 0, пока запрос выполняется.
+type idempotencyRecord struct {
+	Hash        string `json:"hash"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// idempotent повторяет сохраненный ответ на запрос с тем же заголовком
+// Idempotency-Key, не выполняя его снова. Ключи у каждого пользователя свои
+// и хранятся в Redis 24 часа. Тот же ключ с другим запросом и ключ запроса,
+// который еще выполняется, - 409. Ответы 5xx не сохраняются, чтобы клиент
+// мог повторить запрос. Ключ освобождается и при панике обработчика, и при
+// обрыве соединения: записи в Redis не зависят от контекста запроса.
+// Без заголовка запрос выполняется как обычно.
+func (h *Handler) idempotent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyHeader)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKey {
+			paramError(w, r, idempotencyHeader, "Idempotency-Key must be at most 255 characters")
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBody))
+		if err != nil {
+			httpError(w, r, "Request body is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		userID, _ := r.Context().Value(userIDKey).(int64)
+		redisKey := fmt.Sprintf("idempotency:%d:%s", userID, key)
+		hash := requestHash(r, body)
+
+		// Клиент может отключиться посреди запроса, а ключ все равно нужно
+		// освободить или сохранить ответ
+		ctx := context.WithoutCancel(r.Context())
+		pending, _ := json.Marshal(idempotencyRecord{Hash: hash})
+		ok, err := h.service.Redis.SetTokenNX(ctx, redisKey, pending, idempotencyPendingTTL)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		if !ok {
+			if h.replay(w, r, redisKey, hash) {
+				return
+			}
+			// Первый запрос только что завершился ошибкой сервера и освободил
+			// ключ - занимаем его снова и выполняем запрос
+			ok, err = h.service.Redis.SetTokenNX(ctx, redisKey, pending, idempotencyPendingTTL)
+			if err != nil {
+				writeError(w, r, err)
+				return
+			}
+			if !ok {
+				httpError(w, r, "A request with this Idempotency-Key is still in progress", http.StatusConflict)
+				return
+			}
+		}
+
+		saved := false
+		defer func() {
+			if saved {
+				return
+			}
+			if err := h.service.Redis.DeleteToken(ctx, redisKey); err != nil {
+				slog.Error("Idempotency key release failed", "key", redisKey, "error", err)
+			}
+		}()
+
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		if rec.status >= 500 {
+			return
+		}
+
+		done, _ := json.Marshal(idempotencyRecord{
+			Hash:        hash,
+			Status:      rec.status,
+			ContentType: rec.Header().Get("Content-Type"),
+			Body:        rec.body.Bytes(),
+		})
+		if err := h.service.Redis.SetToken(ctx, redisKey, done, idempotencyTTL); err != nil {
+			slog.Error("Idempotency response save failed", "key", redisKey, "error", err)
+			return
+		}
+		saved = true
+	})
+}
+
+// replay отвечает на повтор запроса с уже использованным ключом. Если
+// ключа уже нет, ничего не пишет и возвращает false.
+func (h *Handler) replay(w http.ResponseWriter, r *http.Request, redisKey, hash string) bool {
+	raw, err := h.service.Redis.GetToken(r.Context(), redisKey)
+	if errors.Is(err, repository.ErrKeyNotFound) {
+		return false
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return true
+	}
+	var rec idempotencyRecord
+	if err := json.Unmarshal([]byte(raw), &rec); err != nil {
+		writeError(w, r, fmt.Errorf("idempotency record %s: %w", redisKey, err))
+		return true
+	}
+	switch {
+	case rec.Hash != hash:
+		httpError(w, r, "Idempotency-Key was already used with a different request", http.StatusConflict)
+	case rec.Status == 0:
+		httpError(w, r, "A request with this Idempotency-Key is still in progress", http.StatusConflict)
+	default:
+		if rec.ContentType != "" {
+			w.Header().Set("Content-Type", rec.ContentType)
+		}
+		w.Header().Set("Idempotent-Replayed", "true")
+		w.WriteHeader(rec.Status)
+		w.Write(rec.Body)
+	}
+	return true
+}
+
+// requestHash - отпечаток запроса: метод, путь и тело.
+func requestHash(r *http.Request, body []byte) string {
+	sum := sha256.New()
+	fmt.Fprintf(sum, "%s %s\n", r.Method, r.URL.Path)
+	sum.Write(body)
+	return hex.EncodeToString(sum.Sum(nil))
+}
+
+// responseRecorder пишет ответ клиенту и запоминает статус и тело.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	r.body.Write(p)
+	return r.ResponseWriter.Write(p)
+}
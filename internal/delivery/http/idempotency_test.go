@@ -0,0 +1,188 @@
+package httpHandler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func taskBody(title string) string {
+	deadline := time.Now().Add(time.Hour).Format("02.01.2006 15:04")
+	return `{"title":"` + title + `","deadline":"` + deadline + `"}`
+}
+
+func TestIdempotency_ReplaysResponse(t *testing.T) {
+	api := newTestAPI(t)
+	body := taskBody("Купить хлеб")
+
+	first := api.do("POST", "/tasks", body, idempotencyHeader, "key-1")
+	second := api.do("POST", "/tasks", body, idempotencyHeader, "key-1")
+
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, "true", second.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Len(t, api.repo.Tasks(), 1)
+	assert.Equal(t, idempotencyTTL, api.redis.TTL("idempotency:42:key-1"))
+}
+
+func TestIdempotency_ReplaysValidationError(t *testing.T) {
+	api := newTestAPI(t)
+	body := `{"title":"Купить хлеб","deadline":"вчера"}`
+
+	first := api.do("POST", "/tasks", body, idempotencyHeader, "key-1")
+	second := api.do("POST", "/tasks", body, idempotencyHeader, "key-1")
+
+	assert.Equal(t, http.StatusUnprocessableEntity, first.Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, second.Code)
+	assert.Equal(t, problemContentType, second.Header().Get("Content-Type"))
+	assert.Equal(t, first.Body.String(), second.Body.String())
+}
+
+func TestIdempotency_DifferentBodyConflicts(t *testing.T) {
+	api := newTestAPI(t)
+
+	api.do("POST", "/tasks", taskBody("Купить хлеб"), idempotencyHeader, "key-1")
+	w := api.do("POST", "/tasks", taskBody("Купить молоко"), idempotencyHeader, "key-1")
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, "Idempotency-Key was already used with a different request", decodeProblem(t, w).Detail)
+	assert.Len(t, api.repo.Tasks(), 1)
+}
+
+func TestIdempotency_InProgressConflicts(t *testing.T) {
+	api := newTestAPI(t)
+	body := taskBody("Купить хлеб")
+	require.NoError(t, api.redis.Set("idempotency:42:key-1", `{"hash":"`+requestHash(httptest.NewRequest("POST", "/tasks", nil), []byte(body))+`"}`))
+
+	w := api.do("POST", "/tasks", body, idempotencyHeader, "key-1")
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, "A request with this Idempotency-Key is still in progress", decodeProblem(t, w).Detail)
+	assert.Empty(t, api.repo.Tasks())
+}
+
+// serveIdempotent выполняет next под idempotent с отменяемым контекстом
+// запроса. next получает функцию отмены, чтобы изобразить обрыв соединения.
+func serveIdempotent(t *testing.T, api *testAPI, next func(w http.ResponseWriter, cancel context.CancelFunc)) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.WithValue(t.Context(), userIDKey, int64(testUserID)))
+	defer cancel()
+	r := httptest.NewRequestWithContext(ctx, "POST", "/tasks", nil)
+	r.Header.Set(idempotencyHeader, "key-1")
+	handler := NewHandler(api.service).idempotent(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Пока запрос выполняется, ключ занят ненадолго
+		assert.Equal(t, idempotencyPendingTTL, api.redis.TTL("idempotency:42:key-1"))
+		next(w, cancel)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+}
+
+func TestIdempotency_SavesAfterClientGone(t *testing.T) {
+	api := newTestAPI(t)
+
+	serveIdempotent(t, api, func(w http.ResponseWriter, cancel context.CancelFunc) {
+		cancel()
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	assert.Equal(t, idempotencyTTL, api.redis.TTL("idempotency:42:key-1"))
+	raw, err := api.redis.Get("idempotency:42:key-1")
+	require.NoError(t, err)
+	assert.Contains(t, raw, `"status":201`)
+}
+
+func TestIdempotency_ReleasesAfterClientGone(t *testing.T) {
+	api := newTestAPI(t)
+
+	serveIdempotent(t, api, func(w http.ResponseWriter, cancel context.CancelFunc) {
+		cancel()
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	assert.False(t, api.redis.Exists("idempotency:42:key-1"))
+}
+
+func TestIdempotency_ReleasesOnPanic(t *testing.T) {
+	api := newTestAPI(t)
+
+	assert.Panics(t, func() {
+		serveIdempotent(t, api, func(http.ResponseWriter, context.CancelFunc) { panic("boom") })
+	})
+
+	assert.False(t, api.redis.Exists("idempotency:42:key-1"))
+}
+
+func TestIdempotency_RetriesReleasedKey(t *testing.T) {
+	api := newTestAPI(t)
+	body := taskBody("Купить хлеб")
+	const key = "idempotency:42:key-1"
+	require.NoError(t, api.redis.Set(key, `{"hash":"`+requestHash(httptest.NewRequest("POST", "/tasks", nil), []byte(body))+`"}`))
+
+	// Первый запрос освобождает ключ между SETNX и GET второго
+	var once sync.Once
+	api.redis.Server().SetPreHook(func(_ *server.Peer, cmd string, args ...string) bool {
+		if cmd == "GET" && len(args) == 1 && args[0] == key {
+			once.Do(func() { api.redis.Del(key) })
+		}
+		return false
+	})
+
+	w := api.do("POST", "/tasks", body, idempotencyHeader, "key-1")
+
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Len(t, api.repo.Tasks(), 1)
+	assert.Equal(t, idempotencyTTL, api.redis.TTL(key))
+}
+
+func TestIdempotency_KeysArePerUser(t *testing.T) {
+	api := newTestAPI(t)
+	body := taskBody("Купить хлеб")
+	api.do("POST", "/tasks", body, idempotencyHeader, "key-1")
+
+	token, err := api.service.GenerateToken(7, time.Minute)
+	require.NoError(t, err)
+	api.token = token
+	w := api.do("POST", "/tasks", body, idempotencyHeader, "key-1")
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+	tasks := api.repo.Tasks()
+	require.Len(t, tasks, 2)
+	assert.Equal(t, int64(7), tasks[1].UserID)
+}
+
+func TestIdempotency_WithoutKey(t *testing.T) {
+	api := newTestAPI(t)
+	body := taskBody("Купить хлеб")
+
+	api.do("POST", "/tasks", body)
+	api.do("POST", "/tasks", body)
+
+	assert.Len(t, api.repo.Tasks(), 2)
+	for _, key := range api.redis.Keys() {
+		assert.NotContains(t, key, "idempotency:")
+	}
+}
+
+func TestIdempotency_KeyTooLong(t *testing.T) {
+	api := newTestAPI(t)
+	key := make([]byte, maxIdempotencyKey+1)
+	for i := range key {
+		key[i] = 'k'
+	}
+
+	w := api.do("POST", "/tasks", taskBody("Купить хлеб"), idempotencyHeader, string(key))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, idempotencyHeader, decodeProblem(t, w).Errors[0].Field)
+	assert.Empty(t, api.repo.Tasks())
+}
@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"net/http"
 	"strings"
+	"task-traker/internal/i18n"
 	"time"
 )
 
@@ -20,20 +21,35 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// LanguageMiddleware выбирает язык текстов ошибок по заголовку
+// Accept-Language. Без заголовка API отвечает по-английски.
+func LanguageMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromAcceptLanguage(r.Header.Get("Accept-Language"), i18n.EN)
+		w.Header().Add("Vary", "Accept-Language")
+		next.ServeHTTP(w, r.WithContext(i18n.WithLang(r.Context(), lang)))
+	})
+}
+
 func (h *Handler) authMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+		// EventSource и WebSocket в браузере не умеют задавать заголовки,
+		// поэтому потокам событий токен можно передать в access_token
+		if authHeader == "" && isStreamRequest(r) {
+			tokenString = r.URL.Query().Get("access_token")
+		}
 
 		bad, _ := h.service.Redis.GetToken(r.Context(), "blacklist:"+tokenString)
 		if bad != "" {
-			http.Error(w, "Token is invalidated (logged out)", http.StatusUnauthorized)
+			httpError(w, r, "Token is invalidated (logged out)", http.StatusUnauthorized)
 			return
 		}
 
 		userID, err := h.service.VerifyToken(tokenString)
 		if err != nil {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			httpError(w, r, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
 
@@ -42,3 +58,8 @@ func (h *Handler) authMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// isStreamRequest - запрос открывает поток событий (SSE или WebSocket).
+func isStreamRequest(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/event-stream") || isWebSocketUpgrade(r)
+}
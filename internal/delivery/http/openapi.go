@@ -0,0 +1,122 @@
+package httpHandler
+
+import (
+	"compress/gzip"
+	_ "embed"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/swaggest/swgui/v5/static"
+)
+
+// openAPISpec - описание API. Его пути и методы сверяются с routes
+// в TestOpenAPIMatchesRoutes.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// docsPage - Swagger UI для openapi.json. Скрипты и стили swagger-ui-dist
+// встроены в бинарник и отдаются с /docs/, внешние CDN не нужны.
+//
+//go:embed docs.html
+var docsPage []byte
+
+// route - эндпоинт API. auth - доступ только с access-токеном,
+// idempotent - поддерживается заголовок Idempotency-Key.
+type route struct {
+	method     string
+	path       string
+	handler    http.Handler
+	auth       bool
+	idempotent bool
+}
+
+// routes - все эндпоинты сервера. По этой таблице строится роутер.
+func (h *Handler) routes() []route {
+	return []route{
+		{"GET", "/tasks", http.HandlerFunc(h.getTasks), true, false},
+		{"POST", "/tasks", http.HandlerFunc(h.createTask), true, true},
+		{"POST", "/tasks/batch", http.HandlerFunc(h.batchTasks), true, true},
+		{"GET", "/tasks/{id}", http.HandlerFunc(h.getTask), true, false},
+		{"PATCH", "/tasks/{id}", http.HandlerFunc(h.patchTask), true, false},
+		{"DELETE", "/tasks/{id}", http.HandlerFunc(h.deleteTasks), true, false},
+		{"GET", "/stats", http.HandlerFunc(h.getStats), true, false},
+		{"GET", "/webhooks", http.HandlerFunc(h.listWebhooks), true, false},
+		{"POST", "/webhooks", http.HandlerFunc(h.createWebhook), true, false},
+		{"DELETE", "/webhooks/{id}", http.HandlerFunc(h.deleteWebhook), true, false},
+		{"GET", "/webhooks/{id}/deliveries", http.HandlerFunc(h.webhookDeliveries), true, false},
+		{"POST", "/webhooks/{id}/ping", http.HandlerFunc(h.pingWebhook), true, false},
+		{"GET", "/events", http.HandlerFunc(h.streamEvents), true, false},
+		{"GET", "/ws", http.HandlerFunc(h.streamWebSocket), true, false},
+		{"POST", "/login", h.limitAuth(h.limitLoginUser(http.HandlerFunc(h.login))), false, false},
+		{"POST", "/auth/refresh", h.limitAuth(http.HandlerFunc(h.Refresh)), false, false},
+		{"POST", "/logout", http.HandlerFunc(h.Logout), true, false},
+		{"GET", "/openapi.json", http.HandlerFunc(serveOpenAPI), false, false},
+		{"GET", "/docs", http.HandlerFunc(serveDocs), false, false},
+		{"GET", "/docs/{file}", http.HandlerFunc(serveDocsAsset), false, false},
+	}
+}
+
+// MountWebhook возвращает обработчик, который отдает POST path в webhook,
+// а остальные запросы - в api. Путь не может быть корнем или начинаться
+// с того же сегмента, что и маршрут API: шаблон "POST path" точнее "/"
+// и перехватил бы запросы API.
+func (h *Handler) MountWebhook(api http.Handler, path string, webhook http.Handler) (http.Handler, error) {
+	first := firstSegment(path)
+	if first == "" {
+		return nil, fmt.Errorf("webhook path %q must not be the root", path)
+	}
+	for _, rt := range h.routes() {
+		if firstSegment(rt.path) == first {
+			return nil, fmt.Errorf("webhook path %q overlaps API route %s %s", path, rt.method, rt.path)
+		}
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/", api)
+	mux.Handle("POST "+path, webhook)
+	return mux, nil
+}
+
+// firstSegment - первый сегмент пути: "tasks" для "/tasks/{id}".
+func firstSegment(path string) string {
+	first, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	return first
+}
+
+func serveOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPISpec)
+}
+
+func serveDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(docsPage)
+}
+
+// serveDocsAsset отдает файл Swagger UI. Файлы хранятся сжатыми, клиентам
+// без gzip они распаковываются на лету.
+func serveDocsAsset(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("file")
+	f, err := static.FS.Open(name + ".gz")
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", mime.TypeByExtension(path.Ext(name)))
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.Header().Add("Vary", "Accept-Encoding")
+	var body io.Reader = f
+	if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		w.Header().Set("Content-Encoding", "gzip")
+	} else if body, err = gzip.NewReader(f); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	io.Copy(w, body)
+}
@@ -0,0 +1,198 @@
+package httpHandler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// undocumented - служебные маршруты, которых нет в openapi.json.
+var undocumented = map[string]bool{
+	"GET /docs":        true,
+	"GET /docs/{file}": true,
+}
+
+type specOperation struct {
+	Security   []map[string][]string `json:"security"`
+	Parameters []struct {
+		Ref string `json:"$ref"`
+	} `json:"parameters"`
+}
+
+// access - авторизация и идемпотентность операции.
+type access struct {
+	auth, idempotent bool
+}
+
+func (op specOperation) access() access {
+	a := access{auth: len(op.Security) > 0}
+	for _, p := range op.Parameters {
+		a.idempotent = a.idempotent || p.Ref == "#/components/parameters/IdempotencyKey"
+	}
+	return a
+}
+
+func loadSpec(t *testing.T) map[string]map[string]json.RawMessage {
+	t.Helper()
+	var spec struct {
+		OpenAPI string                                `json:"openapi"`
+		Paths   map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(openAPISpec, &spec))
+	require.True(t, strings.HasPrefix(spec.OpenAPI, "3."))
+	return spec.Paths
+}
+
+// TestOpenAPIMatchesRoutes проверяет, что openapi.json описывает ровно те
+// маршруты, что есть в роутере, с той же авторизацией и поддержкой
+// Idempotency-Key.
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	paths := loadSpec(t)
+	documented := make(map[string]access)
+	for path, item := range paths {
+		for method, raw := range item {
+			if method == "parameters" {
+				continue
+			}
+			var op specOperation
+			require.NoError(t, json.Unmarshal(raw, &op), path)
+			documented[strings.ToUpper(method)+" "+path] = op.access()
+		}
+	}
+
+	h := NewHandler(nil)
+	routed := make(map[string]access)
+	for _, rt := range h.routes() {
+		key := rt.method + " " + rt.path
+		if !undocumented[key] {
+			routed[key] = access{auth: rt.auth, idempotent: rt.idempotent}
+		}
+	}
+	assert.Equal(t, routed, documented)
+}
+
+// TestOpenAPIPathsAreRouted проходит по операциям из openapi.json через
+// настоящий роутер: ни одна не должна получить 404 или 405 от ServeMux.
+func TestOpenAPIPathsAreRouted(t *testing.T) {
+	router := newTestAPI(t).handler
+	param := regexp.MustCompile(`\{[^}]+\}`)
+	for path, item := range loadSpec(t) {
+		for method := range item {
+			method = strings.ToUpper(method)
+			r := httptest.NewRequest(method, param.ReplaceAllString(path, "1"), strings.NewReader("{}"))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			// Ответы самого ServeMux - text/plain, а ответы API - JSON
+			notRouted := (w.Code == http.StatusNotFound || w.Code == http.StatusMethodNotAllowed) &&
+				strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain")
+			assert.False(t, notRouted, "%s %s: %d %s", method, path, w.Code, w.Body)
+		}
+	}
+}
+
+// TestOpenAPIRefsResolve проверяет, что все $ref указывают на существующие
+// компоненты.
+func TestOpenAPIRefsResolve(t *testing.T) {
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(openAPISpec, &doc))
+
+	var walk func(v any)
+	refs := 0
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			if ref, ok := v["$ref"].(string); ok {
+				refs++
+				var target any = doc
+				for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+					m, _ := target.(map[string]any)
+					target = m[part]
+				}
+				assert.NotNil(t, target, ref)
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(doc)
+	assert.Greater(t, refs, 0)
+}
+
+func TestServeOpenAPIAndDocs(t *testing.T) {
+	api := newTestAPI(t)
+
+	w := api.do("GET", "/openapi.json", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, string(openAPISpec), w.Body.String())
+
+	w = api.do("GET", "/docs", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, w.Body.String(), `url: "openapi.json"`)
+	assert.NotContains(t, w.Body.String(), "https://")
+}
+
+func TestServeDocsAssets(t *testing.T) {
+	api := newTestAPI(t)
+
+	// Страница ссылается только на встроенные файлы
+	for _, src := range regexp.MustCompile(`(?:href|src)="docs/([^"]+)"`).FindAllStringSubmatch(string(docsPage), -1) {
+		w := api.do("GET", "/docs/"+src[1], "")
+		assert.Equal(t, http.StatusOK, w.Code, src[1])
+		assert.NotEmpty(t, w.Body.Bytes(), src[1])
+	}
+
+	w := api.do("GET", "/docs/swagger-ui.css", "", "Accept-Encoding", "gzip")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/css")
+	w = api.do("GET", "/docs/swagger-ui.css", "")
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Contains(t, w.Body.String(), ".swagger-ui")
+
+	assert.Equal(t, http.StatusNotFound, api.do("GET", "/docs/missing.js", "").Code)
+}
+
+func TestDebugVarsNotPublic(t *testing.T) {
+	api := newTestAPI(t)
+
+	// Метрики expvar отдает только служебный сервер DEBUG_ADDR
+	w := api.do("GET", "/debug/vars", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.NotContains(t, w.Body.String(), "cmdline")
+}
+
+func TestMountWebhook(t *testing.T) {
+	api := newTestAPI(t)
+	h := NewHandler(api.service)
+	webhook := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	for _, path := range []string{"/", "", "/tasks", "/tasks/hook", "/login", "/webhooks/telegram", "/docs"} {
+		_, err := h.MountWebhook(api.handler, path, webhook)
+		assert.Error(t, err, path)
+	}
+
+	api.handler, _ = h.MountWebhook(api.handler, "/telegram/webhook", webhook)
+	require.NotNil(t, api.handler)
+	assert.Equal(t, http.StatusTeapot, api.do("POST", "/telegram/webhook", "{}").Code)
+	// Запросы API по-прежнему доходят до API
+	w := api.do("POST", "/tasks", `{"title":"Купить хлеб","deadline":"31.12.2099 18:00"}`)
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, http.StatusOK, api.do("GET", "/tasks", "").Code)
+	assert.Equal(t, http.StatusBadRequest, api.do("POST", "/login", "{").Code)
+}
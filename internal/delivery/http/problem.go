@@ -0,0 +1,106 @@
+package httpHandler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"task-traker/internal/domain"
+	"task-traker/internal/i18n"
+	"task-traker/internal/service"
+)
+
+// problemContentType - тип ответа с ошибкой по RFC 7807.
+const problemContentType = "application/problem+json"
+
+// Problem - тело ответа с ошибкой (RFC 7807). Type всегда about:blank,
+// поэтому Title - стандартный текст статуса, а подробности - в Detail
+// на языке из Accept-Language.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// Errors - ошибки отдельных полей запроса.
+	Errors []ProblemField `json:"errors,omitempty"`
+}
+
+type ProblemField struct {
+	Field  string `json:"field"`
+	Detail string `json:"detail"`
+}
+
+func writeProblem(w http.ResponseWriter, r *http.Request, p Problem) {
+	p.Type = "about:blank"
+	p.Title = http.StatusText(p.Status)
+	p.Instance = r.URL.Path
+	w.Header().Set("Content-Type", problemContentType)
+	w.Header().Set("Content-Language", string(i18n.FromContext(r.Context())))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	if err := json.NewEncoder(w).Encode(p); err != nil {
+		slog.Error("JSON encode error", "error", err)
+	}
+}
+
+// httpError отвечает ошибкой со статусом code и текстом msg на языке
+// из Accept-Language.
+func httpError(w http.ResponseWriter, r *http.Request, msg string, code int) {
+	lang := i18n.FromContext(r.Context())
+	writeProblem(w, r, Problem{Status: code, Detail: lang.T(msg)})
+}
+
+// paramError отвечает 400 на некорректный параметр запроса field.
+func paramError(w http.ResponseWriter, r *http.Request, field, msg string) {
+	detail := i18n.FromContext(r.Context()).T(msg)
+	writeProblem(w, r, Problem{
+		Status: http.StatusBadRequest,
+		Detail: detail,
+		Errors: []ProblemField{{Field: field, Detail: detail}},
+	})
+}
+
+// writeError отвечает на ошибку сервиса, см. problemFor.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	writeProblem(w, r, problemFor(r, err))
+}
+
+// problemFor описывает ошибку сервиса: ошибки проверки - 422 с текстом
+// по каждому полю, остальные виды - 404, 409 и 401. Прочие ошибки
+// записываются в лог, а клиент получает 500 без подробностей.
+func problemFor(r *http.Request, err error) Problem {
+	lang := i18n.FromContext(r.Context())
+	var verr *service.ValidationError
+	if errors.As(err, &verr) {
+		p := Problem{Status: http.StatusUnprocessableEntity, Detail: lang.T("Request validation failed")}
+		for _, f := range verr.Fields {
+			msg, _ := lang.Error(f.Err)
+			p.Errors = append(p.Errors, ProblemField{Field: f.Field, Detail: msg})
+		}
+		return p
+	}
+
+	p := Problem{Status: http.StatusInternalServerError}
+	switch {
+	case errors.Is(err, domain.ErrTaskNotFound):
+		p.Status, p.Detail = http.StatusNotFound, lang.T("Task not found")
+	case errors.Is(err, domain.ErrVersionConflict):
+		p.Status, p.Detail = http.StatusConflict, lang.T("Task was modified by another request")
+	case errors.Is(err, domain.ErrWebhookNotFound):
+		p.Status, p.Detail = http.StatusNotFound, lang.T("Webhook not found")
+	case errors.Is(err, service.ErrNotFound):
+		p.Status = http.StatusNotFound
+	case errors.Is(err, service.ErrConflict):
+		p.Status = http.StatusConflict
+	case errors.Is(err, service.ErrUnauthorized):
+		p.Status = http.StatusUnauthorized
+	default:
+		slog.Error("HTTP request failed", "method", r.Method, "path", r.URL.Path, "error", err)
+		p.Detail = lang.T("Internal Server Error")
+	}
+	if msg, ok := lang.Error(err); ok && p.Detail == "" {
+		p.Detail = msg
+	}
+	return p
+}
@@ -0,0 +1,134 @@
+package httpHandler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimit - не больше Limit запросов за скользящее окно Window. Если
+// Lockout не 0, превышение лимита блокирует ключ на Lockout целиком.
+type RateLimit struct {
+	Limit   int
+	Window  time.Duration
+	Lockout time.Duration
+}
+
+// RateLimits - лимиты запросов к API. Счетчики хранятся в Redis и общие
+// для всех реплик.
+type RateLimits struct {
+	// IP - все запросы с одного адреса.
+	IP RateLimit
+	// User - запросы с токеном одного пользователя.
+	User RateLimit
+	// Auth - POST /login и POST /auth/refresh с одного адреса. Лимит
+	// строгий: код входа из шести цифр иначе можно перебрать.
+	Auth RateLimit
+	// LoginUser - POST /login с одним user_id с любых адресов: лимит Auth
+	// не мешает перебирать код одного пользователя со многих адресов.
+	LoginUser RateLimit
+}
+
+var DefaultRateLimits = RateLimits{
+	IP:        RateLimit{Limit: 600, Window: time.Minute},
+	User:      RateLimit{Limit: 300, Window: time.Minute},
+	Auth:      RateLimit{Limit: 20, Window: 10 * time.Minute, Lockout: 15 * time.Minute},
+	LoginUser: RateLimit{Limit: 10, Window: 10 * time.Minute},
+}
+
+// maxLoginBody - сколько тела POST /login читает limitLoginUser.
+const maxLoginBody = 1 << 10
+
+// limitIP ограничивает запросы с одного адреса по h.Limits.IP.
+func (h *Handler) limitIP(next http.Handler) http.Handler {
+	return h.rateLimited("ip", &h.Limits.IP, h.clientIP, next)
+}
+
+// limitUser ограничивает запросы пользователя по h.Limits.User. Работает
+// после authMiddleware, которая кладет в контекст id пользователя.
+func (h *Handler) limitUser(next http.Handler) http.Handler {
+	userKey := func(r *http.Request) string {
+		userID, _ := r.Context().Value(userIDKey).(int64)
+		return strconv.FormatInt(userID, 10)
+	}
+	return h.rateLimited("user", &h.Limits.User, userKey, next)
+}
+
+// limitAuth - строгий лимит h.Limits.Auth для входа и обновления токенов.
+func (h *Handler) limitAuth(next http.Handler) http.Handler {
+	return h.rateLimited("auth", &h.Limits.Auth, h.clientIP, next)
+}
+
+// limitLoginUser ограничивает вход пользователя user_id из тела запроса
+// по h.Limits.LoginUser. Тело остается обработчику целиком.
+func (h *Handler) limitLoginUser(next http.Handler) http.Handler {
+	userKey := func(r *http.Request) string {
+		head, _ := io.ReadAll(io.LimitReader(r.Body, maxLoginBody))
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(head), r.Body), r.Body}
+		var req loginRequest
+		json.Unmarshal(head, &req)
+		return strconv.FormatInt(req.UserID, 10)
+	}
+	return h.rateLimited("login_user", &h.Limits.LoginUser, userKey, next)
+}
+
+// rateLimited пропускает запрос, если он укладывается в лимит для ключа
+// key(r), иначе отвечает 429 с Retry-After. Лимит читается при каждом
+// запросе, поэтому h.Limits можно менять после InitRouter. Если Redis
+// недоступен, запрос пропускается: API важнее лимитов.
+func (h *Handler) rateLimited(name string, limit *RateLimit, key func(*http.Request) string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l := *limit
+		if l.Limit <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		redisKey := fmt.Sprintf("ratelimit:%s:%s", name, key(r))
+		ok, retryAfter, err := h.service.Redis.AllowRate(r.Context(), redisKey, time.Now(), l.Limit, l.Window, l.Lockout)
+		if err != nil {
+			slog.Error("Rate limit check failed", "key", redisKey, "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !ok {
+			slog.Warn("Rate limit exceeded", "key", redisKey, "retry_after", retryAfter)
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(max(retryAfter, time.Second).Seconds()))))
+			httpError(w, r, "Too many requests, try again later", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientIP - адрес клиента. С TrustProxy берется последний адрес из
+// X-Forwarded-For: его добавил наш прокси, а более ранние мог подставить
+// сам клиент.
+func (h *Handler) clientIP(r *http.Request) string {
+	if h.TrustProxy {
+		if fwd := r.Header.Values("X-Forwarded-For"); len(fwd) > 0 {
+			last := fwd[len(fwd)-1]
+			if i := strings.LastIndexByte(last, ','); i >= 0 {
+				last = last[i+1:]
+			}
+			if ip := strings.TrimSpace(last); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,151 @@
+package httpHandler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withLimits пересобирает роутер тестового API с лимитами limits.
+func (a *testAPI) withLimits(limits RateLimits, trustProxy bool) {
+	h := NewHandler(a.service)
+	h.Limits, h.TrustProxy = limits, trustProxy
+	a.handler = h.InitRouter()
+}
+
+// from выполняет анонимный запрос с адреса remoteAddr.
+func (a *testAPI) from(remoteAddr, method, target, body string, header ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.RemoteAddr = remoteAddr
+	for i := 0; i+1 < len(header); i += 2 {
+		r.Header.Add(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	a.handler.ServeHTTP(w, r)
+	return w
+}
+
+func retryAfter(t *testing.T, w *httptest.ResponseRecorder) time.Duration {
+	t.Helper()
+	secs, err := strconv.Atoi(w.Header().Get("Retry-After"))
+	require.NoError(t, err, "Retry-After: %q", w.Header().Get("Retry-After"))
+	return time.Duration(secs) * time.Second
+}
+
+func TestRateLimit_PerUser(t *testing.T) {
+	api := newTestAPI(t)
+	api.withLimits(RateLimits{User: RateLimit{Limit: 2, Window: time.Minute}}, false)
+
+	for range 2 {
+		assert.Equal(t, http.StatusOK, api.do("GET", "/tasks", "").Code)
+	}
+	w := api.do("GET", "/tasks", "", "Accept-Language", "ru")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "Слишком много запросов, повторите позже", decodeProblem(t, w).Detail)
+	assert.InDelta(t, time.Minute.Seconds(), retryAfter(t, w).Seconds(), 2)
+
+	// У другого пользователя свой лимит
+	token, err := api.service.GenerateToken(testUserID+1, time.Minute)
+	require.NoError(t, err)
+	api.token = token
+	assert.Equal(t, http.StatusOK, api.do("GET", "/tasks", "").Code)
+}
+
+func TestRateLimit_PerIP(t *testing.T) {
+	api := newTestAPI(t)
+	api.withLimits(RateLimits{IP: RateLimit{Limit: 2, Window: time.Minute}}, false)
+
+	for range 2 {
+		assert.Equal(t, http.StatusOK, api.from("203.0.113.5:1000", "GET", "/openapi.json", "").Code)
+	}
+	// Лимит на адрес, а не на соединение
+	w := api.from("203.0.113.5:2000", "GET", "/openapi.json", "")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	decodeProblem(t, w)
+	assert.Equal(t, http.StatusOK, api.from("203.0.113.6:1000", "GET", "/openapi.json", "").Code)
+
+	// Без TrustProxy X-Forwarded-For не меняет адрес
+	w = api.from("203.0.113.5:1000", "GET", "/openapi.json", "", "X-Forwarded-For", "198.51.100.1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
+
+func TestRateLimit_TrustProxy(t *testing.T) {
+	api := newTestAPI(t)
+	api.withLimits(RateLimits{IP: RateLimit{Limit: 1, Window: time.Minute}}, true)
+	const proxy = "10.0.0.2:5000"
+
+	// Адрес клиента - последний в X-Forwarded-For, подставленные клиентом не в счет
+	w := api.from(proxy, "GET", "/openapi.json", "", "X-Forwarded-For", "1.1.1.1, 198.51.100.1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = api.from(proxy, "GET", "/openapi.json", "", "X-Forwarded-For", "2.2.2.2", "X-Forwarded-For", "198.51.100.1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	w = api.from(proxy, "GET", "/openapi.json", "", "X-Forwarded-For", "198.51.100.2")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestRateLimit_LoginLockout(t *testing.T) {
+	api := newTestAPI(t)
+	api.withLimits(RateLimits{Auth: RateLimit{Limit: 3, Window: time.Minute, Lockout: 15 * time.Minute}}, false)
+	const client = "203.0.113.5:1000"
+	code, err := api.service.GenerateAuthCode(context.Background(), testUserID)
+	require.NoError(t, err)
+	wrong := `{"user_id":42,"code":"000000"}`
+	if code == "000000" {
+		wrong = `{"user_id":42,"code":"111111"}`
+	}
+
+	for range 3 {
+		assert.Equal(t, http.StatusUnauthorized, api.from(client, "POST", "/login", wrong).Code)
+	}
+	// Адрес заблокирован целиком, даже с верным кодом и для /auth/refresh
+	w := api.from(client, "POST", "/login", `{"user_id":42,"code":"`+code+`"}`)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, 15*time.Minute, retryAfter(t, w))
+	w = api.from(client, "POST", "/auth/refresh", `{"refresh_token":"x"}`)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	// Остальной API лимит входа не трогает
+	assert.Equal(t, http.StatusOK, api.from(client, "GET", "/openapi.json", "").Code)
+
+	// Код входа живет меньше блокировки, после нее нужен новый
+	api.redis.FastForward(15 * time.Minute)
+	code, err = api.service.GenerateAuthCode(context.Background(), testUserID)
+	require.NoError(t, err)
+	w = api.from(client, "POST", "/login", `{"user_id":42,"code":"`+code+`"}`)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
+
+func TestRateLimit_LoginPerUser(t *testing.T) {
+	api := newTestAPI(t)
+	api.withLimits(RateLimits{LoginUser: RateLimit{Limit: 2, Window: time.Minute}}, false)
+	wrong := func(userID int64) string { return fmt.Sprintf(`{"user_id":%d,"code":"x"}`, userID) }
+
+	// Перебор кода одного пользователя с разных адресов
+	assert.Equal(t, http.StatusUnauthorized, api.from("203.0.113.5:1000", "POST", "/login", wrong(testUserID)).Code)
+	assert.Equal(t, http.StatusUnauthorized, api.from("203.0.113.6:1000", "POST", "/login", wrong(testUserID)).Code)
+	w := api.from("203.0.113.7:1000", "POST", "/login", wrong(testUserID))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	decodeProblem(t, w)
+
+	// У другого пользователя свой лимит, а тело доходит до обработчика целиком
+	assert.Equal(t, http.StatusUnauthorized, api.from("203.0.113.7:1000", "POST", "/login", wrong(testUserID+1)).Code)
+	w = api.from("203.0.113.7:1000", "POST", "/login", `{"user_id":7,"code":"`+strings.Repeat("1", 2*maxLoginBody)+`"}`)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestRateLimit_DisabledLimit(t *testing.T) {
+	api := newTestAPI(t)
+	api.withLimits(RateLimits{}, false)
+
+	for range 5 {
+		assert.Equal(t, http.StatusOK, api.do("GET", "/tasks", "").Code)
+	}
+	assert.Empty(t, api.redis.Keys())
+}
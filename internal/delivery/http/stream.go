@@ -0,0 +1,196 @@
+package httpHandler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+const (
+	// streamHeartbeat - как часто поток шлет пустое сообщение, чтобы прокси
+	// не закрыли простаивающее соединение, а сервер заметил ушедшего клиента.
+	streamHeartbeat = 25 * time.Second
+	// streamWriteTimeout - сколько ждать записи одного события в поток.
+	streamWriteTimeout = 10 * time.Second
+	// sseRetry - через сколько миллисекунд EventSource переподключается.
+	sseRetry = 3000
+)
+
+// CloseStreams завершает открытые потоки событий. http.Server.Shutdown
+// не ждет их сам: SSE-запросы не заканчиваются, а WebSocket-соединения
+// перехвачены (Hijack). Вызывается через RegisterOnShutdown.
+func (h *Handler) CloseStreams() {
+	h.stopStreams()
+}
+
+// streamEvents отдает события задач пользователя потоком Server-Sent
+// Events: на каждое событие - сообщение с именем его типа и JSON в data.
+func (h *Handler) streamEvents(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(userIDKey).(int64)
+	if !ok {
+		slog.Error("UserID not found in context")
+		httpError(w, r, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if h.service.Events == nil {
+		httpError(w, r, "Event stream is not available", http.StatusServiceUnavailable)
+		return
+	}
+	sub := h.service.Events.Subscribe(userID)
+	defer sub.Close()
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// nginx иначе копит поток в буфере
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	write := func(format string, args ...any) bool {
+		// WriteTimeout сервера рассчитан на обычные запросы, поэтому срок
+		// продлевается на каждую запись
+		rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		if _, err := fmt.Fprintf(w, format, args...); err != nil {
+			return false
+		}
+		return rc.Flush() == nil
+	}
+	if !write("retry: %d\n\n", sseRetry) {
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.streams.Done():
+			return
+		case <-heartbeat.C:
+			if !write(": ping\n\n") {
+				return
+			}
+		case e, ok := <-sub.C:
+			if !ok {
+				// Клиент не успевал читать: EventSource переподключится
+				return
+			}
+			data, err := json.Marshal(e)
+			if err != nil {
+				slog.Error("JSON encode error", "error", err)
+				continue
+			}
+			if !write("event: %s\ndata: %s\n\n", e.Type, data) {
+				return
+			}
+		}
+	}
+}
+
+// streamWebSocket отдает те же события, что и streamEvents, через
+// WebSocket: каждое событие - текстовое сообщение с JSON. Сообщения
+// клиента не нужны и отбрасываются.
+func (h *Handler) streamWebSocket(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(userIDKey).(int64)
+	if !ok {
+		slog.Error("UserID not found in context")
+		httpError(w, r, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if !isWebSocketUpgrade(r) {
+		httpError(w, r, "WebSocket upgrade required", http.StatusUpgradeRequired)
+		return
+	}
+	if h.service.Events == nil {
+		httpError(w, r, "Event stream is not available", http.StatusServiceUnavailable)
+		return
+	}
+	// Подписка до рукопожатия: клиент не пропустит события сразу после него
+	sub := h.service.Events.Subscribe(userID)
+	defer sub.Close()
+	// Токен в ?access_token= может оказаться у чужой страницы, поэтому
+	// браузер с другим Origin пускается только по WSOrigins
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{OriginPatterns: h.WSOrigins})
+	if err != nil {
+		slog.Warn("WebSocket upgrade failed", "error", err)
+		return
+	}
+
+	closeCode, closeReason := websocket.StatusNormalClosure, ""
+	defer func() { conn.Close(closeCode, closeReason) }()
+
+	// Чтение нужно, чтобы отвечать на ping и заметить закрытие клиентом
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	go func() {
+		defer cancel()
+		for {
+			_, msg, err := conn.Reader(ctx)
+			if err != nil {
+				return
+			}
+			if _, err := io.Copy(io.Discard, msg); err != nil {
+				return
+			}
+		}
+	}()
+
+	send := func(write func(context.Context) error) bool {
+		ctx, cancel := context.WithTimeout(ctx, streamWriteTimeout)
+		defer cancel()
+		return write(ctx) == nil
+	}
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-h.streams.Done():
+			closeCode = websocket.StatusGoingAway
+			return
+		case <-heartbeat.C:
+			if !send(conn.Ping) {
+				return
+			}
+		case e, ok := <-sub.C:
+			if !ok {
+				closeCode, closeReason = websocket.StatusGoingAway, "too slow"
+				return
+			}
+			data, err := json.Marshal(e)
+			if err != nil {
+				slog.Error("JSON encode error", "error", err)
+				continue
+			}
+			if !send(func(ctx context.Context) error { return conn.Write(ctx, websocket.MessageText, data) }) {
+				return
+			}
+		}
+	}
+}
+
+// isWebSocketUpgrade - запрос на рукопожатие WebSocket (RFC 6455).
+func isWebSocketUpgrade(r *http.Request) bool {
+	return headerHasToken(r.Header, "Connection", "upgrade") && headerHasToken(r.Header, "Upgrade", "websocket")
+}
+
+// headerHasToken - в заголовке name есть токен token без учета регистра.
+func headerHasToken(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for t := range strings.SplitSeq(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
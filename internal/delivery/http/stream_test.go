@@ -0,0 +1,184 @@
+package httpHandler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"task-traker/internal/events"
+
+	"github.com/coder/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStreamServer запускает API с шиной событий на настоящем сервере:
+// потокам нужен Flush и Hijack.
+func newStreamServer(t *testing.T) (*testAPI, *httptest.Server) {
+	t.Helper()
+	api := newTestAPI(t)
+	api.service.Events = events.NewBus(nil)
+	srv := httptest.NewServer(api.handler)
+	t.Cleanup(srv.Close)
+	return api, srv
+}
+
+// readSSE читает из потока одно сообщение с событием, пропуская
+// служебные строки.
+func readSSE(t *testing.T, r *bufio.Reader) (name string, e events.Event) {
+	t.Helper()
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &e))
+		case line == "" && name != "":
+			return name, e
+		}
+	}
+}
+
+func TestStreamEvents_SSE(t *testing.T) {
+	api, srv := newStreamServer(t)
+
+	req, err := http.NewRequest("GET", srv.URL+"/events", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+api.token)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "no-cache", resp.Header.Get("Cache-Control"))
+
+	w := api.do("POST", "/tasks", taskBody("Купить хлеб"))
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	name, e := readSSE(t, bufio.NewReader(resp.Body))
+	assert.Equal(t, "task.created", name)
+	assert.Equal(t, events.TaskCreated, e.Type)
+	assert.Equal(t, int64(testUserID), e.UserID)
+	require.NotNil(t, e.Task)
+	assert.Equal(t, "Купить хлеб", e.Task.Title)
+}
+
+func TestStreamEvents_TokenInQuery(t *testing.T) {
+	api, srv := newStreamServer(t)
+
+	// Без Accept: text/event-stream токен из query не принимается
+	resp, err := http.Get(srv.URL + "/events?access_token=" + api.token)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	req, err := http.NewRequest("GET", srv.URL+"/events?access_token="+api.token, nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestStreamEvents_Unavailable(t *testing.T) {
+	api := newTestAPI(t)
+
+	w := api.do("GET", "/events", "")
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	decodeProblem(t, w)
+}
+
+func TestStreamWebSocket(t *testing.T) {
+	api, srv := newStreamServer(t)
+	url := "ws://" + strings.TrimPrefix(srv.URL, "http://") + "/ws?access_token=" + api.token
+
+	conn, _, err := websocket.Dial(t.Context(), url, nil)
+	require.NoError(t, err)
+	defer conn.CloseNow()
+
+	w := api.do("POST", "/tasks", taskBody("Позвонить"))
+	require.Equal(t, http.StatusCreated, w.Code)
+	id := api.repo.Tasks()[0].ID
+	w = api.do("DELETE", fmt.Sprintf("/tasks/%d", id), "")
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	var got []events.Type
+	for range 2 {
+		typ, data, err := conn.Read(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, websocket.MessageText, typ)
+		var e events.Event
+		require.NoError(t, json.Unmarshal(data, &e))
+		assert.Equal(t, id, e.TaskID)
+		got = append(got, e.Type)
+	}
+	assert.Equal(t, []events.Type{events.TaskCreated, events.TaskDeleted}, got)
+}
+
+func TestStreamWebSocket_Origin(t *testing.T) {
+	api := newTestAPI(t)
+	api.service.Events = events.NewBus(nil)
+	h := NewHandler(api.service)
+	h.WSOrigins = []string{"app.example.com"}
+	srv := httptest.NewServer(h.InitRouter())
+	defer srv.Close()
+	url := "ws://" + strings.TrimPrefix(srv.URL, "http://") + "/ws?access_token=" + api.token
+
+	dial := func(origin string) (*http.Response, error) {
+		conn, resp, err := websocket.Dial(t.Context(), url,
+			&websocket.DialOptions{HTTPHeader: http.Header{"Origin": {origin}}})
+		if err == nil {
+			conn.CloseNow()
+		}
+		return resp, err
+	}
+
+	resp, err := dial("https://evil.example.net")
+	require.Error(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	_, err = dial("https://app.example.com")
+	assert.NoError(t, err)
+	_, err = dial(srv.URL)
+	assert.NoError(t, err, "same host must be allowed")
+}
+
+func TestStreamWebSocket_Shutdown(t *testing.T) {
+	api := newTestAPI(t)
+	api.service.Events = events.NewBus(nil)
+	h := NewHandler(api.service)
+	srv := httptest.NewServer(h.InitRouter())
+	defer srv.Close()
+
+	conn, _, err := websocket.Dial(t.Context(), "ws://"+strings.TrimPrefix(srv.URL, "http://")+"/ws",
+		&websocket.DialOptions{HTTPHeader: http.Header{"Authorization": {"Bearer " + api.token}}})
+	require.NoError(t, err)
+	defer conn.CloseNow()
+
+	h.CloseStreams()
+	ctx, cancel := context.WithTimeout(t.Context(), 2*time.Second)
+	defer cancel()
+	_, _, err = conn.Read(ctx)
+	assert.Equal(t, websocket.StatusGoingAway, websocket.CloseStatus(err), "stream was not closed: %v", err)
+}
+
+func TestStreamWebSocket_RequiresUpgrade(t *testing.T) {
+	api := newTestAPI(t)
+	api.service.Events = events.NewBus(nil)
+
+	w := api.do("GET", "/ws", "")
+
+	assert.Equal(t, http.StatusUpgradeRequired, w.Code)
+	decodeProblem(t, w)
+}
@@ -0,0 +1,206 @@
+package httpHandler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"task-traker/internal/domain"
+	"time"
+)
+
+// pingWriteTimeout - срок записи ответа на ping. WriteTimeout сервера
+// отсчитывается с начала запроса и рассчитан на обычные запросы, а ping
+// ждет получателя (до Webhooks.PingTimeout) и пишет журнал.
+const pingWriteTimeout = 30 * time.Second
+
+type createWebhookRequest struct {
+	URL string `json:"url"`
+	// Secret - ключ подписи; если не задан, сервер создаст его сам.
+	Secret string `json:"secret"`
+	// Events - типы событий; пустой список - все события.
+	Events []string `json:"events"`
+}
+
+// webhookResponse - подписка в ответе. Secret отдается только при создании.
+type webhookResponse struct {
+	ID        int       `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type deliveryResponse struct {
+	ID           int64                 `json:"id"`
+	Event        string                `json:"event"`
+	Status       domain.DeliveryStatus `json:"status"`
+	Attempts     int                   `json:"attempts"`
+	ResponseCode int                   `json:"response_code,omitempty"`
+	Error        string                `json:"error,omitempty"`
+	// NextAttemptAt - время следующей попытки, только у ожидающих доставок.
+	NextAttemptAt *time.Time      `json:"next_attempt_at,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	DeliveredAt   *time.Time      `json:"delivered_at,omitempty"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+func newWebhookResponse(hook domain.Webhook) webhookResponse {
+	events := hook.Events
+	if events == nil {
+		events = []string{}
+	}
+	return webhookResponse{ID: hook.ID, URL: hook.URL, Events: events, CreatedAt: hook.CreatedAt}
+}
+
+func newDeliveryResponse(d domain.WebhookDelivery) deliveryResponse {
+	resp := deliveryResponse{
+		ID:           d.ID,
+		Event:        d.Event,
+		Status:       d.Status,
+		Attempts:     d.Attempts,
+		ResponseCode: d.ResponseCode,
+		Error:        d.Error,
+		CreatedAt:    d.CreatedAt,
+		DeliveredAt:  d.DeliveredAt,
+		Payload:      d.Payload,
+	}
+	if d.Status == domain.DeliveryPending {
+		resp.NextAttemptAt = &d.NextAttemptAt
+	}
+	return resp
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("JSON encode error", "error", err)
+	}
+}
+
+// webhookID разбирает id подписки из пути; при ошибке отвечает 400.
+func webhookID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		paramError(w, r, "id", "Invalid webhook ID")
+		return 0, false
+	}
+	return id, true
+}
+
+func (h *Handler) listWebhooks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(userIDKey).(int64)
+	if !ok {
+		slog.Error("UserID not found in context")
+		httpError(w, r, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	hooks, err := h.service.ListWebhooks(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	resp := make([]webhookResponse, len(hooks))
+	for i, hook := range hooks {
+		resp[i] = newWebhookResponse(hook)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"webhooks": resp})
+}
+
+// createWebhook подписывает пользователя на события задач. Секрет
+// возвращается только в этом ответе.
+func (h *Handler) createWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(userIDKey).(int64)
+	if !ok {
+		slog.Error("UserID not found in context")
+		httpError(w, r, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var req createWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpError(w, r, "JSON decode error", http.StatusBadRequest)
+		return
+	}
+	hook, err := h.service.CreateWebhook(r.Context(), userID, req.URL, req.Secret, req.Events)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	resp := newWebhookResponse(hook)
+	resp.Secret = hook.Secret
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+func (h *Handler) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(userIDKey).(int64)
+	if !ok {
+		slog.Error("UserID not found in context")
+		httpError(w, r, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, ok := webhookID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteWebhook(r.Context(), userID, id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// webhookDeliveries отдает журнал доставок подписки, новые первыми.
+// Параметр limit - до service.MaxWebhookDeliveries.
+func (h *Handler) webhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(userIDKey).(int64)
+	if !ok {
+		slog.Error("UserID not found in context")
+		httpError(w, r, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, ok := webhookID(w, r)
+	if !ok {
+		return
+	}
+	var limit int
+	if v := r.URL.Query().Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			paramError(w, r, "limit", "Invalid limit")
+			return
+		}
+	}
+	deliveries, err := h.service.WebhookDeliveries(r.Context(), userID, id, limit)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	resp := make([]deliveryResponse, len(deliveries))
+	for i, d := range deliveries {
+		resp[i] = newDeliveryResponse(d)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"deliveries": resp})
+}
+
+// pingWebhook отправляет на подписку тестовое событие и отдает итог
+// попытки: 200 и доставку, даже если получатель ответил ошибкой.
+func (h *Handler) pingWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(userIDKey).(int64)
+	if !ok {
+		slog.Error("UserID not found in context")
+		httpError(w, r, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, ok := webhookID(w, r)
+	if !ok {
+		return
+	}
+	http.NewResponseController(w).SetWriteDeadline(time.Now().Add(pingWriteTimeout))
+	d, err := h.service.PingWebhook(r.Context(), userID, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newDeliveryResponse(d))
+}
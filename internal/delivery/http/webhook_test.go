@@ -0,0 +1,126 @@
+package httpHandler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"task-traker/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhooks_CRUD(t *testing.T) {
+	api := newTestAPI(t)
+
+	w := api.do("POST", "/webhooks", `{"url":"https://ci.example.com/hook","events":["task.created"]}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created webhookResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&created))
+	assert.NotEmpty(t, created.Secret)
+	assert.Equal(t, []string{"task.created"}, created.Events)
+
+	w = api.do("GET", "/webhooks", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var list struct {
+		Webhooks []map[string]any `json:"webhooks"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&list))
+	require.Len(t, list.Webhooks, 1)
+	assert.NotContains(t, list.Webhooks[0], "secret")
+
+	w = api.do("DELETE", fmt.Sprintf("/webhooks/%d", created.ID), "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = api.do("DELETE", fmt.Sprintf("/webhooks/%d", created.ID), "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "Webhook not found", decodeProblem(t, w).Detail)
+}
+
+func TestWebhooks_ValidationProblem(t *testing.T) {
+	api := newTestAPI(t)
+
+	w := api.do("POST", "/webhooks", `{"url":"not a url","events":["task.exploded"]}`, "Accept-Language", "ru")
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	p := decodeProblem(t, w)
+	require.Len(t, p.Errors, 2)
+	assert.Equal(t, "url", p.Errors[0].Field)
+	assert.Equal(t, `неизвестный тип события "task.exploded"`, p.Errors[1].Detail)
+}
+
+func TestWebhooks_PingAndDeliveries(t *testing.T) {
+	api := newTestAPI(t)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer receiver.Close()
+	api.service.Webhooks = service.NewWebhookDispatcher(api.repo)
+	api.service.Webhooks.Client = receiver.Client()
+
+	hook, err := api.service.CreateWebhook(t.Context(), testUserID, receiver.URL, "", nil)
+	require.NoError(t, err)
+
+	w := api.do("POST", fmt.Sprintf("/webhooks/%d/ping", hook.ID), "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var ping deliveryResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&ping))
+	assert.Equal(t, "ping", ping.Event)
+	assert.Equal(t, "failed", string(ping.Status))
+	assert.Equal(t, http.StatusTeapot, ping.ResponseCode)
+	assert.Nil(t, ping.NextAttemptAt)
+
+	w = api.do("GET", fmt.Sprintf("/webhooks/%d/deliveries?limit=10", hook.ID), "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var log struct {
+		Deliveries []deliveryResponse `json:"deliveries"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&log))
+	require.Len(t, log.Deliveries, 1)
+	assert.Equal(t, ping.ID, log.Deliveries[0].ID)
+	assert.JSONEq(t, string(ping.Payload), string(log.Deliveries[0].Payload))
+
+	// Чужой вебхук не отличается от несуществующего
+	other, err := api.service.CreateWebhook(t.Context(), 7, receiver.URL, "", nil)
+	require.NoError(t, err)
+	w = api.do("GET", fmt.Sprintf("/webhooks/%d/deliveries", other.ID), "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = api.do("POST", "/webhooks/abc/ping", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestWebhooks_PingSlowReceiver(t *testing.T) {
+	api := newTestAPI(t)
+	stall := make(chan struct{})
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-stall
+	}))
+	defer receiver.Close()
+	defer close(stall)
+	api.service.Webhooks = service.NewWebhookDispatcher(api.repo)
+	api.service.Webhooks.Client = receiver.Client()
+	api.service.Webhooks.PingTimeout = 300 * time.Millisecond
+	hook, err := api.service.CreateWebhook(t.Context(), testUserID, receiver.URL, "", nil)
+	require.NoError(t, err)
+
+	// Получатель молчит дольше, чем и ping, и WriteTimeout сервера
+	srv := httptest.NewUnstartedServer(api.handler)
+	srv.Config.WriteTimeout = 100 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/webhooks/%d/ping", srv.URL, hook.ID), nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+api.token)
+	resp, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var ping deliveryResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&ping))
+	assert.Equal(t, "failed", string(ping.Status))
+	assert.Contains(t, ping.Error, "deadline exceeded")
+}
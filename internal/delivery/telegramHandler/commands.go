@@ -0,0 +1,100 @@
+package telegramHandler
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"task-traker/internal/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// commandScope - где команда доступна и показывается в меню команд.
+type commandScope int
+
+const (
+	scopePrivate commandScope = 1 << iota
+	scopeGroup
+)
+
+// command - команда бота. Из одного реестра строятся и маршрутизация
+// апдейтов, и меню команд, которое регистрируется через setMyCommands.
+type command struct {
+	Name string
+	// Description - описание для меню на русском, переводится через i18n.
+	Description string
+	Scopes      commandScope
+	Handle      func(Handler, context.Context, *tgbotapi.Message)
+}
+
+// commands возвращает реестр команд в порядке показа в меню.
+func commands() []command {
+	return []command{
+		{"start", "Главное меню", scopePrivate, Handler.handleStartCommand},
+		{"add", "Добавить задачу", scopePrivate, Handler.handleAddCommand},
+		{"list", "Список задач", scopePrivate, Handler.handleListCommand},
+		{"today", "Задачи на сегодня", scopePrivate, Handler.handleTodayCommand},
+		{"week", "Задачи на неделю", scopePrivate, Handler.handleWeekCommand},
+		{"search", "Найти задачи по тексту", scopePrivate, Handler.handleSearchCommand},
+		{"stats", "Статистика за четыре недели", scopePrivate, Handler.handleStatsCommand},
+		{"remind", "Напомнить о сообщении (ответом на него)", scopePrivate | scopeGroup, Handler.handleRemindCommand},
+		{"login", "Код для входа в API", scopePrivate, Handler.handleLoginCommand},
+		{"lang", "Сменить язык", scopePrivate | scopeGroup, Handler.handleLangCommand},
+		{"cancel", "Отменить текущее действие", scopePrivate, Handler.handleCancelCommand},
+	}
+}
+
+// handleCommand выполняет команду из реестра. В группах доступны только
+// команды со scopeGroup, остальные (например, /login с кодом входа)
+// работают лишь в личном чате.
+func (h Handler) handleCommand(ctx context.Context, m *tgbotapi.Message) {
+	lang := i18n.FromContext(ctx)
+	scope := scopePrivate
+	if !m.Chat.IsPrivate() {
+		scope = scopeGroup
+	}
+
+	registry := commands()
+	i := slices.IndexFunc(registry, func(c command) bool { return c.Name == m.Command() })
+	switch {
+	case i >= 0 && registry[i].Scopes&scope != 0:
+		registry[i].Handle(h, ctx, m)
+	case i >= 0:
+		h.Bot.SendMessage(m.Chat.ID, lang.T("Эта команда работает только в личном чате с ботом."))
+	case scope == scopePrivate:
+		// В группах чужие команды могут быть адресованы другим ботам
+		h.Bot.SendMessage(m.Chat.ID, lang.T("Неизвестная команда"))
+	}
+}
+
+// commandScopes - области меню команд в Telegram.
+var commandScopes = []struct {
+	scope    commandScope
+	telegram tgbotapi.BotCommandScope
+}{
+	{scopePrivate, tgbotapi.NewBotCommandScopeAllPrivateChats()},
+	{scopeGroup, tgbotapi.NewBotCommandScopeAllGroupChats()},
+}
+
+// RegisterCommands публикует меню команд для личных чатов и групп.
+// Русские описания регистрируются для клиентов на русском, английские -
+// для всех остальных (меню без language_code).
+func (h Handler) RegisterCommands() error {
+	var errs []error
+	for _, s := range commandScopes {
+		for _, lang := range i18n.Supported {
+			var list []tgbotapi.BotCommand
+			for _, c := range commands() {
+				if c.Scopes&s.scope != 0 {
+					list = append(list, tgbotapi.BotCommand{Command: c.Name, Description: lang.T(c.Description)})
+				}
+			}
+			code := string(lang)
+			if lang == i18n.EN {
+				code = ""
+			}
+			errs = append(errs, h.Bot.SetMyCommands(s.telegram, code, list))
+		}
+	}
+	return errors.Join(errs...)
+}
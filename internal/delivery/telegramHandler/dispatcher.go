@@ -0,0 +1,130 @@
+package telegramHandler
+
+import (
+	"context"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	defaultWorkers   = 8
+	shardQueueSize   = 64
+	updateTimeout    = 5 * time.Second
+	drainGracePeriod = 10 * time.Second
+)
+
+// Dispatcher обрабатывает апдейты пулом воркеров. Апдейты распределяются
+// по шардам по id чата, поэтому сообщения одного чата обрабатываются
+// строго по порядку, а медленный запрос одного пользователя не блокирует остальных.
+//
+// Ответы бота уходят через telegram.Queue.Async: воркер ставит их в очередь
+// и берет следующий апдейт, не дожидаясь, пока у чата восстановится лимит
+// или пройдет retry_after после ответа 429. Ответы одного чата очередь
+// отправляет по порядку, а ошибки отправки только записывает в лог.
+type Dispatcher struct {
+	handle func(ctx context.Context, update tgbotapi.Update)
+	shards []chan tgbotapi.Update
+	wg     sync.WaitGroup
+}
+
+func NewDispatcher(workers int, handle func(context.Context, tgbotapi.Update)) *Dispatcher {
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+	d := &Dispatcher{
+		handle: handle,
+		shards: make([]chan tgbotapi.Update, workers),
+	}
+	for i := range d.shards {
+		d.shards[i] = make(chan tgbotapi.Update, shardQueueSize)
+	}
+	return d
+}
+
+// Run запускает воркеры. Контекст апдейтов не отменяется вместе с ctx,
+// чтобы при остановке уже принятые апдейты были обработаны до конца.
+func (d *Dispatcher) Run(ctx context.Context) {
+	baseCtx := context.WithoutCancel(ctx)
+	for _, shard := range d.shards {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			for update := range shard {
+				queueLength.Add(-1)
+				d.process(baseCtx, update)
+			}
+		}()
+	}
+}
+
+// Dispatch ставит апдейт в очередь шарда его чата. Блокируется, если
+// очередь заполнена, пока не освободится место или не отменится ctx.
+func (d *Dispatcher) Dispatch(ctx context.Context, update tgbotapi.Update) error {
+	shard := d.shards[shardIndex(chatKey(update), len(d.shards))]
+	// Счетчик растет до отправки, иначе воркер может уменьшить его раньше
+	queueLength.Add(1)
+	select {
+	case shard <- update:
+		return nil
+	case <-ctx.Done():
+		queueLength.Add(-1)
+		return ctx.Err()
+	}
+}
+
+// Shutdown перестает принимать апдейты и ждет обработки очереди,
+// но не дольше, чем позволяет ctx.
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	for _, shard := range d.shards {
+		close(shard)
+	}
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (d *Dispatcher) process(ctx context.Context, update tgbotapi.Update) {
+	start := time.Now()
+	requestCtx, cancel := context.WithTimeout(ctx, updateTimeout)
+	defer func() {
+		cancel()
+		if r := recover(); r != nil {
+			updatePanics.Add(1)
+			slog.Error("panic while handling update", "update_id", update.UpdateID, "panic", r, "stack", string(debug.Stack()))
+		}
+		observeLatency(time.Since(start))
+	}()
+
+	d.handle(requestCtx, update)
+}
+
+// chatKey возвращает ключ шардирования: id чата, а для апдейтов без чата - id пользователя.
+func chatKey(update tgbotapi.Update) int64 {
+	if chat := update.FromChat(); chat != nil {
+		return chat.ID
+	}
+	if user := update.SentFrom(); user != nil {
+		return user.ID
+	}
+	return 0
+}
+
+func shardIndex(key int64, n int) int {
+	idx := key % int64(n)
+	if idx < 0 {
+		idx = -idx
+	}
+	return int(idx)
+}
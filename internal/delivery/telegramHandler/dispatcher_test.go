@@ -0,0 +1,122 @@
+package telegramHandler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"task-traker/pkg/telegram"
+	"task-traker/pkg/telegram/telegramtest"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func messageUpdate(id int, chatID int64) tgbotapi.Update {
+	return tgbotapi.Update{
+		UpdateID: id,
+		Message:  &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: chatID}},
+	}
+}
+
+func TestDispatcher_PreservesPerChatOrder(t *testing.T) {
+	var mu sync.Mutex
+	got := make(map[int64][]int)
+
+	d := NewDispatcher(4, func(ctx context.Context, u tgbotapi.Update) {
+		// Медленный первый апдейт не должен обгоняться следующими апдейтами того же чата
+		if u.UpdateID%10 == 0 {
+			time.Sleep(5 * time.Millisecond)
+		}
+		mu.Lock()
+		got[u.Message.Chat.ID] = append(got[u.Message.Chat.ID], u.UpdateID)
+		mu.Unlock()
+	})
+	ctx := context.Background()
+	d.Run(ctx)
+
+	for i := range 30 {
+		chatID := int64(i % 3)
+		assert.NoError(t, d.Dispatch(ctx, messageUpdate(i, chatID)))
+	}
+	assert.NoError(t, d.Shutdown(ctx))
+
+	for chatID, ids := range got {
+		assert.Len(t, ids, 10)
+		assert.IsIncreasing(t, ids, "chat %d", chatID)
+	}
+}
+
+func TestDispatcher_RecoversFromPanic(t *testing.T) {
+	handled := 0
+	d := NewDispatcher(1, func(ctx context.Context, u tgbotapi.Update) {
+		if u.UpdateID == 1 {
+			panic("boom")
+		}
+		handled++
+	})
+	ctx := context.Background()
+	d.Run(ctx)
+
+	d.Dispatch(ctx, messageUpdate(1, 7))
+	d.Dispatch(ctx, messageUpdate(2, 7))
+	assert.NoError(t, d.Shutdown(ctx))
+
+	assert.Equal(t, 1, handled, "Воркер должен продолжить работу после паники")
+}
+
+func TestChatKey_FallsBackToSender(t *testing.T) {
+	u := tgbotapi.Update{InlineQuery: &tgbotapi.InlineQuery{From: &tgbotapi.User{ID: 99}}}
+	assert.Equal(t, int64(99), chatKey(u))
+	assert.Equal(t, 1, shardIndex(-7, 3))
+}
+
+func TestDispatcher_QueueLength(t *testing.T) {
+	before := queueLength.Value()
+	d := NewDispatcher(1, func(ctx context.Context, u tgbotapi.Update) {})
+	ctx := context.Background()
+
+	for i := range shardQueueSize {
+		assert.NoError(t, d.Dispatch(ctx, messageUpdate(i, 7)))
+	}
+	assert.Equal(t, before+shardQueueSize, queueLength.Value())
+
+	// Апдейт, не попавший в полную очередь, не учитывается
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.ErrorIs(t, d.Dispatch(cancelled, messageUpdate(shardQueueSize, 7)), context.Canceled)
+	assert.Equal(t, before+shardQueueSize, queueLength.Value())
+
+	d.Run(ctx)
+	assert.NoError(t, d.Shutdown(ctx))
+	assert.Equal(t, before, queueLength.Value())
+}
+
+func TestDispatcher_ThrottledChatDoesNotBlockShard(t *testing.T) {
+	server := telegramtest.NewServer(t)
+	q := telegram.NewQueue(server.Client(t), telegram.Limits{Global: 1000, PerChat: 1, ChatBurst: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.Run(ctx)
+	bot := q.Async()
+
+	// Один воркер: оба чата в одном шарде
+	d := NewDispatcher(1, func(ctx context.Context, u tgbotapi.Update) {
+		bot.SendMessage(u.Message.Chat.ID, "reply")
+	})
+	d.Run(ctx)
+	start := time.Now()
+	// Второй и третий ответы чату 1 ждут лимита чата - по секунде
+	for i := range 3 {
+		require.NoError(t, d.Dispatch(ctx, messageUpdate(i, 1)))
+	}
+	require.NoError(t, d.Dispatch(ctx, messageUpdate(3, 2)))
+
+	// Ответ чату 2 уходит сразу вслед за первым ответом чату 1
+	calls := server.WaitCalls(t, "sendMessage", 2)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.ElementsMatch(t, []string{"1", "2"}, []string{calls[0].Params.Get("chat_id"), calls[1].Params.Get("chat_id")})
+	require.NoError(t, d.Shutdown(ctx))
+}
@@ -0,0 +1,261 @@
+package telegramHandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"task-traker/internal/delivery/telegramHandler/fsm"
+	"task-traker/internal/domain"
+	"task-traker/internal/i18n"
+	"task-traker/internal/service"
+	"task-traker/pkg/telegram"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const flowEditTask = "edit_task"
+
+// Поля формы редактирования задачи.
+const (
+	fieldTaskID      = "task_id"
+	fieldMessageID   = "message_id"
+	fieldListState   = "list_state"
+	fieldPriority    = "priority"
+	fieldRemind      = "remind_before"
+	stateChooseField = "choose_field"
+)
+
+func (h Handler) editTaskFlow() *fsm.Flow {
+	return &fsm.Flow{
+		Name:    flowEditTask,
+		Initial: stateChooseField,
+		States: []fsm.State{
+			{
+				Name:   stateChooseField,
+				Prompt: "Что изменить в задаче?",
+				Buttons: [][]fsm.Button{
+					{{Text: "Название", Data: "field:" + fieldTitle}, {Text: "Срок", Data: "field:" + fieldDeadline}},
+					{{Text: "Приоритет", Data: "field:" + fieldPriority}, {Text: "Напоминание", Data: "field:" + fieldRemind}},
+				},
+				OnText:     chooseWithButtons,
+				OnCallback: goToField,
+			},
+			{
+				Name:     fieldTitle,
+				Prompt:   "Введите новое название задачи:",
+				Field:    fieldTitle,
+				Validate: validateTitle,
+				Next:     fsm.End,
+			},
+			{
+				Name:     fieldDeadline,
+				Prompt:   "Введите новую дату и время (ДД.ММ.ГГГГ ЧЧ:ММ):",
+				Field:    fieldDeadline,
+				Validate: validateDeadline,
+				Next:     fsm.End,
+			},
+			{
+				Name:   fieldPriority,
+				Prompt: "Выберите приоритет:",
+				Buttons: [][]fsm.Button{{
+					{Text: "🔽 Низкий", Data: fmt.Sprintf("set:%d", domain.PriorityLow)},
+					{Text: "Обычный", Data: fmt.Sprintf("set:%d", domain.PriorityNormal)},
+					{Text: "🔥 Высокий", Data: fmt.Sprintf("set:%d", domain.PriorityHigh)},
+				}},
+				OnText:     chooseWithButtons,
+				OnCallback: setField(fieldPriority, validatePriority),
+			},
+			{
+				Name:   fieldRemind,
+				Prompt: "За сколько минут до срока напомнить? Выберите вариант или введите число минут:",
+				Buttons: [][]fsm.Button{{
+					{Text: "В срок", Data: "set:0"},
+					{Text: "15 мин", Data: "set:15"},
+					{Text: "1 час", Data: "set:60"},
+					{Text: "1 день", Data: "set:1440"},
+				}},
+				Field:      fieldRemind,
+				Validate:   validateMinutes,
+				Next:       fsm.End,
+				OnCallback: setField(fieldRemind, validateMinutes),
+			},
+		},
+		Done: h.saveEditedTask,
+	}
+}
+
+func chooseWithButtons(ctx context.Context, in fsm.Input, s *fsm.Session) (string, error) {
+	return "", fsm.Invalid("Выберите вариант кнопкой.")
+}
+
+func goToField(ctx context.Context, in fsm.Input, s *fsm.Session) (string, error) {
+	field, ok := strings.CutPrefix(in.Callback, "field:")
+	if !ok {
+		return "", nil
+	}
+	return field, nil
+}
+
+// setField сохраняет значение кнопки "set:<value>" в поле формы и завершает диалог.
+// callback_data присылает клиент, поэтому значение проверяется так же, как
+// введенное текстом: подделанное значение не дойдет до сохранения задачи.
+func setField(field string, validate func(string) error) fsm.CallbackHandler {
+	return func(ctx context.Context, in fsm.Input, s *fsm.Session) (string, error) {
+		value, ok := strings.CutPrefix(in.Callback, "set:")
+		if !ok {
+			return "", nil
+		}
+		if err := validate(value); err != nil {
+			return "", err
+		}
+		s.Data[field] = value
+		return fsm.End, nil
+	}
+}
+
+// maxRemindMinutes - самое раннее напоминание: за год до срока. Большие
+// числа переполнили бы колонку remind_before.
+const maxRemindMinutes = 365 * 24 * 60
+
+func validatePriority(text string) error {
+	p, err := strconv.Atoi(text)
+	if err != nil || domain.Priority(p) < domain.PriorityLow || domain.Priority(p) > domain.PriorityHigh {
+		return fsm.Invalid("Выберите вариант кнопкой.")
+	}
+	return nil
+}
+
+func validateMinutes(text string) error {
+	n, err := strconv.Atoi(strings.TrimSpace(text))
+	if err != nil || n < 0 || n > maxRemindMinutes {
+		return fsm.Invalid("Введите целое число минут, например 30.")
+	}
+	return nil
+}
+
+// handleEditTask открывает диалог редактирования задачи из списка.
+// Задача ищется среди задач нажавшего кнопку пользователя, поэтому
+// подделанный id чужой задачи не даст ее изменить.
+func (h Handler) handleEditTask(ctx context.Context, cb *tgbotapi.CallbackQuery) {
+	lang := i18n.FromContext(ctx)
+	chatID := cb.Message.Chat.ID
+	id, err := strconv.Atoi(strings.TrimPrefix(cb.Data, "edit_"))
+	if err != nil {
+		return
+	}
+	task, err := h.TaskService.GetTask(ctx, cb.From.ID, id)
+	if errors.Is(err, domain.ErrTaskNotFound) {
+		h.Bot.SendMessage(chatID, lang.T("Задача не найдена"))
+		return
+	}
+	if err != nil {
+		slog.Error("handleEditTask error", "id", id, "error", err)
+		h.Bot.SendMessage(chatID, lang.T("Ошибка сервера"))
+		return
+	}
+
+	data := map[string]string{
+		fieldTaskID:    strconv.Itoa(task.ID),
+		fieldMessageID: strconv.Itoa(cb.Message.MessageID),
+	}
+	// Кнопка может быть и под списком, и под подтверждением быстрого добавления
+	if state, ok := listStateOf(cb.Message); ok {
+		data[fieldListState] = state.data()
+	}
+	replies, err := h.Dialogs.BeginAt(chatID, flowEditTask, stateChooseField, data)
+	if err != nil {
+		slog.Error("Dialog start error", "flow", flowEditTask, "error", err)
+		return
+	}
+	h.sendReplies(ctx, chatID, replies)
+}
+
+// saveEditedTask применяет измененное поле и перерисовывает исходное сообщение:
+// список задач или подтверждение быстрого добавления.
+func (h Handler) saveEditedTask(ctx context.Context, in fsm.Input, s *fsm.Session) (fsm.Reply, error) {
+	id, _ := strconv.Atoi(s.Data[fieldTaskID])
+	task, err := h.TaskService.GetTask(ctx, in.UserID, id)
+	if errors.Is(err, domain.ErrTaskNotFound) {
+		return fsm.Reply{Text: "Задача не найдена"}, nil
+	}
+	if err != nil {
+		return fsm.Reply{}, err
+	}
+
+	if v, ok := s.Data[fieldTitle]; ok {
+		task.Title = strings.TrimSpace(v)
+	}
+	if v, ok := s.Data[fieldDeadline]; ok {
+		if task.Deadline, err = service.ParseDeadline(v); err != nil {
+			return fsm.Reply{}, fsm.Invalid("Ошибка формата даты. Попробуйте еще раз.")
+		}
+	}
+	if v, ok := s.Data[fieldPriority]; ok {
+		p, _ := strconv.Atoi(v)
+		task.Priority = domain.Priority(p)
+	}
+	if v, ok := s.Data[fieldRemind]; ok {
+		task.RemindBefore, _ = strconv.Atoi(strings.TrimSpace(v))
+	}
+
+	err = h.TaskService.UpdateTask(ctx, &task)
+	if errors.Is(err, domain.ErrTaskNotFound) {
+		return fsm.Reply{Text: "Задача не найдена"}, nil
+	}
+	if err != nil {
+		return fsm.Reply{}, err
+	}
+
+	messageID, _ := strconv.Atoi(s.Data[fieldMessageID])
+	if state, ok := parseListState(s.Data[fieldListState]); ok {
+		h.refreshList(ctx, in.UserID, in.ChatID, messageID, state)
+	} else if messageID != 0 {
+		lang := i18n.FromContext(ctx)
+		keyboard := quickAddKeyboard(lang, task.ID)
+		if err := h.Bot.EditFormatted(in.ChatID, messageID, taskCard(lang, task), &keyboard); err != nil {
+			slog.Error("Ошибка редактирования сообщения", "error", err)
+		}
+	}
+	return fsm.Reply{Text: "✅ Задача обновлена"}, nil
+}
+
+// formatTask - текст задачи в списке: название с приоритетом, срок,
+// напоминание, теги и ссылка на исходное сообщение. Название выделяется
+// жирным и экранируется, поэтому "_" или "<" в нем не ломают разметку.
+func formatTask(lang i18n.Lang, task domain.Task) telegram.Formatted {
+	b := telegram.NewBuilder(messageMode)
+	switch task.Priority {
+	case domain.PriorityHigh:
+		b.Text("🔥 ")
+	case domain.PriorityLow:
+		b.Text("🔽 ")
+	}
+	b.Format("%s", telegram.Bold(task.Title))
+	b.Format("\n ⏰ %s", task.Deadline.Format("02.01.2006 15:04"))
+	if task.RemindBefore != domain.DefaultRemindBefore {
+		b.Format("\n 🔔 %s", formatRemind(lang, task.RemindBefore))
+	}
+	if len(task.Tags) > 0 {
+		b.Format("\n 🏷 #%s", strings.Join(task.Tags, " #"))
+	}
+	if task.SourceURL != "" {
+		b.Format("\n 🔗 %s", telegram.Link(lang.T("Исходное сообщение"), task.SourceURL))
+	}
+	return b.Message()
+}
+
+func formatRemind(lang i18n.Lang, minutes int) string {
+	switch {
+	case minutes == 0:
+		return lang.T("в срок")
+	case minutes%1440 == 0:
+		return lang.N(minutes/1440, "за %d день", "за %d дня", "за %d дней")
+	case minutes%60 == 0:
+		return lang.N(minutes/60, "за %d час", "за %d часа", "за %d часов")
+	default:
+		return lang.N(minutes, "за %d минуту", "за %d минуты", "за %d минут")
+	}
+}
@@ -0,0 +1,116 @@
+package telegramHandler
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"task-traker/internal/delivery/telegramHandler/fsm"
+	"task-traker/internal/domain"
+	"task-traker/internal/i18n"
+	"task-traker/internal/service"
+)
+
+const flowAddTask = "add_task"
+
+// Поля формы добавления задачи.
+const (
+	fieldTitle    = "title"
+	fieldDeadline = "deadline"
+	// fieldTags - теги через пробел, если задача начата быстрым добавлением.
+	fieldTags = "tags"
+)
+
+func (h Handler) addTaskFlow() *fsm.Flow {
+	return &fsm.Flow{
+		Name:    flowAddTask,
+		Initial: fieldTitle,
+		States: []fsm.State{
+			{
+				Name:     fieldTitle,
+				Prompt:   "Напишите текст задачи",
+				Field:    fieldTitle,
+				Validate: validateTitle,
+				Next:     fieldDeadline,
+			},
+			{
+				Name:     fieldDeadline,
+				Prompt:   "Теперь введите дату и время (ДД.ММ.ГГГГ ЧЧ:ММ):",
+				Field:    fieldDeadline,
+				Validate: validateDeadline,
+				Next:     fsm.End,
+			},
+		},
+		Done: func(ctx context.Context, in fsm.Input, s *fsm.Session) (fsm.Reply, error) {
+			deadline, err := service.ParseDeadline(s.Data[fieldDeadline])
+			if err != nil {
+				return fsm.Reply{}, fsm.Invalid("Ошибка формата даты. Попробуйте еще раз.")
+			}
+			priority, _ := strconv.Atoi(s.Data[fieldPriority])
+			task := domain.Task{
+				UserID:       in.UserID,
+				Title:        s.Data[fieldTitle],
+				Deadline:     deadline,
+				Priority:     domain.Priority(priority),
+				RemindBefore: domain.DefaultRemindBefore,
+				Tags:         strings.Fields(s.Data[fieldTags]),
+				SourceURL:    s.Data[fieldSource],
+			}
+			if err := h.TaskService.AddTask(ctx, &task); err != nil {
+				return fsm.Reply{}, err
+			}
+			return fsm.Reply{Text: "✅ Задача сохранена!"}, nil
+		},
+	}
+}
+
+func validateTitle(text string) error {
+	if strings.TrimSpace(text) == "" {
+		return fsm.Invalid("Текст задачи не может быть пустым.")
+	}
+	return nil
+}
+
+func validateDeadline(text string) error {
+	if _, err := service.ParseDeadline(text); err != nil {
+		return fsm.Invalid("Ошибка формата даты. Попробуйте еще раз.")
+	}
+	return nil
+}
+
+// beginDialog запускает диалог и отправляет первый запрос.
+func (h Handler) beginDialog(ctx context.Context, chatID int64, flow string) {
+	replies, err := h.Dialogs.Begin(chatID, flow)
+	if err != nil {
+		slog.Error("Dialog start error", "flow", flow, "error", err)
+		h.Bot.SendMessage(chatID, i18n.FromContext(ctx).T("Ошибка сервера"))
+		return
+	}
+	h.sendReplies(ctx, chatID, replies)
+}
+
+// handleDialog передает ввод активному диалогу. Возвращает false,
+// если у чата нет диалога или диалог не обрабатывает это событие.
+func (h Handler) handleDialog(ctx context.Context, in fsm.Input) bool {
+	replies, handled, err := h.Dialogs.Handle(ctx, in)
+	if err != nil {
+		slog.Error("Dialog error", "chat_id", in.ChatID, "error", err)
+		h.Bot.SendMessage(in.ChatID, i18n.FromContext(ctx).T("Ошибка сервера. Попробуйте еще раз."))
+		return true
+	}
+	h.sendReplies(ctx, in.ChatID, replies)
+	return handled
+}
+
+// sendReplies отправляет ответы диалога на языке пользователя. Тексты
+// диалогов написаны по-русски и переводятся здесь, при отправке.
+func (h Handler) sendReplies(ctx context.Context, chatID int64, replies []fsm.Reply) {
+	lang := i18n.FromContext(ctx)
+	for _, r := range replies {
+		if len(r.Buttons) == 0 {
+			h.Bot.SendMessage(chatID, lang.T(r.Text))
+			continue
+		}
+		h.Bot.SendWithKeyboard(chatID, lang.T(r.Text), dialogKeyboard(lang, r.Buttons))
+	}
+}
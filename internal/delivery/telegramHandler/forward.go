@@ -0,0 +1,105 @@
+package telegramHandler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"task-traker/internal/domain"
+	"task-traker/internal/i18n"
+	"task-traker/internal/service"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// forwardTitleLimit - длина названия задачи из пересланного сообщения в символах.
+const forwardTitleLimit = 200
+
+// fieldSource - ссылка на исходное сообщение задачи в диалоге добавления.
+const fieldSource = "source"
+
+// handleForward превращает пересланное сообщение в задачу: название берется
+// из текста, а диалог добавления начинается сразу с запроса срока.
+func (h Handler) handleForward(ctx context.Context, m *tgbotapi.Message) {
+	lang := i18n.FromContext(ctx)
+	title := messageTitle(m)
+	if title == "" {
+		h.Bot.SendMessage(m.Chat.ID, lang.T("В пересланном сообщении нет текста для задачи."))
+		return
+	}
+	replies, err := h.Dialogs.BeginAt(m.Chat.ID, flowAddTask, fieldDeadline, map[string]string{
+		fieldTitle:  title,
+		fieldSource: messageLink(m.ForwardFromChat, m.ForwardFromMessageID),
+	})
+	if err != nil {
+		slog.Error("Dialog start error", "flow", flowAddTask, "error", err)
+		h.Bot.SendMessage(m.Chat.ID, lang.T("Ошибка сервера"))
+		return
+	}
+	h.Bot.SendMessage(m.Chat.ID, lang.T("📩 Задача: %s", title))
+	h.sendReplies(ctx, m.Chat.ID, replies)
+}
+
+// handleRemindCommand ставит напоминание о сообщении, на которое ответили
+// командой "/remind 2h". Напоминание придет в личный чат с ботом.
+func (h Handler) handleRemindCommand(ctx context.Context, m *tgbotapi.Message) {
+	lang := i18n.FromContext(ctx)
+	if m.ReplyToMessage == nil {
+		h.Bot.SendMessage(m.Chat.ID, lang.T("Ответьте командой /remind 2h на сообщение, о котором нужно напомнить."))
+		return
+	}
+	delay, err := service.ParseDelay(m.CommandArguments())
+	if err != nil {
+		h.Bot.SendMessage(m.Chat.ID, lang.T("Укажите срок: /remind 30m, /remind 2h или /remind 1d."))
+		return
+	}
+
+	title := messageTitle(m.ReplyToMessage)
+	if title == "" {
+		title = lang.T("Сообщение в чате")
+		if m.Chat.Title != "" {
+			title = lang.T("Сообщение в чате «%s»", m.Chat.Title)
+		}
+	}
+	task := domain.Task{
+		UserID:    m.From.ID,
+		Title:     title,
+		Deadline:  service.Now().Add(delay),
+		SourceURL: messageLink(m.Chat, m.ReplyToMessage.MessageID),
+	}
+	if err := h.TaskService.AddTask(ctx, &task); err != nil {
+		slog.Error("Remind command error", "error", err)
+		h.Bot.SendMessage(m.Chat.ID, lang.T("Ошибка сервера"))
+		return
+	}
+	h.Bot.SendMessage(m.Chat.ID, lang.T("⏰ Напомню %s в %s", m.From.FirstName, task.Deadline.Format("02.01.2006 15:04")))
+}
+
+// messageTitle - текст или подпись сообщения, обрезанные до forwardTitleLimit.
+func messageTitle(m *tgbotapi.Message) string {
+	text := m.Text
+	if text == "" {
+		text = m.Caption
+	}
+	text = strings.Join(strings.Fields(text), " ")
+	if r := []rune(text); len(r) > forwardTitleLimit {
+		text = string(r[:forwardTitleLimit-1]) + "…"
+	}
+	return text
+}
+
+// messageLink - ссылка t.me на сообщение в канале или супергруппе.
+// На сообщения личных чатов и обычных групп ссылок не бывает.
+func messageLink(chat *tgbotapi.Chat, messageID int) string {
+	if chat == nil || messageID == 0 {
+		return ""
+	}
+	if chat.UserName != "" && !chat.IsPrivate() {
+		return fmt.Sprintf("https://t.me/%s/%d", chat.UserName, messageID)
+	}
+	if id, ok := strings.CutPrefix(strconv.FormatInt(chat.ID, 10), "-100"); ok {
+		return fmt.Sprintf("https://t.me/c/%s/%d", id, messageID)
+	}
+	return ""
+}
@@ -0,0 +1,294 @@
+// Package fsm реализует декларативный конечный автомат для многошаговых
+// диалогов бота: именованные состояния, валидация ввода с повторным
+// запросом, переходы «Назад»/«Отмена» и сбор данных формы.
+// Пакет не зависит от Telegram, поэтому диалоги тестируются без сети.
+// Тексты запросов, кнопок и ошибок возвращаются как есть - переводит их
+// тот, кто отправляет ответы пользователю.
+package fsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// End - имя псевдо-состояния, переход в которое завершает диалог.
+const End = "end"
+
+// Данные служебных inline-кнопок.
+const (
+	CallbackBack   = "fsm:back"
+	CallbackCancel = "fsm:cancel"
+)
+
+var ErrUnknownFlow = errors.New("fsm: unknown flow")
+
+// Input - входящее событие пользователя: текст или нажатие inline-кнопки.
+type Input struct {
+	ChatID   int64
+	UserID   int64
+	Text     string
+	Callback string
+}
+
+type Button struct {
+	Text string
+	Data string
+}
+
+// Reply - ответ, который нужно отправить пользователю.
+type Reply struct {
+	Text    string
+	Buttons [][]Button
+}
+
+// Session - состояние диалога одного чата и собранные данные формы.
+type Session struct {
+	Flow    string
+	State   string
+	Data    map[string]string
+	history []string
+}
+
+// TextHandler обрабатывает текст в состоянии и возвращает имя следующего состояния
+// (пустая строка - остаться в текущем без повторного запроса).
+// Ошибка ValidationError приводит к повторному запросу того же состояния.
+type TextHandler func(ctx context.Context, in Input, s *Session) (string, error)
+
+// CallbackHandler обрабатывает нажатие inline-кнопки в состоянии.
+type CallbackHandler func(ctx context.Context, in Input, s *Session) (string, error)
+
+type State struct {
+	Name string
+	// Prompt отправляется при входе в состояние и при повторном запросе.
+	Prompt  string
+	Buttons [][]Button
+	// Field - ключ в Session.Data, куда сохраняется введенный текст.
+	Field    string
+	Validate func(text string) error
+	// Next - состояние после успешного ввода, если OnText не задан.
+	Next       string
+	OnText     TextHandler
+	OnCallback CallbackHandler
+}
+
+type Flow struct {
+	Name    string
+	Initial string
+	States  []State
+	// Done вызывается при переходе в End. Если Done вернул ошибку,
+	// диалог остается в последнем состоянии.
+	Done       func(ctx context.Context, in Input, s *Session) (Reply, error)
+	CancelText string
+}
+
+func (f *Flow) state(name string) (*State, bool) {
+	for i := range f.States {
+		if f.States[i].Name == name {
+			return &f.States[i], true
+		}
+	}
+	return nil, false
+}
+
+// ValidationError - ошибка пользовательского ввода, текст которой показывается пользователю.
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+func Invalid(format string, args ...any) error {
+	return &ValidationError{Message: fmt.Sprintf(format, args...)}
+}
+
+// Machine хранит описания диалогов и сессии чатов. Безопасен для
+// конкурентного использования из разных чатов.
+type Machine struct {
+	mu       sync.Mutex
+	flows    map[string]*Flow
+	sessions map[int64]*Session
+}
+
+func New(flows ...*Flow) *Machine {
+	m := &Machine{
+		flows:    make(map[string]*Flow),
+		sessions: make(map[int64]*Session),
+	}
+	for _, f := range flows {
+		m.flows[f.Name] = f
+	}
+	return m
+}
+
+// Begin запускает диалог с начального состояния, заменяя текущий.
+func (m *Machine) Begin(chatID int64, flow string) ([]Reply, error) {
+	f, ok := m.flows[flow]
+	if !ok {
+		return nil, ErrUnknownFlow
+	}
+	return m.BeginAt(chatID, flow, f.Initial, nil)
+}
+
+// BeginAt запускает диалог с произвольного состояния с заранее заполненными данными.
+func (m *Machine) BeginAt(chatID int64, flow, state string, data map[string]string) ([]Reply, error) {
+	f, ok := m.flows[flow]
+	if !ok {
+		return nil, ErrUnknownFlow
+	}
+	st, ok := f.state(state)
+	if !ok {
+		return nil, fmt.Errorf("fsm: unknown state %q in flow %q", state, flow)
+	}
+	s := &Session{Flow: flow, State: state, Data: make(map[string]string)}
+	for k, v := range data {
+		s.Data[k] = v
+	}
+
+	m.mu.Lock()
+	m.sessions[chatID] = s
+	m.mu.Unlock()
+
+	return []Reply{prompt(st, s)}, nil
+}
+
+// Session возвращает активную сессию чата.
+func (m *Machine) Session(chatID int64) (*Session, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[chatID]
+	return s, ok
+}
+
+// Cancel прерывает активный диалог чата.
+func (m *Machine) Cancel(chatID int64) ([]Reply, bool) {
+	m.mu.Lock()
+	s, ok := m.sessions[chatID]
+	delete(m.sessions, chatID)
+	m.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	text := "Действие отменено"
+	if f := m.flows[s.Flow]; f.CancelText != "" {
+		text = f.CancelText
+	}
+	return []Reply{{Text: text}}, true
+}
+
+// Handle передает событие активному диалогу чата.
+// handled == false, если у чата нет активного диалога.
+func (m *Machine) Handle(ctx context.Context, in Input) (replies []Reply, handled bool, err error) {
+	s, ok := m.Session(in.ChatID)
+	if !ok {
+		return nil, false, nil
+	}
+	f := m.flows[s.Flow]
+	st, ok := f.state(s.State)
+	if !ok {
+		m.finish(in.ChatID)
+		return nil, true, fmt.Errorf("fsm: unknown state %q in flow %q", s.State, s.Flow)
+	}
+
+	switch in.Callback {
+	case CallbackCancel:
+		replies, _ = m.Cancel(in.ChatID)
+		return replies, true, nil
+	case CallbackBack:
+		return m.back(f, st, s), true, nil
+	}
+
+	var next string
+	switch {
+	case in.Callback != "":
+		if st.OnCallback == nil {
+			return nil, false, nil
+		}
+		next, err = st.OnCallback(ctx, in, s)
+	case st.Validate != nil:
+		if err = st.Validate(in.Text); err == nil {
+			next, err = m.onText(ctx, in, st, s)
+		}
+	default:
+		next, err = m.onText(ctx, in, st, s)
+	}
+	if err != nil {
+		var verr *ValidationError
+		if errors.As(err, &verr) {
+			return []Reply{{Text: verr.Message}, prompt(st, s)}, true, nil
+		}
+		return nil, true, err
+	}
+
+	if next == "" || next == s.State {
+		return nil, true, nil
+	}
+	if next == End {
+		var reply Reply
+		if f.Done != nil {
+			reply, err = f.Done(ctx, in, s)
+		}
+		if err != nil {
+			var verr *ValidationError
+			if errors.As(err, &verr) {
+				return []Reply{{Text: verr.Message}, prompt(st, s)}, true, nil
+			}
+			return nil, true, err
+		}
+		m.finish(in.ChatID)
+		if reply.Text == "" {
+			return nil, true, nil
+		}
+		return []Reply{reply}, true, nil
+	}
+
+	nextState, ok := f.state(next)
+	if !ok {
+		return nil, true, fmt.Errorf("fsm: unknown state %q in flow %q", next, s.Flow)
+	}
+	s.history = append(s.history, s.State)
+	s.State = next
+	return []Reply{prompt(nextState, s)}, true, nil
+}
+
+func (m *Machine) onText(ctx context.Context, in Input, st *State, s *Session) (string, error) {
+	if st.OnText != nil {
+		return st.OnText(ctx, in, s)
+	}
+	if st.Field != "" {
+		s.Data[st.Field] = in.Text
+	}
+	return st.Next, nil
+}
+
+func (m *Machine) back(f *Flow, st *State, s *Session) []Reply {
+	if len(s.history) == 0 {
+		return []Reply{prompt(st, s)}
+	}
+	prev := s.history[len(s.history)-1]
+	s.history = s.history[:len(s.history)-1]
+	s.State = prev
+	prevState, _ := f.state(prev)
+	return []Reply{prompt(prevState, s)}
+}
+
+func (m *Machine) finish(chatID int64) {
+	m.mu.Lock()
+	delete(m.sessions, chatID)
+	m.mu.Unlock()
+}
+
+// prompt добавляет к запросу состояния кнопки «Назад» и «Отмена».
+func prompt(st *State, s *Session) Reply {
+	buttons := append([][]Button{}, st.Buttons...)
+	nav := []Button{}
+	if len(s.history) > 0 {
+		nav = append(nav, Button{Text: "◀️ Назад", Data: CallbackBack})
+	}
+	nav = append(nav, Button{Text: "✖️ Отмена", Data: CallbackCancel})
+	buttons = append(buttons, nav)
+	return Reply{Text: st.Prompt, Buttons: buttons}
+}
@@ -0,0 +1,131 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const chatID = int64(42)
+
+func testFlow(done *map[string]string) *Flow {
+	return &Flow{
+		Name:    "form",
+		Initial: "name",
+		States: []State{
+			{Name: "name", Prompt: "Имя?", Field: "name", Next: "age"},
+			{
+				Name:   "age",
+				Prompt: "Возраст?",
+				Field:  "age",
+				Validate: func(text string) error {
+					if text == "" || text[0] < '0' || text[0] > '9' {
+						return Invalid("Нужно число")
+					}
+					return nil
+				},
+				Next: End,
+			},
+		},
+		Done: func(ctx context.Context, in Input, s *Session) (Reply, error) {
+			*done = s.Data
+			return Reply{Text: "Готово"}, nil
+		},
+	}
+}
+
+func TestMachine_CollectsFormData(t *testing.T) {
+	var data map[string]string
+	m := New(testFlow(&data))
+	ctx := context.Background()
+
+	replies, err := m.Begin(chatID, "form")
+	assert.NoError(t, err)
+	assert.Equal(t, "Имя?", replies[0].Text)
+
+	replies, handled, err := m.Handle(ctx, Input{ChatID: chatID, Text: "Иван"})
+	assert.NoError(t, err)
+	assert.True(t, handled)
+	assert.Equal(t, "Возраст?", replies[0].Text)
+
+	replies, _, err = m.Handle(ctx, Input{ChatID: chatID, Text: "30"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Готово", replies[0].Text)
+	assert.Equal(t, map[string]string{"name": "Иван", "age": "30"}, data)
+
+	_, ok := m.Session(chatID)
+	assert.False(t, ok, "Сессия должна завершиться после Done")
+}
+
+func TestMachine_ValidationReprompts(t *testing.T) {
+	var data map[string]string
+	m := New(testFlow(&data))
+	ctx := context.Background()
+
+	m.BeginAt(chatID, "form", "age", map[string]string{"name": "Иван"})
+	replies, handled, err := m.Handle(ctx, Input{ChatID: chatID, Text: "много"})
+
+	assert.NoError(t, err)
+	assert.True(t, handled)
+	assert.Len(t, replies, 2)
+	assert.Equal(t, "Нужно число", replies[0].Text)
+	assert.Equal(t, "Возраст?", replies[1].Text)
+
+	s, _ := m.Session(chatID)
+	assert.Equal(t, "age", s.State)
+	assert.Nil(t, data)
+}
+
+func TestMachine_BackAndCancel(t *testing.T) {
+	var data map[string]string
+	m := New(testFlow(&data))
+	ctx := context.Background()
+
+	m.Begin(chatID, "form")
+	m.Handle(ctx, Input{ChatID: chatID, Text: "Иван"})
+
+	replies, _, err := m.Handle(ctx, Input{ChatID: chatID, Callback: CallbackBack})
+	assert.NoError(t, err)
+	assert.Equal(t, "Имя?", replies[0].Text)
+	s, _ := m.Session(chatID)
+	assert.Equal(t, "name", s.State)
+
+	replies, handled, _ := m.Handle(ctx, Input{ChatID: chatID, Callback: CallbackCancel})
+	assert.True(t, handled)
+	assert.Equal(t, "Действие отменено", replies[0].Text)
+	_, ok := m.Session(chatID)
+	assert.False(t, ok)
+}
+
+func TestMachine_NoSession(t *testing.T) {
+	m := New()
+	_, handled, err := m.Handle(context.Background(), Input{ChatID: chatID, Text: "привет"})
+	assert.NoError(t, err)
+	assert.False(t, handled)
+
+	_, err = m.Begin(chatID, "missing")
+	assert.ErrorIs(t, err, ErrUnknownFlow)
+}
+
+func TestMachine_DoneErrorKeepsSession(t *testing.T) {
+	flow := &Flow{
+		Name:    "save",
+		Initial: "title",
+		States:  []State{{Name: "title", Prompt: "Заголовок?", Field: "title", Next: End}},
+		Done: func(ctx context.Context, in Input, s *Session) (Reply, error) {
+			return Reply{}, errors.New("database connection lost")
+		},
+	}
+	m := New(flow)
+
+	m.Begin(chatID, "save")
+	_, handled, err := m.Handle(context.Background(), Input{ChatID: chatID, Text: "Купить хлеб"})
+
+	assert.True(t, handled)
+	assert.Error(t, err)
+	s, ok := m.Session(chatID)
+	assert.True(t, ok)
+	assert.Equal(t, "title", s.State)
+}
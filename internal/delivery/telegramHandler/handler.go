@@ -2,44 +2,86 @@ package telegramHandler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
 	"strings"
+	"task-traker/internal/delivery/telegramHandler/fsm"
+	"task-traker/internal/domain"
+	"task-traker/internal/i18n"
 	"task-traker/internal/service"
 	"task-traker/pkg/telegram"
-	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
-type State int
-
-const (
-	StateIdle State = iota
-	StateWaitTaskTitle
-	StateWaitTaskDeadline
-)
-
-type UserSession struct {
-	State State
-	Title string
-}
+// messageMode - разметка сообщений бота с пользовательским текстом.
+const messageMode = telegram.ModeHTML
 
 type Handler struct {
-	Bot         *telegram.Client
+	Bot         telegram.Bot
 	TaskService *service.TaskService
-	Sessions    map[int64]*UserSession
+	Dialogs     *fsm.Machine
+	// Workers - число воркеров, параллельно обрабатывающих апдейты.
+	Workers int
+
+	webhookUpdates chan tgbotapi.Update
 }
 
-func (h Handler) Start(ctx context.Context) error {
-	if h.Sessions == nil {
-		h.Sessions = make(map[int64]*UserSession)
+func NewHandler(bot telegram.Bot, taskService *service.TaskService) *Handler {
+	h := &Handler{
+		Bot:            bot,
+		TaskService:    taskService,
+		webhookUpdates: make(chan tgbotapi.Update, shardQueueSize),
 	}
+	h.Dialogs = fsm.New(h.addTaskFlow(), h.editTaskFlow())
+	return h
+}
 
+// Start получает апдейты через long polling.
+func (h Handler) Start(ctx context.Context) error {
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = 60
-	botAPI := h.Bot.GetBotAPI()
-	updates := botAPI.GetUpdatesChan(u)
+	updates := h.Bot.GetUpdatesChan(u)
+	defer h.Bot.StopReceivingUpdates()
+
+	return h.serve(ctx, updates)
+}
+
+// StartWebhook регистрирует webhook и обрабатывает апдейты, которые
+// приходят в WebhookHandler. При остановке webhook удаляется.
+func (h Handler) StartWebhook(ctx context.Context, url, secret string) error {
+	if err := h.Bot.SetWebhook(url, secret); err != nil {
+		return fmt.Errorf("setWebhook: %w", err)
+	}
+	slog.Info("Telegram webhook registered", "url", url)
+	defer func() {
+		if err := h.Bot.DeleteWebhook(); err != nil {
+			slog.Error("deleteWebhook error", "error", err)
+		}
+	}()
+
+	return h.serve(ctx, h.webhookUpdates)
+}
+
+// serve передает апдейты в диспетчер до отмены ctx, затем дожидается
+// обработки уже принятых апдейтов.
+func (h Handler) serve(ctx context.Context, updates <-chan tgbotapi.Update) error {
+	// Меню команд не нужно для работы бота, поэтому ошибка не прерывает запуск
+	if err := h.RegisterCommands(); err != nil {
+		slog.Error("setMyCommands error", "error", err)
+	}
+
+	dispatcher := NewDispatcher(h.Workers, h.handleUpdate)
+	dispatcher.Run(ctx)
+	defer func() {
+		drainCtx, cancel := context.WithTimeout(context.Background(), drainGracePeriod)
+		defer cancel()
+		if err := dispatcher.Shutdown(drainCtx); err != nil {
+			slog.Error("Telegram updates were not drained", "error", err)
+		}
+	}()
 
 	for {
 		select {
@@ -49,154 +91,163 @@ func (h Handler) Start(ctx context.Context) error {
 			if !ok {
 				return nil
 			}
-
-			requestCtx, cancel := context.WithTimeout(ctx, time.Second*5)
-
-			func() {
-				defer cancel()
-				if update.CallbackQuery != nil {
-					h.handleDeleteTask(requestCtx, update.CallbackQuery)
-					return
-				}
-
-				if update.Message == nil {
-					return
-				}
-
-				slog.Info("Новое сообщение", "от", update.Message.From.UserName, "текст", update.Message.Text)
-				userID := update.Message.Chat.ID
-				session, ok := h.Sessions[userID]
-				if !ok {
-					session = &UserSession{State: StateIdle}
-					h.Sessions[userID] = session
-				}
-
-				if update.Message.IsCommand() {
-					switch update.Message.Command() {
-					case "start":
-						h.handleStartCommand(requestCtx, update.Message)
-					case "add":
-						h.handleAddCommand(requestCtx, update.Message)
-						session.State = StateWaitTaskTitle
-					case "list":
-						h.handleListCommand(requestCtx, update.Message)
-					case "login":
-						h.handleLoginCommand(requestCtx, update.Message)
-					default:
-						h.Bot.SendMessage(userID, "Неизвестная команда")
-					}
-					return
-				}
-
-				switch session.State {
-				case StateWaitTaskTitle:
-					h.handleAddTitleTask(requestCtx, update.Message, session)
-				case StateWaitTaskDeadline:
-					h.handleAddDeadlineTask(requestCtx, update.Message, session)
-				case StateIdle:
-					switch update.Message.Text {
-					case "➕ Добавить задачу":
-						h.handleAddCommand(requestCtx, update.Message)
-						session.State = StateWaitTaskTitle
-					case "📋 Все задачи":
-						h.handleListCommand(requestCtx, update.Message)
-						// Добавить логику
-					default:
-						h.Bot.SendMessage(userID, "Используйте кнопки меню или команды.")
-					}
-				}
-			}()
+			if err := dispatcher.Dispatch(ctx, update); err != nil {
+				return err
+			}
 		}
 	}
 }
 
-func (h Handler) handleStartCommand(ctx context.Context, m *tgbotapi.Message) {
-	msg := tgbotapi.NewMessage(m.From.ID,
-		"Привет! Я запоминаю задачи и присылаю уведомления о дедлайне.")
-	msg.ReplyMarkup = mainMenuKeyboard()
-	h.Bot.SendMessageWithMarkup(m.From.ID, msg)
-}
+func (h Handler) handleUpdate(ctx context.Context, update tgbotapi.Update) {
+	if user := update.SentFrom(); user != nil {
+		ctx = i18n.WithLang(ctx, h.TaskService.Language(ctx, user.ID, user.LanguageCode))
+	}
+	lang := i18n.FromContext(ctx)
 
-func (h Handler) handleListCommand(ctx context.Context, m *tgbotapi.Message) {
-	userID := m.Chat.ID
-	tasks, err := h.TaskService.Repo.GetTasksByUserID(ctx, userID)
-	if err != nil {
-		slog.Error("handleListCommand error", "error", err)
-		h.Bot.SendMessage(userID, "Ошибка сервера")
+	switch {
+	case update.CallbackQuery != nil:
+		h.handleCallback(ctx, update.CallbackQuery)
+		return
+	case update.InlineQuery != nil:
+		h.handleInlineQuery(ctx, update.InlineQuery)
+		return
+	case update.ChosenInlineResult != nil:
+		h.handleChosenInlineResult(ctx, update.ChosenInlineResult)
 		return
 	}
-	if len(tasks) == 0 {
-		h.Bot.SendMessage(userID, "У вас нет активных задач🎉")
+
+	if update.Message == nil {
 		return
 	}
 
-	h.Bot.SendMessage(userID, "📋 Ваши активные задачи:")
-	for i, task := range tasks {
-		deadlineStr := task.Deadline.Format("02.01.2006 15:04")
-		text := fmt.Sprintf("%d. %s\n ⏰ %s\n\n", i+1, task.Title, deadlineStr)
-		keyboard := deleteKeyboard(task.ID)
+	slog.Info("Новое сообщение", "от", update.Message.From.UserName, "текст", update.Message.Text)
+	userID := update.Message.Chat.ID
 
-		msg := tgbotapi.NewMessage(userID, text)
-		msg.ReplyMarkup = keyboard
-		h.Bot.SendMessageWithMarkup(userID, msg)
+	if update.Message.IsCommand() {
+		h.handleCommand(ctx, update.Message)
+		return
+	}
+
+	// В группах бот отвечает только на команды
+	if !update.Message.Chat.IsPrivate() {
+		return
+	}
+	if update.Message.ForwardDate != 0 {
+		h.handleForward(ctx, update.Message)
+		return
+	}
+
+	if h.handleDialog(ctx, fsm.Input{
+		ChatID: update.Message.Chat.ID,
+		UserID: update.Message.From.ID,
+		Text:   update.Message.Text,
+	}) {
+		return
+	}
+
+	switch {
+	case isMenuButton(update.Message.Text, menuAddTask):
+		h.handleAddCommand(ctx, update.Message)
+	case isMenuButton(update.Message.Text, menuListTasks):
+		h.handleListCommand(ctx, update.Message)
+	default:
+		if !h.handleQuickAdd(ctx, update.Message, update.Message.Text) {
+			h.Bot.SendMessage(userID, lang.T("Используйте кнопки меню или команды."))
+		}
 	}
 }
 
-func (h Handler) handleAddCommand(ctx context.Context, m *tgbotapi.Message) {
-	h.Bot.SendMessage(m.From.ID, "Напишите текст задачи")
+func (h Handler) handleStartCommand(ctx context.Context, m *tgbotapi.Message) {
+	lang := i18n.FromContext(ctx)
+	h.Bot.SendWithKeyboard(m.From.ID,
+		lang.T("Привет! Я запоминаю задачи и присылаю уведомления о дедлайне."),
+		mainMenuKeyboard(lang))
 }
 
-func (h Handler) handleAddTitleTask(ctx context.Context, m *tgbotapi.Message, session *UserSession) {
-	session.Title = m.Text
-	session.State = StateWaitTaskDeadline
-	h.Bot.SendMessage(m.Chat.ID, "Теперь введите дату и время (ДД.ММ.ГГГГ ЧЧ:ММ):")
+// handleAddCommand запускает диалог добавления. "/add <текст>" создает
+// задачу сразу, а если в тексте нет даты - спрашивает только срок.
+func (h Handler) handleAddCommand(ctx context.Context, m *tgbotapi.Message) {
+	args := strings.TrimSpace(m.CommandArguments())
+	if args == "" {
+		h.beginDialog(ctx, m.Chat.ID, flowAddTask)
+		return
+	}
+	if !h.handleQuickAdd(ctx, m, args) {
+		h.beginQuickAddDialog(ctx, m.Chat.ID, args)
+	}
 }
 
-func (h Handler) handleAddDeadlineTask(ctx context.Context, m *tgbotapi.Message, session *UserSession) {
-	err := h.TaskService.CreateTask(ctx, m.From.ID, session.Title, m.Text)
-	if err != nil {
-		slog.Error("Task creation filed", "error", err)
-		h.Bot.SendMessage(m.From.ID, "Ошибка формата даты. Попробуйте еще раз.")
+func (h Handler) handleCancelCommand(ctx context.Context, m *tgbotapi.Message) {
+	replies, ok := h.Dialogs.Cancel(m.Chat.ID)
+	if !ok {
+		h.Bot.SendMessage(m.Chat.ID, i18n.FromContext(ctx).T("Нечего отменять"))
 		return
 	}
-	h.Bot.SendMessage(m.From.ID, "✅ Задача сохранена!")
-	session.State = StateIdle
-	session.Title = ""
+	h.sendReplies(ctx, m.Chat.ID, replies)
 }
 
-func (h Handler) handleDeleteTask(ctx context.Context, cb *tgbotapi.CallbackQuery) {
+func (h Handler) handleCallback(ctx context.Context, cb *tgbotapi.CallbackQuery) {
 	// Убираем часики
-	callbackConfig := tgbotapi.NewCallback(cb.ID, "")
-	h.Bot.GetBotAPI().Request(callbackConfig)
+	h.Bot.AnswerCallback(cb.ID, "")
+
+	if cb.Message == nil {
+		return
+	}
+	if h.handleDialog(ctx, fsm.Input{
+		ChatID:   cb.Message.Chat.ID,
+		UserID:   cb.From.ID,
+		Callback: cb.Data,
+	}) {
+		return
+	}
+	switch {
+	case strings.HasPrefix(cb.Data, "list:"):
+		h.handleListCallback(ctx, cb)
+	case strings.HasPrefix(cb.Data, "done_"):
+		h.handleCompleteTask(ctx, cb)
+	case strings.HasPrefix(cb.Data, "edit_"):
+		h.handleEditTask(ctx, cb)
+	case strings.HasPrefix(cb.Data, "delete_"):
+		h.handleDeleteTask(ctx, cb)
+	case strings.HasPrefix(cb.Data, "undo_"):
+		h.handleUndoTask(ctx, cb)
+	case strings.HasPrefix(cb.Data, "lang:"):
+		h.handleLangCallback(ctx, cb)
+	}
+}
 
+func (h Handler) handleDeleteTask(ctx context.Context, cb *tgbotapi.CallbackQuery) {
 	data := cb.Data
 	if after, ok := strings.CutPrefix(data, "delete_"); ok {
-		idStr := after
-
-		err := h.TaskService.Repo.DeleteByID(ctx, idStr)
+		id, err := strconv.Atoi(after)
 		if err != nil {
-			slog.Error("Ошибка удаления", "id", idStr, "error", err)
-			h.Bot.SendMessage(cb.Message.Chat.ID, "Не удалось удалить задачу")
 			return
 		}
-		editMsg := tgbotapi.NewEditMessageText(cb.Message.Chat.ID, cb.Message.MessageID, "🗑 Задача удалена")
-		if _, err := h.Bot.GetBotAPI().Send(editMsg); err != nil {
-			slog.Error("Ошибка редактирования сообщения", "error", err)
+
+		// Уже удаленная задача просто пропадет из обновленного списка
+		err = h.TaskService.DeleteTask(ctx, cb.From.ID, id)
+		if err != nil && !errors.Is(err, domain.ErrTaskNotFound) {
+			slog.Error("Ошибка удаления", "id", id, "error", err)
+			h.Bot.SendMessage(cb.Message.Chat.ID, i18n.FromContext(ctx).T("Не удалось удалить задачу"))
+			return
 		}
+		state, _ := listStateOf(cb.Message)
+		h.refreshList(ctx, cb.From.ID, cb.Message.Chat.ID, cb.Message.MessageID, state)
 	}
 }
 
 func (h Handler) handleLoginCommand(ctx context.Context, m *tgbotapi.Message) {
+	lang := i18n.FromContext(ctx)
 	code, err := h.TaskService.GenerateAuthCode(ctx, m.Chat.ID)
 	if err != nil {
 		slog.Error("Auth code gen error", "error", err)
-		h.Bot.SendMessage(m.Chat.ID, "❌ Ошибка генерации кода")
+		h.Bot.SendMessage(m.Chat.ID, lang.T("❌ Ошибка генерации кода"))
 		return
 	}
-	msg := fmt.Sprintf("🔐 *Авторизация*\n\n"+
-		"Ваш ID: %d\n"+
-		"Ваш код: %s",
-		m.Chat.ID, code)
+	msg := telegram.Format(messageMode, lang.T("🔐 %s\n\nВаш ID: %s\nВаш код: %s"),
+		telegram.Bold(lang.T("Авторизация")),
+		telegram.Code(strconv.FormatInt(m.Chat.ID, 10)),
+		telegram.Code(code))
 
-	h.Bot.SendMessage(m.Chat.ID, msg)
+	h.Bot.SendFormatted(m.Chat.ID, msg, nil)
 }
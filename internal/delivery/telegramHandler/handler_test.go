@@ -0,0 +1,603 @@
+package telegramHandler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"task-traker/internal/domain"
+	"task-traker/internal/i18n"
+	"task-traker/internal/repository"
+	"task-traker/internal/repository/repotest"
+	"task-traker/internal/service"
+	"task-traker/pkg/telegram/telegramtest"
+
+	"github.com/alicebob/miniredis/v2"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testUserID = int64(1001)
+
+type botEnv struct {
+	server *telegramtest.Server
+	repo   *repotest.MemoryRepo
+	redis  *miniredis.Miniredis
+}
+
+// startBot запускает бота в режиме long polling против фейкового Bot API.
+func startBot(t *testing.T) *botEnv {
+	t.Helper()
+	env := &botEnv{
+		server: telegramtest.NewServer(t),
+		repo:   repotest.NewMemoryRepo(),
+		redis:  miniredis.RunT(t),
+	}
+	taskService := &service.TaskService{
+		Repo:  env.repo,
+		Redis: repository.NewRedisRepo(env.redis.Addr()),
+	}
+	h := NewHandler(env.server.Client(t), taskService)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.Start(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return env
+}
+
+// waitText ждет n-е отправленное ботом сообщение и возвращает его текст.
+func (e *botEnv) waitText(t *testing.T, n int) string {
+	t.Helper()
+	calls := e.server.WaitCalls(t, "sendMessage", n)
+	return calls[n-1].Params.Get("text")
+}
+
+func TestBot_Start(t *testing.T) {
+	env := startBot(t)
+
+	env.server.InjectUpdate(telegramtest.TextMessage(testUserID, "/start"))
+
+	call := env.server.WaitCalls(t, "sendMessage", 1)[0]
+	assert.Equal(t, fmt.Sprint(testUserID), call.Params.Get("chat_id"))
+	assert.Contains(t, call.Params.Get("text"), "Привет!")
+	assert.Contains(t, call.Params.Get("reply_markup"), "➕ Добавить задачу")
+}
+
+func TestBot_AddTask(t *testing.T) {
+	env := startBot(t)
+	deadline := time.Now().Add(24 * time.Hour).Format("02.01.2006 15:04")
+
+	env.server.InjectUpdate(telegramtest.TextMessage(testUserID, "/add"))
+	assert.Equal(t, "Напишите текст задачи", env.waitText(t, 1))
+
+	env.server.InjectUpdate(telegramtest.TextMessage(testUserID, "Купить хлеб"))
+	assert.Contains(t, env.waitText(t, 2), "Теперь введите дату")
+
+	env.server.InjectUpdate(telegramtest.TextMessage(testUserID, "вчера"))
+	assert.Equal(t, "Ошибка формата даты. Попробуйте еще раз.", env.waitText(t, 3))
+
+	env.server.InjectUpdate(telegramtest.TextMessage(testUserID, deadline))
+	assert.Equal(t, "✅ Задача сохранена!", env.waitText(t, 5))
+
+	tasks := env.repo.Tasks()
+	require.Len(t, tasks, 1)
+	assert.Equal(t, testUserID, tasks[0].UserID)
+	assert.Equal(t, "Купить хлеб", tasks[0].Title)
+}
+
+func TestBot_ListAndDelete(t *testing.T) {
+	env := startBot(t)
+	ctx := context.Background()
+	env.repo.Create(ctx, &domain.Task{UserID: testUserID, Title: "Позвонить маме", Deadline: time.Now().Add(2 * time.Hour)})
+	env.repo.Create(ctx, &domain.Task{UserID: testUserID, Title: "Купить хлеб", Deadline: time.Now().Add(time.Hour)})
+	env.repo.Create(ctx, &domain.Task{UserID: 7, Title: "Чужая задача", Deadline: time.Now().Add(time.Hour)})
+
+	env.server.InjectUpdate(telegramtest.TextMessage(testUserID, "/list"))
+
+	call := env.server.WaitCalls(t, "sendMessage", 1)[0]
+	text := call.Params.Get("text")
+	assert.Contains(t, text, "📋 Ваши активные задачи:")
+	assert.Contains(t, text, "1. <b>Купить хлеб</b>")
+	assert.Contains(t, text, "2. <b>Позвонить маме</b>")
+	assert.NotContains(t, text, "Чужая задача")
+	assert.Contains(t, call.Params.Get("reply_markup"), "delete_2")
+
+	env.server.InjectUpdate(telegramtest.CallbackQuery(testUserID, 42, "delete_2"))
+
+	edit := env.server.WaitCalls(t, "editMessageText", 1)[0]
+	assert.Equal(t, "42", edit.Params.Get("message_id"))
+	assert.Contains(t, edit.Params.Get("text"), "1. <b>Позвонить маме</b>")
+	assert.NotContains(t, edit.Params.Get("text"), "Купить хлеб")
+	assert.Len(t, env.server.Calls("answerCallbackQuery"), 1)
+
+	for _, task := range env.repo.Tasks() {
+		assert.NotEqual(t, 2, task.ID)
+	}
+}
+
+func TestBot_ListEscapesMarkup(t *testing.T) {
+	env := startBot(t)
+	env.repo.Create(context.Background(), &domain.Task{UserID: testUserID, Title: "a<b> & *c_", Deadline: time.Now().Add(time.Hour)})
+
+	env.server.InjectUpdate(telegramtest.TextMessage(testUserID, "/list"))
+
+	call := env.server.WaitCalls(t, "sendMessage", 1)[0]
+	assert.Equal(t, "HTML", call.Params.Get("parse_mode"))
+	assert.Contains(t, call.Params.Get("text"), "1. <b>a&lt;b&gt; &amp; *c_</b>")
+}
+
+func TestBot_ListPagination(t *testing.T) {
+	env := startBot(t)
+	ctx := context.Background()
+	for i := range 7 {
+		env.repo.Create(ctx, &domain.Task{
+			UserID:   testUserID,
+			Title:    fmt.Sprintf("Задача %d", i+1),
+			Deadline: service.Now().Add(time.Duration(i+1) * time.Hour),
+			Tags:     []string{"home"},
+		})
+	}
+
+	env.server.InjectUpdate(telegramtest.TextMessage(testUserID, "/list"))
+	call := env.server.WaitCalls(t, "sendMessage", 1)[0]
+	assert.Contains(t, call.Params.Get("text"), "5. <b>Задача 5</b>")
+	assert.NotContains(t, call.Params.Get("text"), "Задача 6")
+	assert.Contains(t, call.Params.Get("reply_markup"), "·1/2·")
+	assert.Contains(t, call.Params.Get("reply_markup"), "#home")
+
+	env.server.InjectUpdate(telegramtest.CallbackQuery(testUserID, 42, "list:1:"))
+	edit := env.server.WaitCalls(t, "editMessageText", 1)[0]
+	assert.Contains(t, edit.Params.Get("text"), "6. <b>Задача 6</b>")
+	assert.Contains(t, edit.Params.Get("text"), "7. <b>Задача 7</b>")
+	assert.Contains(t, edit.Params.Get("reply_markup"), "·2/2·")
+
+	env.server.InjectUpdate(telegramtest.CallbackQuery(testUserID, 42, "list:0:overdue"))
+	edit = env.server.WaitCalls(t, "editMessageText", 2)[1]
+	assert.Contains(t, edit.Params.Get("text"), "Нет задач по этому фильтру.")
+	assert.Contains(t, edit.Params.Get("reply_markup"), "✓ Просрочено")
+}
+
+func TestBot_ListTagsStayWithFilter(t *testing.T) {
+	env := startBot(t)
+	ctx := context.Background()
+	env.repo.Create(ctx, &domain.Task{UserID: testUserID, Title: "Купить хлеб", Deadline: time.Now().Add(time.Hour), Tags: []string{"дом"}})
+	env.repo.Create(ctx, &domain.Task{UserID: testUserID, Title: "Отчет", Deadline: time.Now().Add(time.Hour), Tags: []string{"работа"}})
+
+	// С фильтром по тегу остальные теги остаются на клавиатуре
+	env.server.InjectUpdate(telegramtest.CallbackQuery(testUserID, 42, "list:0:#дом"))
+	keyboard := env.server.WaitCalls(t, "editMessageText", 1)[0].Params.Get("reply_markup")
+	assert.Contains(t, keyboard, "✓ #дом")
+	assert.Contains(t, keyboard, "list:0:#работа")
+
+	// И когда под фильтр не подходит ни одна задача
+	env.server.InjectUpdate(telegramtest.CallbackQuery(testUserID, 42, "list:0:overdue"))
+	keyboard = env.server.WaitCalls(t, "editMessageText", 2)[1].Params.Get("reply_markup")
+	assert.Contains(t, keyboard, "list:0:#дом")
+	assert.Contains(t, keyboard, "list:0:#работа")
+}
+
+func TestBot_ListTagFilterIgnoresTitle(t *testing.T) {
+	env := startBot(t)
+	ctx := context.Background()
+	env.repo.Create(ctx, &domain.Task{UserID: testUserID, Title: "Купить хлеб", Deadline: time.Now().Add(time.Hour), Tags: []string{"дом"}})
+	env.repo.Create(ctx, &domain.Task{UserID: testUserID, Title: "Разобрать #дом в заметках", Deadline: time.Now().Add(time.Hour)})
+
+	env.server.InjectUpdate(telegramtest.CallbackQuery(testUserID, 42, "list:0:#дом"))
+	text := env.server.WaitCalls(t, "editMessageText", 1)[0].Params.Get("text")
+	assert.Contains(t, text, "Купить хлеб")
+	assert.NotContains(t, text, "Разобрать")
+}
+
+func TestFilterRows_KeepsActiveTag(t *testing.T) {
+	rows := filterRows(i18n.RU, listState{filter: "#архив"}, []string{"дом", "работа"})
+
+	require.Len(t, rows, 2)
+	var labels []string
+	for _, b := range rows[1] {
+		labels = append(labels, b.Text)
+	}
+	assert.Equal(t, []string{"✓ #архив", "#дом", "#работа"}, labels)
+}
+
+func TestFilterRange(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC) }
+
+	from, to, ok := filterRange(filterToday, now)
+	assert.True(t, ok)
+	assert.Equal(t, []time.Time{day(10), day(11)}, []time.Time{from, to})
+
+	from, to, _ = filterRange(filterWeek, now)
+	assert.Equal(t, []time.Time{now, day(17)}, []time.Time{from, to})
+
+	from, to, _ = filterRange(filterOverdue, now)
+	assert.True(t, from.IsZero())
+	assert.Equal(t, now, to)
+
+	_, _, ok = filterRange("#work", now)
+	assert.False(t, ok)
+}
+
+func TestBot_TodayAndWeek(t *testing.T) {
+	env := startBot(t)
+	ctx := context.Background()
+	now := service.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 0, 0, time.UTC)
+	env.repo.Create(ctx, &domain.Task{UserID: testUserID, Title: "Сегодня вечером", Deadline: today})
+	env.repo.Create(ctx, &domain.Task{UserID: testUserID, Title: "Послезавтра", Deadline: today.AddDate(0, 0, 2)})
+	env.repo.Create(ctx, &domain.Task{UserID: testUserID, Title: "Через месяц", Deadline: today.AddDate(0, 1, 0)})
+
+	env.server.InjectUpdate(telegramtest.TextMessage(testUserID, "/today"))
+	text := env.waitText(t, 1)
+	assert.Contains(t, text, "(Сегодня)")
+	assert.Contains(t, text, "Сегодня вечером")
+	assert.NotContains(t, text, "Послезавтра")
+
+	env.server.InjectUpdate(telegramtest.TextMessage(testUserID, "/week"))
+	text = env.waitText(t, 2)
+	assert.Contains(t, text, "📅 <b>Сегодня</b>\n\n1. <b>Сегодня вечером</b>")
+	day := today.AddDate(0, 0, 2)
+	assert.Contains(t, text, fmt.Sprintf("📅 <b>%s, %s</b>\n\n2. <b>Послезавтра</b>", weekdays[day.Weekday()], day.Format("02.01")))
+	assert.NotContains(t, text, "Через месяц")
+}
+
+func TestBot_Search(t *testing.T) {
+	env := startBot(t)
+	ctx := context.Background()
+	env.repo.Create(ctx, &domain.Task{UserID: testUserID, Title: "Купить Молоко", Deadline: time.Now().Add(time.Hour)})
+	env.repo.Create(ctx, &domain.Task{UserID: testUserID, Title: "Позвонить маме", Deadline: time.Now().Add(time.Hour)})
+	env.repo.Create(ctx, &domain.Task{UserID: 7, Title: "Чужое молоко", Deadline: time.Now().Add(time.Hour)})
+
+	env.server.InjectUpdate(telegramtest.TextMessage(testUserID, "/search молоко"))
+	call := env.server.WaitCalls(t, "sendMessage", 1)[0]
+	text := call.Params.Get("text")
+	assert.Contains(t, text, "(поиск «молоко»)")
+	assert.Contains(t, text, "1. <b>Купить Молоко</b>")
+	assert.NotContains(t, text, "маме")
+	assert.NotContains(t, text, "Чужое")
+	assert.Contains(t, call.Params.Get("reply_markup"), "list:0:?молоко")
+
+	env.server.InjectUpdate(telegramtest.TextMessage(testUserID, "/search"))
+	assert.Contains(t, env.waitText(t, 2), "/search молоко")
+
+	env.server.InjectUpdate(telegramtest.TextMessage(testUserID, "/search "+strings.Repeat("очень ", 10)))
+	assert.Equal(t, "Слишком длинный запрос, сократите его.", env.waitText(t, 3))
+}
+
+func TestBot_Stats(t *testing.T) {
+	env := startBot(t)
+	ctx := context.Background()
+	task := &domain.Task{UserID: testUserID, Title: "Сделано", Deadline: time.Now().Add(time.Hour)}
+	env.repo.Create(ctx, task)
+	env.repo.Complete(ctx, testUserID, task.ID)
+	env.repo.Create(ctx, &domain.Task{UserID: testUserID, Title: "В работе", Deadline: time.Now().Add(time.Hour)})
+
+	env.server.InjectUpdate(telegramtest.TextMessage(testUserID, "/stats"))
+
+	text := env.waitText(t, 1)
+	assert.Contains(t, text, "Создано: 2\nВыполнено: 1, в срок 100%")
+	assert.Contains(t, text, "🔥 Серия: 1 день (рекорд: 1 день)")
+	week := service.WeekStart(service.Now()).Format("02.01")
+	assert.Contains(t, text, "<code>"+week+" ██████████ 1</code>")
+	assert.Equal(t, 4, strings.Count(text, "<code>"))
+}
+
+func TestBar(t *testing.T) {
+	assert.Equal(t, "", bar(0, 0, 10))
+	assert.Equal(t, "", bar(0, 4, 10))
+	assert.Equal(t, "█████", bar(2, 4, 10))
+	assert.Equal(t, "█▍", bar(1, 7, 10))
+	assert.Equal(t, "██████████", bar(4, 4, 10))
+}
+
+func TestBot_Login(t *testing.T) {
+	env := startBot(t)
+
+	env.server.InjectUpdate(telegramtest.TextMessage(testUserID, "/login"))
+
+	text := env.waitText(t, 1)
+	assert.Contains(t, text, fmt.Sprintf("Ваш ID: <code>%d</code>", testUserID))
+
+	code, err := env.redis.Get(fmt.Sprintf("otp:%d", testUserID))
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(text, "Ваш код: <code>"+code+"</code>"))
+}
+
+func TestBot_EditTask(t *testing.T) {
+	env := startBot(t)
+	ctx := context.Background()
+	task := &domain.Task{UserID: testUserID, Title: "Купить хлеб", Deadline: time.Now().Add(time.Hour), RemindBefore: 15}
+	env.repo.Create(ctx, task)
+
+	env.server.InjectUpdate(telegramtest.CallbackQuery(testUserID, 42, fmt.Sprintf("edit_%d", task.ID)))
+	assert.Equal(t, "Что изменить в задаче?", env.waitText(t, 1))
+
+	env.server.InjectUpdate(telegramtest.CallbackQuery(testUserID, 43, "field:title"))
+	assert.Equal(t, "Введите новое название задачи:", env.waitText(t, 2))
+
+	env.server.InjectUpdate(telegramtest.TextMessage(testUserID, "Купить батон"))
+	assert.Equal(t, "✅ Задача обновлена", env.waitText(t, 3))
+
+	edit := env.server.WaitCalls(t, "editMessageText", 1)[0]
+	assert.Equal(t, "42", edit.Params.Get("message_id"))
+	assert.Contains(t, edit.Params.Get("text"), "Купить батон")
+
+	env.server.InjectUpdate(telegramtest.CallbackQuery(testUserID, 42, fmt.Sprintf("edit_%d", task.ID)))
+	env.waitText(t, 4)
+	env.server.InjectUpdate(telegramtest.CallbackQuery(testUserID, 44, "field:priority"))
+	env.waitText(t, 5)
+	env.server.InjectUpdate(telegramtest.CallbackQuery(testUserID, 45, "set:1"))
+	assert.Equal(t, "✅ Задача обновлена", env.waitText(t, 6))
+
+	saved, err := env.repo.GetTaskByID(ctx, testUserID, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Купить батон", saved.Title)
+	assert.Equal(t, domain.PriorityHigh, saved.Priority)
+}
+
+func TestBot_EditRejectsForgedValue(t *testing.T) {
+	env := startBot(t)
+	ctx := context.Background()
+	task := &domain.Task{UserID: testUserID, Title: "Купить хлеб", Deadline: time.Now().Add(time.Hour), RemindBefore: 15}
+	env.repo.Create(ctx, task)
+
+	env.server.InjectUpdate(telegramtest.CallbackQuery(testUserID, 42, fmt.Sprintf("edit_%d", task.ID)))
+	env.waitText(t, 1)
+	env.server.InjectUpdate(telegramtest.CallbackQuery(testUserID, 43, "field:priority"))
+	env.waitText(t, 2)
+
+	// Подделанные данные кнопки: диалог переспрашивает, а не сохраняет 0
+	env.server.InjectUpdate(telegramtest.CallbackQuery(testUserID, 44, "set:7"))
+	assert.Equal(t, "Выберите вариант кнопкой.", env.waitText(t, 3))
+	assert.Equal(t, "Выберите приоритет:", env.waitText(t, 4))
+	env.server.InjectUpdate(telegramtest.CallbackQuery(testUserID, 44, "set:x"))
+	assert.Equal(t, "Выберите вариант кнопкой.", env.waitText(t, 5))
+	env.server.InjectUpdate(telegramtest.CallbackQuery(testUserID, 44, "set:1"))
+	assert.Equal(t, "✅ Задача обновлена", env.waitText(t, 7))
+
+	env.server.InjectUpdate(telegramtest.CallbackQuery(testUserID, 42, fmt.Sprintf("edit_%d", task.ID)))
+	env.waitText(t, 8)
+	env.server.InjectUpdate(telegramtest.CallbackQuery(testUserID, 45, "field:remind_before"))
+	env.waitText(t, 9)
+	env.server.InjectUpdate(telegramtest.CallbackQuery(testUserID, 46, "set:99999999999"))
+	assert.Equal(t, "Введите целое число минут, например 30.", env.waitText(t, 10))
+	env.server.InjectUpdate(telegramtest.CallbackQuery(testUserID, 46, "set:-5"))
+	assert.Equal(t, "Введите целое число минут, например 30.", env.waitText(t, 12))
+	env.server.InjectUpdate(telegramtest.CallbackQuery(testUserID, 46, "set:60"))
+	assert.Equal(t, "✅ Задача обновлена", env.waitText(t, 14))
+
+	saved, err := env.repo.GetTaskByID(ctx, testUserID, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PriorityHigh, saved.Priority)
+	assert.Equal(t, 60, saved.RemindBefore)
+}
+
+func TestBot_EditForeignTaskIsRejected(t *testing.T) {
+	env := startBot(t)
+	ctx := context.Background()
+	foreign := &domain.Task{UserID: 7, Title: "Чужая задача", Deadline: time.Now().Add(time.Hour)}
+	env.repo.Create(ctx, foreign)
+
+	// Подделанные данные кнопки с id чужой задачи
+	env.server.InjectUpdate(telegramtest.CallbackQuery(testUserID, 42, fmt.Sprintf("edit_%d", foreign.ID)))
+
+	assert.Equal(t, "Задача не найдена", env.waitText(t, 1))
+	assert.Empty(t, env.server.Calls("editMessageText"))
+	saved, _ := env.repo.GetTaskByID(ctx, 7, foreign.ID)
+	assert.Equal(t, "Чужая задача", saved.Title)
+}
+
+func TestBot_QuickAddAndUndo(t *testing.T) {
+	env := startBot(t)
+
+	env.server.InjectUpdate(telegramtest.TextMessage(testUserID, "Купить молоко завтра 18:00 #дом !high"))
+	text := env.waitText(t, 1)
+	assert.Contains(t, text, "✅ Задача сохранена!")
+	assert.Contains(t, text, "🔥 <b>Купить молоко</b>")
+
+	tasks := env.repo.Tasks()
+	require.Len(t, tasks, 1)
+	assert.Equal(t, []string{"дом"}, tasks[0].Tags)
+	assert.Equal(t, domain.PriorityHigh, tasks[0].Priority)
+
+	env.server.InjectUpdate(telegramtest.CallbackQuery(testUserID, 42, fmt.Sprintf("undo_%d", tasks[0].ID)))
+	edit := env.server.WaitCalls(t, "editMessageText", 1)[0]
+	assert.Contains(t, edit.Params.Get("text"), "отменена")
+	assert.Empty(t, env.repo.Tasks())
+}
+
+func TestBot_QuickAddWithoutDeadlineAsksForIt(t *testing.T) {
+	env := startBot(t)
+
+	env.server.InjectUpdate(telegramtest.TextMessage(testUserID, "Просто текст"))
+	assert.Equal(t, "Используйте кнопки меню или команды.", env.waitText(t, 1))
+
+	env.server.InjectUpdate(telegramtest.TextMessage(testUserID, "/add Позвонить маме #семья"))
+	assert.Contains(t, env.waitText(t, 2), "Теперь введите дату")
+
+	deadline := service.Now().Add(48 * time.Hour).Format("02.01.2006 15:04")
+	env.server.InjectUpdate(telegramtest.TextMessage(testUserID, deadline))
+	assert.Equal(t, "✅ Задача сохранена!", env.waitText(t, 3))
+
+	tasks := env.repo.Tasks()
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "Позвонить маме", tasks[0].Title)
+	assert.Equal(t, []string{"семья"}, tasks[0].Tags)
+}
+
+func TestBot_InlineSearch(t *testing.T) {
+	env := startBot(t)
+	ctx := context.Background()
+	env.repo.Create(ctx, &domain.Task{UserID: testUserID, Title: "Купить хлеб", Deadline: time.Now().Add(time.Hour), Tags: []string{"дом"}})
+	env.repo.Create(ctx, &domain.Task{UserID: testUserID, Title: "Отчет", Deadline: time.Now().Add(time.Hour), Tags: []string{"работа"}})
+	env.repo.Create(ctx, &domain.Task{UserID: 999, Title: "Чужой хлеб", Deadline: time.Now().Add(time.Hour)})
+
+	env.server.InjectUpdate(telegramtest.InlineQuery(testUserID, "хлеб"))
+	call := env.server.WaitCalls(t, "answerInlineQuery", 1)[0]
+
+	var results []struct {
+		ID      string `json:"id"`
+		Title   string `json:"title"`
+		Content struct {
+			Text string `json:"message_text"`
+		} `json:"input_message_content"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(call.Params.Get("results")), &results))
+	require.Len(t, results, 1)
+	assert.Equal(t, "task_1", results[0].ID)
+	assert.Contains(t, results[0].Content.Text, "#дом")
+	assert.Equal(t, "true", call.Params.Get("is_personal"))
+}
+
+func TestBot_InlineCreate(t *testing.T) {
+	env := startBot(t)
+	query := "Позвонить маме завтра 10:00"
+
+	env.server.InjectUpdate(telegramtest.InlineQuery(testUserID, query))
+	call := env.server.WaitCalls(t, "answerInlineQuery", 1)[0]
+	assert.Contains(t, call.Params.Get("results"), `"id":"create"`)
+	assert.Empty(t, env.repo.Tasks(), "задача создается только после выбора результата")
+
+	env.server.InjectUpdate(telegramtest.ChosenInlineResult(testUserID, "create", query))
+	assert.Contains(t, env.waitText(t, 1), "Позвонить маме")
+
+	tasks := env.repo.Tasks()
+	require.Len(t, tasks, 1)
+	assert.Equal(t, testUserID, tasks[0].UserID)
+}
+
+func TestBot_ForwardedMessageBecomesTask(t *testing.T) {
+	env := startBot(t)
+
+	update := telegramtest.TextMessage(testUserID, "Созвон с командой в пятницу")
+	update.Message.ForwardDate = int(time.Now().Unix())
+	update.Message.ForwardFromChat = &tgbotapi.Chat{ID: -1001234567890, Type: "supergroup"}
+	update.Message.ForwardFromMessageID = 77
+	env.server.InjectUpdate(update)
+	assert.Equal(t, "📩 Задача: Созвон с командой в пятницу", env.waitText(t, 1))
+	assert.Contains(t, env.waitText(t, 2), "Теперь введите дату")
+
+	deadline := service.Now().Add(48 * time.Hour).Format("02.01.2006 15:04")
+	env.server.InjectUpdate(telegramtest.TextMessage(testUserID, deadline))
+	assert.Equal(t, "✅ Задача сохранена!", env.waitText(t, 3))
+
+	tasks := env.repo.Tasks()
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "Созвон с командой в пятницу", tasks[0].Title)
+	assert.Equal(t, "https://t.me/c/1234567890/77", tasks[0].SourceURL)
+}
+
+func TestBot_RemindReplyInGroup(t *testing.T) {
+	env := startBot(t)
+	group := &tgbotapi.Chat{ID: -1009876543210, Type: "supergroup", Title: "Команда"}
+
+	update := telegramtest.TextMessage(testUserID, "/remind 2h")
+	update.Message.Chat = group
+	update.Message.ReplyToMessage = &tgbotapi.Message{MessageID: 15, Chat: group, Text: "Не забыть выложить релиз"}
+	env.server.InjectUpdate(update)
+	assert.Contains(t, env.waitText(t, 1), "⏰ Напомню")
+
+	tasks := env.repo.Tasks()
+	require.Len(t, tasks, 1)
+	assert.Equal(t, testUserID, tasks[0].UserID)
+	assert.Equal(t, "Не забыть выложить релиз", tasks[0].Title)
+	assert.Equal(t, "https://t.me/c/9876543210/15", tasks[0].SourceURL)
+	assert.Zero(t, tasks[0].RemindBefore)
+	assert.WithinDuration(t, service.Now().Add(2*time.Hour), tasks[0].Deadline, time.Minute)
+
+	// Обычные сообщения в группе бот игнорирует
+	plain := telegramtest.TextMessage(testUserID, "Купить молоко завтра 18:00")
+	plain.Message.Chat = group
+	env.server.InjectUpdate(plain)
+	usage := telegramtest.TextMessage(testUserID, "/remind")
+	usage.Message.Chat = group
+	env.server.InjectUpdate(usage)
+	assert.Contains(t, env.waitText(t, 2), "Ответьте командой")
+	assert.Len(t, env.repo.Tasks(), 1)
+}
+
+func TestBot_Language(t *testing.T) {
+	env := startBot(t)
+
+	english := func(text string) tgbotapi.Update {
+		update := telegramtest.TextMessage(testUserID, text)
+		update.Message.From.LanguageCode = "en-US"
+		return update
+	}
+
+	env.server.InjectUpdate(english("/add"))
+	assert.Equal(t, "Write the task text", env.waitText(t, 1))
+	env.server.InjectUpdate(english("/cancel"))
+	assert.Equal(t, "Cancelled", env.waitText(t, 2))
+
+	// Выбранный язык важнее языка клиента
+	env.server.InjectUpdate(english("/lang ru"))
+	assert.Equal(t, "Язык бота: русский", env.waitText(t, 3))
+	env.server.InjectUpdate(english("/add"))
+	assert.Equal(t, "Напишите текст задачи", env.waitText(t, 4))
+
+	env.server.InjectUpdate(telegramtest.CallbackQuery(testUserID, 42, "lang:en"))
+	assert.Equal(t, "Bot language: English", env.waitText(t, 5))
+	keyboard := env.server.Calls("sendMessage")[4].Params.Get("reply_markup")
+	assert.Contains(t, keyboard, "➕ Add task")
+
+	env.server.InjectUpdate(telegramtest.TextMessage(testUserID, "/cancel"))
+	assert.Equal(t, "Cancelled", env.waitText(t, 6))
+	env.server.InjectUpdate(telegramtest.TextMessage(testUserID, "➕ Add task"))
+	assert.Equal(t, "Write the task text", env.waitText(t, 7))
+}
+
+func TestBot_RegistersCommands(t *testing.T) {
+	env := startBot(t)
+
+	calls := env.server.WaitCalls(t, "setMyCommands", 4)
+	menus := map[string][]tgbotapi.BotCommand{}
+	for _, c := range calls {
+		var scope tgbotapi.BotCommandScope
+		require.NoError(t, json.Unmarshal([]byte(c.Params.Get("scope")), &scope))
+		var list []tgbotapi.BotCommand
+		require.NoError(t, json.Unmarshal([]byte(c.Params.Get("commands")), &list))
+		menus[scope.Type+"/"+c.Params.Get("language_code")] = list
+	}
+
+	require.Len(t, menus["all_private_chats/ru"], len(commands()))
+	assert.Equal(t, "login", menus["all_private_chats/ru"][8].Command)
+	assert.Equal(t, "Код для входа в API", menus["all_private_chats/ru"][8].Description)
+	assert.Equal(t, "Get an API login code", menus["all_private_chats/"][8].Description)
+
+	var group []string
+	for _, c := range menus["all_group_chats/"] {
+		group = append(group, c.Command)
+	}
+	assert.Equal(t, []string{"remind", "lang"}, group)
+}
+
+func TestCommandDescriptionsAreTranslated(t *testing.T) {
+	for _, c := range commands() {
+		assert.NotEqual(t, c.Description, i18n.EN.T(c.Description), c.Name)
+	}
+}
+
+func TestBot_PrivateCommandInGroup(t *testing.T) {
+	env := startBot(t)
+	group := &tgbotapi.Chat{ID: -1009876543210, Type: "supergroup"}
+
+	login := telegramtest.TextMessage(testUserID, "/login")
+	login.Message.Chat = group
+	env.server.InjectUpdate(login)
+	assert.Equal(t, "Эта команда работает только в личном чате с ботом.", env.waitText(t, 1))
+
+	env.server.InjectUpdate(telegramtest.TextMessage(testUserID, "/unknown"))
+	assert.Equal(t, "Неизвестная команда", env.waitText(t, 2))
+}
@@ -0,0 +1,77 @@
+package telegramHandler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"task-traker/internal/domain"
+	"task-traker/internal/i18n"
+	"task-traker/internal/service"
+	"task-traker/pkg/telegram"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// inlineResultsLimit - сколько задач показывать в ответе на inline-запрос
+	// (телеграм принимает не больше 50).
+	inlineResultsLimit = 20
+	// inlineCreateID - id результата «создать задачу». Задача сохраняется,
+	// только когда пользователь выбрал этот результат (chosen_inline_result).
+	inlineCreateID = "create"
+)
+
+// handleInlineQuery ищет задачи пользователя по тексту "@bot <запрос>"
+// и предлагает вставить карточку задачи в любой чат. Если запрос похож на
+// быструю задачу с датой, первым результатом идет ее создание.
+func (h Handler) handleInlineQuery(ctx context.Context, q *tgbotapi.InlineQuery) {
+	lang := i18n.FromContext(ctx)
+	query := strings.TrimSpace(q.Query)
+	results := []any{}
+
+	if task, err := service.ParseQuickTask(query, service.Now()); err == nil && task.Title != "" {
+		card := telegram.Format(messageMode, "🆕 %s", formatTask(lang, task))
+		results = append(results, inlineArticle(inlineCreateID, lang.T("➕ Создать задачу: %s", task.Title), task, card))
+	}
+
+	tasks, err := h.TaskService.Repo.SearchTasks(ctx, q.From.ID, query, inlineResultsLimit-len(results))
+	if err != nil {
+		slog.Error("Inline query error", "error", err)
+	}
+	for _, task := range tasks {
+		results = append(results, inlineArticle(fmt.Sprintf("task_%d", task.ID), task.Title, task, formatTask(lang, task)))
+	}
+
+	if err := h.Bot.AnswerInlineQuery(q.ID, results); err != nil {
+		slog.Error("answerInlineQuery error", "error", err)
+	}
+}
+
+// handleChosenInlineResult создает задачу, если пользователь выбрал
+// результат «создать». Для этих апдейтов в BotFather должен быть включен
+// inline feedback (/setinlinefeedback).
+func (h Handler) handleChosenInlineResult(ctx context.Context, r *tgbotapi.ChosenInlineResult) {
+	if r.ResultID != inlineCreateID {
+		return
+	}
+	task, err := h.TaskService.QuickAdd(ctx, r.From.ID, r.Query)
+	if err != nil {
+		slog.Warn("Inline quick add failed", "error", err)
+		return
+	}
+	// Подтверждение уходит в личный чат: если пользователь еще не писал
+	// боту, телеграм его не доставит, но задача уже сохранена.
+	lang := i18n.FromContext(ctx)
+	if err := h.Bot.SendFormatted(r.From.ID, taskCard(lang, task), quickAddKeyboard(lang, task.ID)); err != nil {
+		slog.Warn("Inline quick add confirmation not sent", "error", err)
+	}
+}
+
+// inlineArticle - результат inline-запроса, который вставляет в чат карточку задачи.
+func inlineArticle(id, title string, task domain.Task, card telegram.Formatted) tgbotapi.InlineQueryResultArticle {
+	article := tgbotapi.NewInlineQueryResultArticle(id, title, card.Text)
+	article.InputMessageContent = tgbotapi.InputTextMessageContent{Text: card.Text, ParseMode: string(card.Mode)}
+	article.Description = task.Deadline.Format("02.01.2006 15:04")
+	return article
+}
@@ -1,26 +1,48 @@
 package telegramHandler
 
 import (
-	"fmt"
+	"task-traker/internal/delivery/telegramHandler/fsm"
+	"task-traker/internal/i18n"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
-func mainMenuKeyboard() tgbotapi.ReplyKeyboardMarkup {
+// Кнопки главного меню. Тексты переводятся, поэтому нажатие узнается
+// по переводу на любой из языков: у пользователя может остаться
+// клавиатура, отправленная до смены языка.
+const (
+	menuAddTask   = "➕ Добавить задачу"
+	menuListTasks = "📋 Все задачи"
+)
+
+func mainMenuKeyboard(lang i18n.Lang) tgbotapi.ReplyKeyboardMarkup {
 	keyboard := tgbotapi.NewReplyKeyboard(
 		tgbotapi.NewKeyboardButtonRow(
-			tgbotapi.NewKeyboardButton("➕ Добавить задачу"),
-			tgbotapi.NewKeyboardButton("📋 Все задачи"),
+			tgbotapi.NewKeyboardButton(lang.T(menuAddTask)),
+			tgbotapi.NewKeyboardButton(lang.T(menuListTasks)),
 		),
 	)
 	return keyboard
 }
 
-func deleteKeyboard(data int) tgbotapi.InlineKeyboardMarkup {
-	keyboard := tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("❌ Удалить", fmt.Sprintf("delete_%d", data)),
-		),
-	)
-	return keyboard
+func isMenuButton(text, button string) bool {
+	for _, lang := range i18n.Supported {
+		if text == lang.T(button) {
+			return true
+		}
+	}
+	return false
+}
+
+// dialogKeyboard строит клавиатуру диалога, переводя тексты кнопок.
+func dialogKeyboard(lang i18n.Lang, buttons [][]fsm.Button) tgbotapi.InlineKeyboardMarkup {
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(buttons))
+	for _, row := range buttons {
+		r := make([]tgbotapi.InlineKeyboardButton, 0, len(row))
+		for _, b := range row {
+			r = append(r, tgbotapi.NewInlineKeyboardButtonData(lang.T(b.Text), b.Data))
+		}
+		rows = append(rows, r)
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
 }
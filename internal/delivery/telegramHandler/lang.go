@@ -0,0 +1,55 @@
+package telegramHandler
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"task-traker/internal/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// langNames - названия языков на самих этих языках для кнопок /lang.
+var langNames = map[i18n.Lang]string{
+	i18n.RU: "🇷🇺 Русский",
+	i18n.EN: "🇬🇧 English",
+}
+
+// handleLangCommand меняет язык бота: "/lang en" сразу, а без аргумента
+// показывает кнопки выбора языка.
+func (h Handler) handleLangCommand(ctx context.Context, m *tgbotapi.Message) {
+	if arg := strings.TrimSpace(m.CommandArguments()); arg != "" {
+		lang, ok := i18n.Parse(arg)
+		if !ok {
+			h.Bot.SendMessage(m.Chat.ID, i18n.FromContext(ctx).T("Поддерживаются языки: ru, en."))
+			return
+		}
+		h.setLanguage(ctx, m.Chat.ID, m.From.ID, lang)
+		return
+	}
+
+	var row []tgbotapi.InlineKeyboardButton
+	for _, lang := range i18n.Supported {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(langNames[lang], "lang:"+string(lang)))
+	}
+	h.Bot.SendWithKeyboard(m.Chat.ID, i18n.FromContext(ctx).T("Выберите язык:"), tgbotapi.NewInlineKeyboardMarkup(row))
+}
+
+func (h Handler) handleLangCallback(ctx context.Context, cb *tgbotapi.CallbackQuery) {
+	lang, ok := i18n.Parse(strings.TrimPrefix(cb.Data, "lang:"))
+	if !ok {
+		return
+	}
+	h.setLanguage(ctx, cb.Message.Chat.ID, cb.From.ID, lang)
+}
+
+// setLanguage сохраняет язык и заново отправляет главное меню,
+// чтобы кнопки тоже были на новом языке.
+func (h Handler) setLanguage(ctx context.Context, chatID, userID int64, lang i18n.Lang) {
+	if err := h.TaskService.SetLanguage(ctx, userID, lang); err != nil {
+		slog.Error("SetLanguage error", "user_id", userID, "error", err)
+		h.Bot.SendMessage(chatID, i18n.FromContext(ctx).T("Ошибка сервера"))
+		return
+	}
+	h.Bot.SendWithKeyboard(chatID, lang.T("Язык бота: русский"), mainMenuKeyboard(lang))
+}
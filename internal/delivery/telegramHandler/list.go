@@ -0,0 +1,363 @@
+package telegramHandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"task-traker/internal/domain"
+	"task-traker/internal/i18n"
+	"task-traker/internal/service"
+	"task-traker/pkg/telegram"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	listPageSize = 5
+	// listTagButtons - сколько тегов показывать в строке фильтров.
+	listTagButtons = 4
+	// callbackDataLimit - ограничение телеграм на размер callback_data в байтах.
+	callbackDataLimit = 64
+	// maxListPage - номер страницы, под который резервируется место
+	// в callback_data при проверке длины поискового запроса.
+	maxListPage = 999
+)
+
+// Фильтры списка задач. Фильтр по тегу записывается как "#<тег>",
+// поиск по тексту - как "?<запрос>".
+const (
+	filterAll     = ""
+	filterToday   = "today"
+	filterWeek    = "week"
+	filterOverdue = "overdue"
+	filterSearch  = "?"
+)
+
+var filterLabels = []struct {
+	filter string
+	label  string
+}{
+	{filterToday, "Сегодня"},
+	{filterWeek, "Неделя"},
+	{filterOverdue, "Просрочено"},
+}
+
+// listState - страница и фильтр, которые показывает сообщение со списком.
+// Сохраняется в callback_data кнопок в виде "list:<page>:<filter>".
+type listState struct {
+	page   int
+	filter string
+}
+
+func (s listState) data() string {
+	return fmt.Sprintf("list:%d:%s", s.page, s.filter)
+}
+
+func parseListState(data string) (listState, bool) {
+	rest, ok := strings.CutPrefix(data, "list:")
+	if !ok {
+		return listState{}, false
+	}
+	pageStr, filter, _ := strings.Cut(rest, ":")
+	page, err := strconv.Atoi(pageStr)
+	if err != nil || page < 0 {
+		return listState{}, false
+	}
+	return listState{page: page, filter: filter}, true
+}
+
+// listStateOf восстанавливает состояние списка из кнопки с номером страницы
+// под сообщением, чтобы после действия с задачей перерисовать тот же вид.
+func listStateOf(msg *tgbotapi.Message) (listState, bool) {
+	if msg == nil || msg.ReplyMarkup == nil {
+		return listState{}, false
+	}
+	for _, row := range msg.ReplyMarkup.InlineKeyboard {
+		for _, b := range row {
+			if b.CallbackData == nil || !strings.HasPrefix(b.Text, "·") {
+				continue
+			}
+			if state, ok := parseListState(*b.CallbackData); ok {
+				return state, true
+			}
+		}
+	}
+	return listState{}, false
+}
+
+func (h Handler) handleListCommand(ctx context.Context, m *tgbotapi.Message) {
+	h.sendList(ctx, m.Chat.ID, listState{})
+}
+
+// handleTodayCommand показывает задачи со сроком сегодня.
+func (h Handler) handleTodayCommand(ctx context.Context, m *tgbotapi.Message) {
+	h.sendList(ctx, m.Chat.ID, listState{filter: filterToday})
+}
+
+// handleWeekCommand показывает задачи на ближайшие семь дней по дням.
+func (h Handler) handleWeekCommand(ctx context.Context, m *tgbotapi.Message) {
+	h.sendList(ctx, m.Chat.ID, listState{filter: filterWeek})
+}
+
+// handleSearchCommand ищет задачи по тексту "/search <текст>". Запрос
+// хранится в callback_data кнопок списка, поэтому его длина ограничена.
+func (h Handler) handleSearchCommand(ctx context.Context, m *tgbotapi.Message) {
+	lang := i18n.FromContext(ctx)
+	query := strings.Join(strings.Fields(m.CommandArguments()), " ")
+	if query == "" {
+		h.Bot.SendMessage(m.Chat.ID, lang.T("Напишите, что искать, например: /search молоко"))
+		return
+	}
+	state := listState{filter: filterSearch + query}
+	if len(listState{page: maxListPage, filter: state.filter}.data()) > callbackDataLimit {
+		h.Bot.SendMessage(m.Chat.ID, lang.T("Слишком длинный запрос, сократите его."))
+		return
+	}
+	h.sendList(ctx, m.Chat.ID, state)
+}
+
+// sendList отправляет в личный чат userID список задач в состоянии state.
+func (h Handler) sendList(ctx context.Context, userID int64, state listState) {
+	text, keyboard, err := h.renderList(ctx, userID, state)
+	if err != nil {
+		slog.Error("sendList error", "filter", state.filter, "error", err)
+		h.Bot.SendMessage(userID, i18n.FromContext(ctx).T("Ошибка сервера"))
+		return
+	}
+	if keyboard == nil {
+		h.Bot.SendFormatted(userID, text, nil)
+		return
+	}
+	h.Bot.SendFormatted(userID, text, *keyboard)
+}
+
+// handleListCallback перелистывает список или переключает фильтр, редактируя сообщение.
+func (h Handler) handleListCallback(ctx context.Context, cb *tgbotapi.CallbackQuery) {
+	state, ok := parseListState(cb.Data)
+	if !ok {
+		return
+	}
+	h.refreshList(ctx, cb.From.ID, cb.Message.Chat.ID, cb.Message.MessageID, state)
+}
+
+// refreshList перерисовывает сообщение со списком задач.
+func (h Handler) refreshList(ctx context.Context, userID, chatID int64, messageID int, state listState) {
+	text, keyboard, err := h.renderList(ctx, userID, state)
+	if err != nil {
+		slog.Error("refreshList error", "error", err)
+		h.Bot.SendMessage(chatID, i18n.FromContext(ctx).T("Ошибка сервера"))
+		return
+	}
+	if err := h.Bot.EditFormatted(chatID, messageID, text, keyboard); err != nil {
+		slog.Error("Ошибка редактирования сообщения", "error", err)
+	}
+}
+
+func (h Handler) handleCompleteTask(ctx context.Context, cb *tgbotapi.CallbackQuery) {
+	id, err := strconv.Atoi(strings.TrimPrefix(cb.Data, "done_"))
+	if err != nil {
+		return
+	}
+	lang := i18n.FromContext(ctx)
+	err = h.TaskService.CompleteTask(ctx, cb.From.ID, id)
+	if errors.Is(err, domain.ErrTaskNotFound) {
+		h.Bot.SendMessage(cb.Message.Chat.ID, lang.T("Задача не найдена"))
+		return
+	}
+	if err != nil {
+		slog.Error("Ошибка завершения задачи", "id", id, "error", err)
+		h.Bot.SendMessage(cb.Message.Chat.ID, lang.T("Не удалось отметить задачу"))
+		return
+	}
+	state, _ := listStateOf(cb.Message)
+	h.refreshList(ctx, cb.From.ID, cb.Message.Chat.ID, cb.Message.MessageID, state)
+}
+
+// renderList строит текст и клавиатуру одной страницы списка.
+// Если у пользователя нет задач, клавиатура равна nil.
+func (h Handler) renderList(ctx context.Context, userID int64, state listState) (telegram.Formatted, *tgbotapi.InlineKeyboardMarkup, error) {
+	lang := i18n.FromContext(ctx)
+	now := service.Now()
+	tasks, err := h.listTasks(ctx, userID, state.filter, now)
+	if err != nil {
+		return telegram.Formatted{}, nil, err
+	}
+	if len(tasks) == 0 && state.filter == filterAll {
+		return telegram.Format(messageMode, lang.T("У вас нет активных задач🎉")), nil, nil
+	}
+
+	pages := max(1, (len(tasks)+listPageSize-1)/listPageSize)
+	state.page = min(state.page, pages-1)
+	from := state.page * listPageSize
+	pageTasks := tasks[from:min(from+listPageSize, len(tasks))]
+
+	b := telegram.NewBuilder(messageMode)
+	b.Text(lang.T("📋 Ваши активные задачи"))
+	if label := filterLabel(lang, state.filter); label != "" {
+		b.Format(" (%s)", label)
+	}
+	b.Text(":")
+	if len(pageTasks) == 0 {
+		b.Text("\n\n" + lang.T("Нет задач по этому фильтру."))
+	}
+	var day time.Time
+	for i, task := range pageTasks {
+		// Задачи на неделю разбиты по дням
+		if d := startOfDay(task.Deadline); state.filter == filterWeek && !d.Equal(day) {
+			day = d
+			b.Format("\n\n📅 %s", telegram.Bold(dayLabel(lang, day, now)))
+		}
+		b.Format("\n\n%d. %s", from+i+1, formatTask(lang, task))
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, task := range pageTasks {
+		rows = append(rows, taskActionsRow(from+i+1, task.ID))
+	}
+	rows = append(rows, navRow(state, pages))
+	// Теги берутся из всех задач, а не только из отфильтрованных: иначе
+	// с активным фильтром переключиться на другой тег было бы нельзя
+	tags, err := h.TaskService.Repo.GetUserTags(ctx, userID)
+	if err != nil {
+		return telegram.Formatted{}, nil, err
+	}
+	rows = append(rows, filterRows(lang, state, tags)...)
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return b.Message(), &keyboard, nil
+}
+
+// listTasks загружает задачи для фильтра списка. Отбор по сроку, тегу
+// и тексту выполняет репозиторий.
+func (h Handler) listTasks(ctx context.Context, userID int64, filter string, now time.Time) ([]domain.Task, error) {
+	repo := h.TaskService.Repo
+	if from, to, ok := filterRange(filter, now); ok {
+		return repo.GetTasksByDeadline(ctx, userID, from, to)
+	}
+	if query, ok := strings.CutPrefix(filter, filterSearch); ok {
+		return repo.SearchTasks(ctx, userID, query, 0)
+	}
+	// Тег сравнивается только с тегами: поиск нашел бы "#дом" и в названии
+	if tag, ok := strings.CutPrefix(filter, "#"); ok {
+		return repo.ListTasks(ctx, domain.TaskFilter{UserID: userID, Tag: tag})
+	}
+	return repo.GetTasksByUserID(ctx, userID)
+}
+
+// filterRange - интервал сроков [from, to) для фильтров по дате. Неделя -
+// сегодняшний день после now и шесть следующих дней.
+func filterRange(filter string, now time.Time) (from, to time.Time, ok bool) {
+	today := startOfDay(now)
+	switch filter {
+	case filterToday:
+		return today, today.AddDate(0, 0, 1), true
+	case filterWeek:
+		return now, today.AddDate(0, 0, 7), true
+	case filterOverdue:
+		return time.Time{}, now, true
+	default:
+		return time.Time{}, time.Time{}, false
+	}
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+var weekdays = [...]string{"Воскресенье", "Понедельник", "Вторник", "Среда", "Четверг", "Пятница", "Суббота"}
+
+// dayLabel - заголовок дня в списке на неделю: «Сегодня», «Завтра»
+// или день недели с датой.
+func dayLabel(lang i18n.Lang, day, now time.Time) string {
+	switch today := startOfDay(now); {
+	case day.Equal(today):
+		return lang.T("Сегодня")
+	case day.Equal(today.AddDate(0, 0, 1)):
+		return lang.T("Завтра")
+	default:
+		return lang.T(weekdays[day.Weekday()]) + ", " + day.Format("02.01")
+	}
+}
+
+func filterLabel(lang i18n.Lang, filter string) string {
+	if strings.HasPrefix(filter, "#") {
+		return filter
+	}
+	if query, ok := strings.CutPrefix(filter, filterSearch); ok {
+		return lang.T("поиск «%s»", query)
+	}
+	for _, f := range filterLabels {
+		if f.filter == filter {
+			return lang.T(f.label)
+		}
+	}
+	return ""
+}
+
+func taskActionsRow(n, id int) []tgbotapi.InlineKeyboardButton {
+	return tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("✅ %d", n), fmt.Sprintf("done_%d", id)),
+		tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("✏️ %d", n), fmt.Sprintf("edit_%d", id)),
+		tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("❌ %d", n), fmt.Sprintf("delete_%d", id)),
+	)
+}
+
+// navRow - строка навигации «◀️ ·1/5· ▶️». Кнопка с номером страницы
+// хранит текущее состояние списка и обновляет его при нажатии.
+func navRow(state listState, pages int) []tgbotapi.InlineKeyboardButton {
+	var row []tgbotapi.InlineKeyboardButton
+	if state.page > 0 {
+		prev := listState{page: state.page - 1, filter: state.filter}
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData("◀️", prev.data()))
+	}
+	row = append(row, tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("·%d/%d·", state.page+1, pages), state.data()))
+	if state.page < pages-1 {
+		next := listState{page: state.page + 1, filter: state.filter}
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData("▶️", next.data()))
+	}
+	return row
+}
+
+// filterRows - переключатели фильтров и до listTagButtons тегов из tags.
+// Выбранный тег показывается всегда, даже если задач с ним больше нет.
+// Повторное нажатие активного фильтра снимает его.
+func filterRows(lang i18n.Lang, state listState, tags []string) [][]tgbotapi.InlineKeyboardButton {
+	toggle := func(filter, label string) tgbotapi.InlineKeyboardButton {
+		target := listState{filter: filter}
+		if state.filter == filter {
+			label = "✓ " + label
+			target.filter = filterAll
+		}
+		return tgbotapi.NewInlineKeyboardButtonData(label, target.data())
+	}
+
+	var row []tgbotapi.InlineKeyboardButton
+	for _, f := range filterLabels {
+		row = append(row, toggle(f.filter, lang.T(f.label)))
+	}
+	rows := [][]tgbotapi.InlineKeyboardButton{row}
+
+	if active, ok := strings.CutPrefix(state.filter, "#"); ok {
+		tags = append([]string{active}, slices.DeleteFunc(slices.Clone(tags), func(t string) bool { return t == active })...)
+	}
+	var tagRow []tgbotapi.InlineKeyboardButton
+	for _, tag := range tags {
+		filter := "#" + tag
+		if len(listState{filter: filter}.data()) > callbackDataLimit {
+			continue
+		}
+		tagRow = append(tagRow, toggle(filter, filter))
+		if len(tagRow) == listTagButtons {
+			break
+		}
+	}
+	if len(tagRow) > 0 {
+		rows = append(rows, tagRow)
+	}
+	return rows
+}
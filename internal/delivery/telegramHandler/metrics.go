@@ -0,0 +1,39 @@
+package telegramHandler
+
+import (
+	"expvar"
+	"time"
+)
+
+// Метрики публикуются через expvar и доступны на /debug/vars служебного
+// сервера DEBUG_ADDR.
+var (
+	queueLength   = expvar.NewInt("telegram_queue_length")
+	updatesTotal  = expvar.NewInt("telegram_updates_total")
+	updatePanics  = expvar.NewInt("telegram_update_panics_total")
+	updateLatency = expvar.NewMap("telegram_update_latency_ms")
+)
+
+// Границы корзин гистограммы времени обработки апдейта, мс.
+var latencyBuckets = []struct {
+	name  string
+	limit time.Duration
+}{
+	{"le_10", 10 * time.Millisecond},
+	{"le_50", 50 * time.Millisecond},
+	{"le_100", 100 * time.Millisecond},
+	{"le_500", 500 * time.Millisecond},
+	{"le_1000", time.Second},
+	{"le_5000", 5 * time.Second},
+}
+
+func observeLatency(d time.Duration) {
+	updatesTotal.Add(1)
+	updateLatency.Add("sum", d.Milliseconds())
+	for _, b := range latencyBuckets {
+		if d <= b.limit {
+			updateLatency.Add(b.name, 1)
+		}
+	}
+	updateLatency.Add("le_inf", 1)
+}
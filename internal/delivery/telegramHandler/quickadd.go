@@ -0,0 +1,96 @@
+package telegramHandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"task-traker/internal/domain"
+	"task-traker/internal/i18n"
+	"task-traker/internal/service"
+	"task-traker/pkg/telegram"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// handleQuickAdd создает задачу из одного сообщения вида
+// "Купить молоко завтра 18:00 #дом !high". Возвращает false, если в тексте
+// нет даты или времени: такое сообщение не похоже на задачу.
+func (h Handler) handleQuickAdd(ctx context.Context, m *tgbotapi.Message, text string) bool {
+	lang := i18n.FromContext(ctx)
+	task, err := h.TaskService.QuickAdd(ctx, m.From.ID, text)
+	if errors.Is(err, service.ErrNoDeadline) {
+		return false
+	}
+	if err != nil {
+		slog.Warn("Quick add failed", "error", err)
+		h.Bot.SendMessage(m.Chat.ID, lang.T("❌ Не удалось сохранить задачу: проверьте название, дату и время."))
+		return true
+	}
+	h.Bot.SendFormatted(m.Chat.ID, taskCard(lang, task), quickAddKeyboard(lang, task.ID))
+	return true
+}
+
+// beginQuickAddDialog продолжает /add без даты: название, теги и приоритет
+// уже известны, поэтому диалог начинается сразу с запроса срока.
+func (h Handler) beginQuickAddDialog(ctx context.Context, chatID int64, text string) {
+	task, _ := service.ParseQuickTask(text, service.Now())
+	if task.Title == "" {
+		h.beginDialog(ctx, chatID, flowAddTask)
+		return
+	}
+	replies, err := h.Dialogs.BeginAt(chatID, flowAddTask, fieldDeadline, map[string]string{
+		fieldTitle:    task.Title,
+		fieldTags:     strings.Join(task.Tags, " "),
+		fieldPriority: strconv.Itoa(int(task.Priority)),
+	})
+	if err != nil {
+		slog.Error("Dialog start error", "flow", flowAddTask, "error", err)
+		h.Bot.SendMessage(chatID, i18n.FromContext(ctx).T("Ошибка сервера"))
+		return
+	}
+	h.sendReplies(ctx, chatID, replies)
+}
+
+// handleUndoTask удаляет только что созданную задачу по кнопке «Отменить».
+func (h Handler) handleUndoTask(ctx context.Context, cb *tgbotapi.CallbackQuery) {
+	lang := i18n.FromContext(ctx)
+	chatID := cb.Message.Chat.ID
+	id, err := strconv.Atoi(strings.TrimPrefix(cb.Data, "undo_"))
+	if err != nil {
+		return
+	}
+	task, err := h.TaskService.GetTask(ctx, cb.From.ID, id)
+	if errors.Is(err, domain.ErrTaskNotFound) {
+		h.Bot.EditMessageText(chatID, cb.Message.MessageID, lang.T("Задача уже удалена"), nil)
+		return
+	}
+	if err == nil {
+		err = h.TaskService.DeleteTask(ctx, cb.From.ID, task.ID)
+	}
+	if err != nil {
+		slog.Error("Ошибка отмены задачи", "id", id, "error", err)
+		h.Bot.SendMessage(chatID, lang.T("Не удалось отменить задачу"))
+		return
+	}
+	h.Bot.EditMessageText(chatID, cb.Message.MessageID, lang.T("↩️ Задача «%s» отменена", task.Title), nil)
+}
+
+// taskCard - подтверждение создания задачи.
+func taskCard(lang i18n.Lang, task domain.Task) telegram.Formatted {
+	return telegram.NewBuilder(messageMode).
+		Text(lang.T("✅ Задача сохранена!")+"\n\n").
+		Format("%s", formatTask(lang, task)).
+		Message()
+}
+
+func quickAddKeyboard(lang i18n.Lang, id int) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(lang.T("↩️ Отменить"), fmt.Sprintf("undo_%d", id)),
+			tgbotapi.NewInlineKeyboardButtonData(lang.T("✏️ Изменить"), fmt.Sprintf("edit_%d", id)),
+		),
+	)
+}
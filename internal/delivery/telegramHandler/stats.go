@@ -0,0 +1,99 @@
+package telegramHandler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"task-traker/internal/i18n"
+	"task-traker/internal/service"
+	"task-traker/pkg/telegram"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// statsWeeks - за сколько недель, включая текущую, показывается /stats.
+	statsWeeks = 4
+	// statsBarWidth - длина самой длинной полоски графика в символах.
+	statsBarWidth = 10
+)
+
+// handleStatsCommand показывает статистику за последние недели с графиком
+// выполненных задач по неделям.
+func (h Handler) handleStatsCommand(ctx context.Context, m *tgbotapi.Message) {
+	lang := i18n.FromContext(ctx)
+	to := startOfDay(service.Now()).AddDate(0, 0, 1)
+	from := service.WeekStart(to.AddDate(0, 0, -1)).AddDate(0, 0, -7*(statsWeeks-1))
+	stats, err := h.TaskService.Stats(ctx, m.From.ID, from, to)
+	if err != nil {
+		slog.Error("handleStatsCommand error", "error", err)
+		h.Bot.SendMessage(m.Chat.ID, lang.T("Ошибка сервера"))
+		return
+	}
+	h.Bot.SendFormatted(m.Chat.ID, formatStats(lang, stats), nil)
+}
+
+func formatStats(lang i18n.Lang, s service.Stats) telegram.Formatted {
+	b := telegram.NewBuilder(messageMode)
+	b.Format(lang.T("📊 Статистика с %s по %s"), s.From.Format("02.01"), s.To.AddDate(0, 0, -1).Format("02.01"))
+	b.Text("\n\n")
+	b.Format(lang.T("Создано: %d"), s.Created)
+	b.Text("\n")
+	b.Format(lang.T("Выполнено: %d"), s.Completed)
+	if s.Completed > 0 {
+		b.Format(lang.T(", в срок %d%%"), int(math.Round(s.OnTimeRate*100)))
+	}
+	if s.AvgLatenessMinutes > 0 {
+		b.Text("\n")
+		b.Format(lang.T("Среднее опоздание: %s"), formatDuration(lang, int(s.AvgLatenessMinutes)))
+	}
+	if s.Overdue > 0 {
+		b.Text("\n")
+		b.Format(lang.T("Просрочено: %d"), s.Overdue)
+	}
+	b.Text("\n")
+	b.Format(lang.T("🔥 Серия: %s (рекорд: %s)"),
+		lang.N(s.CurrentStreak, "%d день", "%d дня", "%d дней"),
+		lang.N(s.LongestStreak, "%d день", "%d дня", "%d дней"))
+
+	if len(s.Weeks) > 0 {
+		b.Text("\n\n" + lang.T("Выполнено по неделям:"))
+		most := 0
+		for _, w := range s.Weeks {
+			most = max(most, w.Completed)
+		}
+		for _, w := range s.Weeks {
+			line := fmt.Sprintf("%s %-*s %d", w.Start.Format("02.01"), statsBarWidth, bar(w.Completed, most, statsBarWidth), w.Completed)
+			b.Format("\n%s", telegram.Code(line))
+		}
+	}
+	return b.Message()
+}
+
+// barEighths - части символа полоски графика от 1/8 до 7/8.
+var barEighths = []rune("▏▎▍▌▋▊▉")
+
+// bar - полоска длиной value/most от width символов с точностью до 1/8 символа.
+func bar(value, most, width int) string {
+	if most == 0 {
+		return ""
+	}
+	eighths := value * width * 8 / most
+	s := strings.Repeat("█", eighths/8)
+	if r := eighths % 8; r > 0 {
+		s += string(barEighths[r-1])
+	}
+	return s
+}
+
+func formatDuration(lang i18n.Lang, minutes int) string {
+	if minutes < 60 {
+		return lang.T("%d мин", minutes)
+	}
+	if minutes%60 == 0 {
+		return lang.T("%d ч", minutes/60)
+	}
+	return lang.T("%d ч %d мин", minutes/60, minutes%60)
+}
@@ -0,0 +1,39 @@
+package telegramHandler
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// WebhookHandler принимает апдейты от телеграм и передает их в тот же
+// диспетчер, что и long polling. Запросы без верного секрета отклоняются.
+func (h Handler) WebhookHandler(secret string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get(secretTokenHeader)
+		if subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+			slog.Warn("Telegram webhook: invalid secret token", "remote", r.RemoteAddr)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var update tgbotapi.Update
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			http.Error(w, "invalid update", http.StatusBadRequest)
+			return
+		}
+
+		select {
+		case h.webhookUpdates <- update:
+			w.WriteHeader(http.StatusOK)
+		case <-r.Context().Done():
+			// Телеграм повторит доставку апдейта
+			http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+		}
+	})
+}
@@ -0,0 +1,47 @@
+package telegramHandler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookHandler(t *testing.T) {
+	h := Handler{webhookUpdates: make(chan tgbotapi.Update, 1)}
+	handler := h.WebhookHandler("s3cret")
+	body := `{"update_id": 10, "message": {"message_id": 1, "text": "hi", "chat": {"id": 5}}}`
+
+	tests := []struct {
+		name       string
+		secret     string
+		body       string
+		wantStatus int
+	}{
+		{"Missing secret", "", body, http.StatusUnauthorized},
+		{"Wrong secret", "guess", body, http.StatusUnauthorized},
+		{"Broken body", "s3cret", "{", http.StatusBadRequest},
+		{"Valid update", "s3cret", body, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/telegram/webhook", strings.NewReader(tt.body))
+			if tt.secret != "" {
+				req.Header.Set(secretTokenHeader, tt.secret)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+
+	update := <-h.webhookUpdates
+	assert.Equal(t, 10, update.UpdateID)
+	assert.Equal(t, int64(5), update.Message.Chat.ID)
+}
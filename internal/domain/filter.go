@@ -0,0 +1,61 @@
+package domain
+
+import "time"
+
+// TaskStatus - отбор задач по выполнению.
+type TaskStatus string
+
+const (
+	StatusActive    TaskStatus = "active"
+	StatusCompleted TaskStatus = "completed"
+	StatusAll       TaskStatus = "all"
+)
+
+// TaskSort - порядок списка задач. Минус в начале - по убыванию.
+// При равных значениях задачи упорядочиваются по id.
+type TaskSort string
+
+const (
+	SortDeadline     TaskSort = "deadline"
+	SortDeadlineDesc TaskSort = "-deadline"
+	SortCreated      TaskSort = "created"
+	SortCreatedDesc  TaskSort = "-created"
+)
+
+// Desc сообщает, что сортировка по убыванию.
+func (s TaskSort) Desc() bool {
+	return s == SortDeadlineDesc || s == SortCreatedDesc
+}
+
+// Key возвращает значение ключа сортировки задачи (срок или время создания).
+func (s TaskSort) Key(t Task) time.Time {
+	if s == SortCreated || s == SortCreatedDesc {
+		return t.CreatedAt
+	}
+	return t.Deadline
+}
+
+// TaskCursor - позиция для keyset-пагинации: ключ сортировки и id последней
+// задачи предыдущей страницы. Следующая страница начинается после нее.
+type TaskCursor struct {
+	Key time.Time
+	ID  int
+}
+
+// TaskFilter - условия выборки задач одного пользователя. Пустые поля
+// не ограничивают выборку.
+type TaskFilter struct {
+	UserID int64
+	Status TaskStatus
+	// DueAfter и DueBefore - границы срока: DueAfter <= deadline < DueBefore.
+	DueAfter  time.Time
+	DueBefore time.Time
+	// Query - слова, которые должны найтись в названии или тегах (см. SearchTasks).
+	Query   string
+	Tag     string
+	Project string
+	Sort    TaskSort
+	After   *TaskCursor
+	// Limit - сколько задач вернуть; 0 - без ограничения.
+	Limit int
+}
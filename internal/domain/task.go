@@ -2,9 +2,28 @@ package domain
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
+var ErrTaskNotFound = errors.New("задача не найдена")
+
+// ErrVersionConflict - задачу успели изменить: ее версия уже не та,
+// с которой начиналось изменение.
+var ErrVersionConflict = errors.New("задача была изменена другим запросом")
+
+// Priority - приоритет задачи. Нулевое значение - обычный приоритет.
+type Priority int
+
+const (
+	PriorityLow    Priority = -1
+	PriorityNormal Priority = 0
+	PriorityHigh   Priority = 1
+)
+
+// DefaultRemindBefore - за сколько минут до дедлайна по умолчанию приходит напоминание.
+const DefaultRemindBefore = 15
+
 type Task struct {
 	ID        int       `db:"id"`
 	UserID    int64     `db:"user_id"`
@@ -12,14 +31,92 @@ type Task struct {
 	Deadline  time.Time `db:"deadline"`
 	Notified  bool      `db:"notified"`
 	CreatedAt time.Time `db:"created_at"`
+	Priority  Priority  `db:"priority"`
+	// RemindBefore - за сколько минут до дедлайна отправить напоминание.
+	RemindBefore int        `db:"remind_before"`
+	Tags         []string   `db:"tags"`
+	CompletedAt  *time.Time `db:"completed_at"`
+	// SourceURL - ссылка на сообщение, из которого создана задача, если она есть.
+	SourceURL string `db:"source_url"`
+	// Project - проект, к которому относится задача; "" - без проекта.
+	Project string `db:"project"`
+	// Version растет при каждом изменении задачи; по ней API строит ETag,
+	// а Update отклоняет изменение устаревшей копии.
+	Version   int       `db:"version"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// Overdue сообщает, что невыполненная задача просрочена к моменту now.
+func (t Task) Overdue(now time.Time) bool {
+	return t.CompletedAt == nil && t.Deadline.Before(now)
 }
 
 type TaskRepository interface {
 	Create(context.Context, *Task) error
 	GetActiveTasks(context.Context) ([]Task, error)
 	MarkAsNotified(context.Context, int) error
+	// GetTasksByUserID возвращает невыполненные задачи пользователя по
+	// возрастанию срока, в том числе те, о которых уже напомнили.
 	GetTasksByUserID(context.Context, int64) ([]Task, error)
-	DeleteByID(context.Context, string) error
+	// GetTasksByDeadline возвращает невыполненные задачи пользователя со сроком
+	// в интервале [from, to) по возрастанию срока.
+	GetTasksByDeadline(ctx context.Context, userID int64, from, to time.Time) ([]Task, error)
+	// SearchTasks возвращает невыполненные задачи пользователя, в названии или
+	// тегах которых есть все слова запроса (без учета регистра), по возрастанию
+	// срока. limit ограничивает число задач; 0 - без ограничения.
+	SearchTasks(ctx context.Context, userID int64, query string, limit int) ([]Task, error)
+	// GetUserTags возвращает теги невыполненных задач пользователя от самых
+	// частых к редким, при равенстве - по алфавиту.
+	GetUserTags(ctx context.Context, userID int64) ([]string, error)
+	// GetTaskHistory возвращает задачи пользователя, включая выполненные,
+	// которые были созданы, выполнены или должны были быть выполнены
+	// в интервале [from, to).
+	GetTaskHistory(ctx context.Context, userID int64, from, to time.Time) ([]Task, error)
+	// ListTasks возвращает задачи, подходящие под фильтр, в порядке filter.Sort.
+	ListTasks(ctx context.Context, filter TaskFilter) ([]Task, error)
+	// GetTaskByID возвращает задачу, только если она принадлежит пользователю.
+	GetTaskByID(ctx context.Context, userID int64, id int) (Task, error)
+	// Update сохраняет изменения задачи владельца, если ее версия все еще
+	// равна task.Version, и записывает в task новые Version и UpdatedAt.
+	// Если задача не найдена или принадлежит другому пользователю -
+	// ErrTaskNotFound, если версия уже другая - ErrVersionConflict.
+	Update(context.Context, *Task) error
+	// Complete отмечает задачу владельца выполненной.
+	Complete(ctx context.Context, userID int64, id int) error
+	// InTx выполняет fn в одной транзакции: все изменения через переданный
+	// в fn репозиторий сохраняются, только если fn вернула nil. Вложенный
+	// вызов InTx откатывает при ошибке лишь свою часть.
+	InTx(ctx context.Context, fn func(TaskRepository) error) error
+	// Delete удаляет задачу владельца версии version (0 - любой версии).
+	// Чужая или несуществующая задача - ErrTaskNotFound, задача другой
+	// версии - ErrVersionConflict.
+	Delete(ctx context.Context, userID int64, id, version int) error
 	SaveAuthCode(context.Context, int64, string, time.Time) error
 	VerifyAuthCode(context.Context, int64, string) (bool, error)
+	// GetLanguage возвращает язык, выбранный пользователем, или "", если он не выбран.
+	GetLanguage(ctx context.Context, userID int64) (string, error)
+	SetLanguage(ctx context.Context, userID int64, lang string) error
+	// CreateWebhook сохраняет подписку и заполняет ее ID и CreatedAt.
+	CreateWebhook(context.Context, *Webhook) error
+	// ListWebhooks возвращает подписки пользователя по возрастанию ID.
+	ListWebhooks(ctx context.Context, userID int64) ([]Webhook, error)
+	// GetWebhook возвращает подписку пользователя. Чужая или
+	// несуществующая - ErrWebhookNotFound.
+	GetWebhook(ctx context.Context, userID int64, id int) (Webhook, error)
+	// DeleteWebhook удаляет подписку пользователя вместе с журналом доставок.
+	DeleteWebhook(ctx context.Context, userID int64, id int) error
+	// EnqueueWebhookEvent создает доставку события event с телом payload
+	// на каждую подписку пользователя, которая его ждет, и возвращает их число.
+	EnqueueWebhookEvent(ctx context.Context, userID int64, event string, payload []byte) (int, error)
+	// CreateWebhookDelivery сохраняет доставку и заполняет ее ID и CreatedAt.
+	CreateWebhookDelivery(context.Context, *WebhookDelivery) error
+	// ClaimWebhookDeliveries возвращает до limit доставок в статусе pending,
+	// чей NextAttemptAt наступил к now, и переносит их NextAttemptAt на
+	// now+lease, чтобы их не взял другой процесс.
+	ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]WebhookDelivery, error)
+	// UpdateWebhookDelivery сохраняет итог попытки доставки.
+	UpdateWebhookDelivery(context.Context, *WebhookDelivery) error
+	// ListWebhookDeliveries возвращает последние limit доставок подписки
+	// пользователя, новые первыми.
+	ListWebhookDeliveries(ctx context.Context, userID int64, webhookID int, limit int) ([]WebhookDelivery, error)
 }
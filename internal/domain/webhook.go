@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrWebhookNotFound = errors.New("вебхук не найден")
+
+// Webhook - подписка пользователя на события задач: каждое событие
+// отправляется POST-запросом на URL с подписью HMAC-SHA256 секретом Secret.
+type Webhook struct {
+	ID     int    `db:"id"`
+	UserID int64  `db:"user_id"`
+	URL    string `db:"url"`
+	Secret string `db:"secret"`
+	// Events - типы событий подписки; пустой список - все события.
+	Events    []string  `db:"events"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type DeliveryStatus string
+
+const (
+	// DeliveryPending - доставка ждет первой или повторной попытки.
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	// DeliveryFailed - попытки исчерпаны.
+	DeliveryFailed DeliveryStatus = "failed"
+)
+
+// WebhookDelivery - отправка одного события на вебхук и ее итог.
+type WebhookDelivery struct {
+	ID        int64  `db:"id"`
+	WebhookID int    `db:"webhook_id"`
+	UserID    int64  `db:"user_id"`
+	Event     string `db:"event"`
+	// Payload - тело запроса, JSON.
+	Payload  []byte         `db:"payload"`
+	Status   DeliveryStatus `db:"status"`
+	Attempts int            `db:"attempts"`
+	// ResponseCode - HTTP-статус последней попытки; 0 - ответа не было.
+	ResponseCode int `db:"response_code"`
+	// Error - причина неудачи последней попытки.
+	Error string `db:"error"`
+	// NextAttemptAt - когда доставку можно взять в работу.
+	NextAttemptAt time.Time  `db:"next_attempt_at"`
+	CreatedAt     time.Time  `db:"created_at"`
+	DeliveredAt   *time.Time `db:"delivered_at"`
+}
@@ -0,0 +1,193 @@
+// Package events доставляет события об изменении задач подписчикам
+// (потокам SSE и WebSocket) во всех репликах сервера.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"task-traker/internal/domain"
+	"task-traker/internal/repository"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Type string
+
+const (
+	TaskCreated   Type = "task.created"
+	TaskUpdated   Type = "task.updated"
+	TaskCompleted Type = "task.completed"
+	TaskDeleted   Type = "task.deleted"
+	TaskReminded  Type = "task.reminded"
+)
+
+// Types - все типы событий.
+var Types = []Type{TaskCreated, TaskUpdated, TaskCompleted, TaskDeleted, TaskReminded}
+
+// Event - изменение задачи пользователя UserID. Task - задача после
+// изменения; для task.completed и task.deleted ее нет, только TaskID.
+type Event struct {
+	Type   Type         `json:"type"`
+	UserID int64        `json:"user_id"`
+	TaskID int          `json:"task_id"`
+	Task   *domain.Task `json:"task,omitempty"`
+	Time   time.Time    `json:"time"`
+}
+
+const (
+	// redisChannel - канал Redis, через который реплики обмениваются событиями.
+	redisChannel = "task-events"
+	// subscriptionBuffer - сколько событий ждет медленного подписчика.
+	subscriptionBuffer = 64
+	// subscribeRetry - пауза перед повторной подпиской на Redis; каждая
+	// следующая вдвое длиннее, но не больше maxSubscribeRetry.
+	subscribeRetry    = 100 * time.Millisecond
+	maxSubscribeRetry = 30 * time.Second
+)
+
+// Bus - шина событий. Publish сразу доставляет событие подписчикам этого
+// процесса и отправляет его в Redis, откуда его получают другие реплики
+// (см. Run). Нулевой *Bus ничего не делает.
+type Bus struct {
+	redis *repository.RedisRepo
+	// origin отличает свои сообщения в Redis от сообщений других реплик.
+	origin string
+
+	mu   sync.Mutex
+	subs map[int64]map[*Subscription]struct{}
+}
+
+// NewBus создает шину. redis может быть nil - тогда события не покидают процесс.
+func NewBus(redis *repository.RedisRepo) *Bus {
+	return &Bus{redis: redis, origin: uuid.NewString(), subs: make(map[int64]map[*Subscription]struct{})}
+}
+
+// envelope - событие в Redis.
+type envelope struct {
+	Origin string `json:"origin"`
+	Event  Event  `json:"event"`
+}
+
+// Publish доставляет событие подписчикам его пользователя. Ошибка Redis
+// только записывается в лог: изменение задачи уже сохранено.
+func (b *Bus) Publish(ctx context.Context, e Event) {
+	if b == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	b.deliver(e)
+	if b.redis == nil {
+		return
+	}
+	payload, err := json.Marshal(envelope{Origin: b.origin, Event: e})
+	if err == nil {
+		err = b.redis.Publish(ctx, redisChannel, payload)
+	}
+	if err != nil {
+		slog.Error("Event publish failed", "type", e.Type, "task_id", e.TaskID, "error", err)
+	}
+}
+
+// Run получает события других реплик из Redis, пока не отменен ctx.
+// Если подписаться не удалось (например, Redis еще не поднялся), Run
+// повторяет попытки по subscribeRetry, а не останавливается: иначе
+// реплика до перезапуска не видела бы чужих событий.
+func (b *Bus) Run(ctx context.Context) error {
+	if b == nil || b.redis == nil {
+		return nil
+	}
+	retry := subscribeRetry
+	for {
+		msgs, err := b.redis.Subscribe(ctx, redisChannel)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			slog.Warn("Event subscription failed, retrying", "retry_in", retry, "error", err)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(retry):
+			}
+			retry = min(2*retry, maxSubscribeRetry)
+			continue
+		}
+		retry = subscribeRetry
+		for msg := range msgs {
+			var env envelope
+			if err := json.Unmarshal([]byte(msg), &env); err != nil {
+				slog.Error("Event decode failed", "error", err)
+				continue
+			}
+			if env.Origin != b.origin {
+				b.deliver(env.Event)
+			}
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+func (b *Bus) deliver(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subs[e.UserID] {
+		select {
+		case sub.c <- e:
+		default:
+			// Подписчик не успевает: закрываем поток, чтобы клиент
+			// переподключился и заново загрузил задачи, а не пропустил событие
+			slog.Warn("Event subscriber is too slow, closing", "user_id", e.UserID)
+			b.remove(sub)
+		}
+	}
+}
+
+// Subscription - подписка на события одного пользователя.
+type Subscription struct {
+	// C закрывается после Close или если подписчик не успевает читать события.
+	C <-chan Event
+
+	c      chan Event
+	bus    *Bus
+	userID int64
+}
+
+// Subscribe подписывает на события пользователя userID. Подписку нужно
+// закрыть через Close.
+func (b *Bus) Subscribe(userID int64) *Subscription {
+	c := make(chan Event, subscriptionBuffer)
+	sub := &Subscription{C: c, c: c, bus: b, userID: userID}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subs[userID] == nil {
+		b.subs[userID] = make(map[*Subscription]struct{})
+	}
+	b.subs[userID][sub] = struct{}{}
+	return sub
+}
+
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	s.bus.remove(s)
+}
+
+// remove отписывает sub; вызывается под b.mu.
+func (b *Bus) remove(sub *Subscription) {
+	subs := b.subs[sub.userID]
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(b.subs, sub.userID)
+	}
+	close(sub.c)
+}
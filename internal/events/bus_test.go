@@ -0,0 +1,127 @@
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"task-traker/internal/repository"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func receive(t *testing.T, sub *Subscription) Event {
+	t.Helper()
+	select {
+	case e, ok := <-sub.C:
+		require.True(t, ok, "subscription closed")
+		return e
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event")
+		return Event{}
+	}
+}
+
+func TestBus_DeliversToOwnerOnly(t *testing.T) {
+	bus := NewBus(nil)
+	mine := bus.Subscribe(1)
+	defer mine.Close()
+	other := bus.Subscribe(2)
+	defer other.Close()
+
+	bus.Publish(context.Background(), Event{Type: TaskCreated, UserID: 1, TaskID: 10})
+
+	e := receive(t, mine)
+	assert.Equal(t, TaskCreated, e.Type)
+	assert.Equal(t, 10, e.TaskID)
+	assert.False(t, e.Time.IsZero())
+	assert.Empty(t, other.C)
+}
+
+func TestBus_ClosesSlowSubscriber(t *testing.T) {
+	bus := NewBus(nil)
+	sub := bus.Subscribe(1)
+	defer sub.Close()
+
+	for i := range subscriptionBuffer + 1 {
+		bus.Publish(context.Background(), Event{Type: TaskUpdated, UserID: 1, TaskID: i})
+	}
+
+	n := 0
+	for range sub.C {
+		n++
+	}
+	assert.Equal(t, subscriptionBuffer, n)
+	// Повторный Close после закрытия шиной безопасен
+	sub.Close()
+}
+
+func TestBus_NilIsNoop(t *testing.T) {
+	var bus *Bus
+	assert.NotPanics(t, func() {
+		bus.Publish(context.Background(), Event{Type: TaskDeleted, UserID: 1})
+		assert.NoError(t, bus.Run(context.Background()))
+	})
+}
+
+func TestBus_FansOutThroughRedis(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Две реплики с общим Redis
+	a := NewBus(repository.NewRedisRepo(mr.Addr()))
+	b := NewBus(repository.NewRedisRepo(mr.Addr()))
+	for _, bus := range []*Bus{a, b} {
+		go bus.Run(ctx)
+	}
+	require.Eventually(t, func() bool {
+		return mr.PubSubNumSub(redisChannel)[redisChannel] == 2
+	}, 2*time.Second, 10*time.Millisecond)
+
+	subA := a.Subscribe(1)
+	defer subA.Close()
+	subB := b.Subscribe(1)
+	defer subB.Close()
+
+	a.Publish(ctx, Event{Type: TaskCreated, UserID: 1, TaskID: 5})
+
+	assert.Equal(t, 5, receive(t, subB).TaskID)
+	assert.Equal(t, 5, receive(t, subA).TaskID)
+	// Свое сообщение из Redis реплика не доставляет второй раз
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, subA.C)
+}
+
+func TestBus_RetriesSubscription(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Redis недоступен, когда реплика запускается
+	mr.SetError("LOADING Redis is loading the dataset in memory")
+	a := NewBus(repository.NewRedisRepo(mr.Addr()))
+	done := make(chan error, 1)
+	go func() { done <- a.Run(ctx) }()
+	time.Sleep(3 * subscribeRetry)
+	assert.Empty(t, done, "Run stopped on subscription error")
+
+	mr.SetError("")
+	require.Eventually(t, func() bool {
+		return mr.PubSubNumSub(redisChannel)[redisChannel] == 1
+	}, 2*time.Second, 10*time.Millisecond)
+	sub := a.Subscribe(1)
+	defer sub.Close()
+	NewBus(repository.NewRedisRepo(mr.Addr())).Publish(ctx, Event{Type: TaskCreated, UserID: 1, TaskID: 5})
+	assert.Equal(t, 5, receive(t, sub).TaskID)
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not stop")
+	}
+}
@@ -0,0 +1,12 @@
+package i18n
+
+// catalogs - переводы сообщений: исходный текст -> перевод.
+var catalogs = map[Lang]map[string]string{
+	EN: en,
+	RU: ru,
+}
+
+// plurals - переводы форм множественного числа по русской форме one.
+var plurals = map[Lang]map[string][]string{
+	EN: enPlurals,
+}
@@ -0,0 +1,153 @@
+package i18n
+
+// en - английский перевод сообщений бота и ошибок сервиса.
+var en = map[string]string{
+	// Меню и команды
+	"➕ Добавить задачу": "➕ Add task",
+	"📋 Все задачи":      "📋 All tasks",
+	"Привет! Я запоминаю задачи и присылаю уведомления о дедлайне.": "Hi! I keep track of your tasks and remind you about deadlines.",
+	"Неизвестная команда":                                "Unknown command",
+	"Используйте кнопки меню или команды.":               "Use the menu buttons or commands.",
+	"Нечего отменять":                                    "Nothing to cancel",
+	"Ошибка сервера":                                     "Server error",
+	"Ошибка сервера. Попробуйте еще раз.":                "Server error. Please try again.",
+	"❌ Ошибка генерации кода":                            "❌ Failed to generate a code",
+	"🔐 %s\n\nВаш ID: %s\nВаш код: %s":                    "🔐 %s\n\nYour ID: %s\nYour code: %s",
+	"Авторизация":                                        "Login",
+	"Исходное сообщение":                                 "Source message",
+	"Поддерживаются языки: ru, en.":                      "Supported languages: ru, en.",
+	"Выберите язык:":                                     "Choose a language:",
+	"Язык бота: русский":                                 "Bot language: English",
+	"Эта команда работает только в личном чате с ботом.": "This command only works in a private chat with the bot.",
+
+	// Описания команд в меню
+	"Главное меню":                            "Main menu",
+	"Добавить задачу":                         "Add a task",
+	"Список задач":                            "List tasks",
+	"Задачи на сегодня":                       "Tasks due today",
+	"Задачи на неделю":                        "Tasks for the week",
+	"Найти задачи по тексту":                  "Search tasks by text",
+	"Статистика за четыре недели":             "Statistics for four weeks",
+	"Напомнить о сообщении (ответом на него)": "Remind about a message (as a reply)",
+	"Код для входа в API":                     "Get an API login code",
+	"Сменить язык":                            "Change language",
+	"Отменить текущее действие":               "Cancel the current action",
+
+	// Диалоги
+	"Действие отменено":                               "Cancelled",
+	"◀️ Назад":                                        "◀️ Back",
+	"✖️ Отмена":                                       "✖️ Cancel",
+	"Напишите текст задачи":                           "Write the task text",
+	"Текст задачи не может быть пустым.":              "Task text cannot be empty.",
+	"Ошибка формата даты. Попробуйте еще раз.":        "Invalid date format. Please try again.",
+	"Теперь введите дату и время (ДД.ММ.ГГГГ ЧЧ:ММ):": "Now enter the date and time (DD.MM.YYYY HH:MM):",
+	"✅ Задача сохранена!":                             "✅ Task saved!",
+	"Что изменить в задаче?":                          "What would you like to change?",
+	"Название":                                        "Title",
+	"Срок":                                            "Deadline",
+	"Приоритет":                                       "Priority",
+	"Напоминание":                                     "Reminder",
+	"Введите новое название задачи:":                  "Enter a new task title:",
+	"Введите новую дату и время (ДД.ММ.ГГГГ ЧЧ:ММ):": "Enter a new date and time (DD.MM.YYYY HH:MM):",
+	"Выберите приоритет:":                            "Choose a priority:",
+	"🔽 Низкий":                                       "🔽 Low",
+	"Обычный":                                        "Normal",
+	"🔥 Высокий":                                      "🔥 High",
+	"За сколько минут до срока напомнить? Выберите вариант или введите число минут:": "How many minutes before the deadline should I remind you? Pick an option or enter a number of minutes:",
+	"В срок": "On time",
+	"15 мин": "15 min",
+	"1 час":  "1 hour",
+	"1 день": "1 day",
+	"Выберите вариант кнопкой.":               "Please choose an option using the buttons.",
+	"Введите целое число минут, например 30.": "Enter a whole number of minutes, e.g. 30.",
+	"✅ Задача обновлена":                      "✅ Task updated",
+
+	// Список и действия с задачами
+	"📋 Ваши активные задачи":      "📋 Your active tasks",
+	"У вас нет активных задач🎉":   "You have no active tasks🎉",
+	"Нет задач по этому фильтру.": "No tasks match this filter.",
+	"Сегодня":     "Today",
+	"Неделя":      "Week",
+	"Просрочено":  "Overdue",
+	"Завтра":      "Tomorrow",
+	"Понедельник": "Monday",
+	"Вторник":     "Tuesday",
+	"Среда":       "Wednesday",
+	"Четверг":     "Thursday",
+	"Пятница":     "Friday",
+	"Суббота":     "Saturday",
+	"Воскресенье": "Sunday",
+	"поиск «%s»":  "search “%s”",
+	"Напишите, что искать, например: /search молоко": "Tell me what to search for, e.g. /search milk",
+	"Слишком длинный запрос, сократите его.":         "The query is too long, please shorten it.",
+	"в срок":                     "on time",
+	"Задача не найдена":          "Task not found",
+	"Не удалось отметить задачу": "Could not mark the task as done",
+	"Не удалось удалить задачу":  "Could not delete the task",
+
+	// Быстрое добавление, inline-режим и пересланные сообщения
+	"❌ Не удалось сохранить задачу: проверьте название, дату и время.": "❌ Could not save the task: check the title, date and time.",
+	"↩️ Отменить":                "↩️ Undo",
+	"✏️ Изменить":                "✏️ Edit",
+	"Задача уже удалена":         "The task has already been deleted",
+	"Не удалось отменить задачу": "Could not undo the task",
+	"↩️ Задача «%s» отменена":    "↩️ Task “%s” cancelled",
+	"➕ Создать задачу: %s":       "➕ Create task: %s",
+	"В пересланном сообщении нет текста для задачи.": "The forwarded message has no text for a task.",
+	"📩 Задача: %s": "📩 Task: %s",
+	"Ответьте командой /remind 2h на сообщение, о котором нужно напомнить.": "Reply with /remind 2h to the message you want to be reminded about.",
+	"Укажите срок: /remind 30m, /remind 2h или /remind 1d.":                 "Specify a delay: /remind 30m, /remind 2h or /remind 1d.",
+	"Сообщение в чате":      "Message in chat",
+	"Сообщение в чате «%s»": "Message in “%s”",
+	"⏰ Напомню %s в %s":     "⏰ I'll remind %s at %s",
+	"⏰Напоминание: %s":      "⏰Reminder: %s",
+
+	// Статистика
+	"📊 Статистика с %s по %s":  "📊 Statistics from %s to %s",
+	"Создано: %d":              "Created: %d",
+	"Выполнено: %d":            "Completed: %d",
+	", в срок %d%%":            ", on time %d%%",
+	"Среднее опоздание: %s":    "Average delay: %s",
+	"Просрочено: %d":           "Overdue: %d",
+	"🔥 Серия: %s (рекорд: %s)": "🔥 Streak: %s (best: %s)",
+	"Выполнено по неделям:":    "Completed per week:",
+	"%d мин":      "%d min",
+	"%d ч":        "%d h",
+	"%d ч %d мин": "%d h %d min",
+
+	// Ошибки сервиса
+	"название задачи не может быть пустым":                               "task title cannot be empty",
+	"время выполнения не должно быть в прошлом":                          "the deadline must not be in the past",
+	"некорректный приоритет":                                             "invalid priority",
+	"время напоминания не может быть отрицательным":                      "reminder time cannot be negative",
+	"некорректно введенная строка времени, %v":                           "invalid date and time, %v",
+	"не удалось разобрать срок %q":                                       "could not parse delay %q",
+	"срок напоминания должен быть от минуты до года":                     "the reminder delay must be between a minute and a year",
+	"язык %q не поддерживается":                                          "language %q is not supported",
+	"начало периода должно быть раньше конца":                            "the start of the period must be before its end",
+	"период статистики не может быть больше года":                        "the statistics period cannot be longer than a year",
+	"неизвестный статус %q":                                              "unknown status %q",
+	"неизвестная сортировка %q":                                          "unknown sort order %q",
+	"размер страницы должен быть от 1 до %d":                             "the page size must be between 1 and %d",
+	"некорректный курсор":                                                "invalid cursor",
+	"курсор получен для другой сортировки":                               "the cursor belongs to a different sort order",
+	"неверный или просроченный код":                                      "invalid or expired code",
+	"refresh-токен недействителен или истек":                             "refresh token expired or invalid",
+	"неизвестный режим %q":                                               "unknown mode %q",
+	"в пакете должно быть от 1 до %d операций":                           "a batch must contain between 1 and %d operations",
+	"неизвестная операция %q":                                            "unknown operation %q",
+	"не указан срок задачи":                                              "the deadline is required",
+	"слишком много неверных попыток, получите новый код командой /login": "too many wrong codes, get a new one with the /login command",
+	"адрес вебхука должен быть абсолютным URL http или https":            "the webhook address must be an absolute http or https URL",
+	"секрет должен быть не короче %d символов":                           "the secret must be at least %d characters long",
+	"неизвестный тип события %q":                                         "unknown event type %q",
+	"можно создать не больше %d вебхуков":                                "you can create at most %d webhooks",
+}
+
+// enPlurals - формы one и other по русской форме one.
+var enPlurals = map[string][]string{
+	"за %d минуту": {"%d minute before", "%d minutes before"},
+	"за %d час":    {"%d hour before", "%d hours before"},
+	"за %d день":   {"%d day before", "%d days before"},
+	"%d день":      {"%d day", "%d days"},
+}
@@ -0,0 +1,168 @@
+// Package i18n переводит сообщения бота и API на язык пользователя.
+//
+// Ключом перевода служит сам исходный текст, как в gettext: бот написан
+// по-русски, HTTP API - по-английски, а каталоги содержат переводы на
+// другой язык. Строка без перевода выводится как есть.
+package i18n
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// Lang - поддерживаемый язык интерфейса.
+type Lang string
+
+const (
+	RU Lang = "ru"
+	EN Lang = "en"
+)
+
+// Default - язык бота, если язык пользователя неизвестен.
+const Default = RU
+
+// Supported - поддерживаемые языки в порядке показа пользователю.
+var Supported = []Lang{RU, EN}
+
+// russianFamily - языки клиента Telegram, для которых бот отвечает по-русски.
+var russianFamily = []string{"ru", "uk", "be", "kk"}
+
+// Parse разбирает код языка ("ru", "en", "en-US"). ok == false, если язык
+// не поддерживается.
+func Parse(code string) (Lang, bool) {
+	base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(code)), "-")
+	for _, l := range Supported {
+		if string(l) == base {
+			return l, true
+		}
+	}
+	return "", false
+}
+
+// FromTelegram выбирает язык по language_code клиента Telegram: русский
+// для русскоязычных и соседних языков, английский для остальных.
+func FromTelegram(code string) Lang {
+	if code == "" {
+		return Default
+	}
+	if l, ok := Parse(code); ok {
+		return l
+	}
+	base, _, _ := strings.Cut(strings.ToLower(code), "-")
+	for _, c := range russianFamily {
+		if c == base {
+			return RU
+		}
+	}
+	return EN
+}
+
+var matcher = language.NewMatcher([]language.Tag{language.English, language.Russian})
+
+// FromAcceptLanguage выбирает язык по заголовку Accept-Language с учетом
+// весов q. Если заголовок пуст или ни один язык не подходит - fallback.
+func FromAcceptLanguage(header string, fallback Lang) Lang {
+	tags, _, err := language.ParseAcceptLanguage(header)
+	if err != nil || len(tags) == 0 {
+		return fallback
+	}
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return fallback
+	}
+	return []Lang{EN, RU}[index]
+}
+
+// T переводит сообщение msg и подставляет в него args, как fmt.Sprintf.
+func (l Lang) T(msg string, args ...any) string {
+	if tr, ok := catalogs[l][msg]; ok {
+		msg = tr
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// N выбирает форму сообщения для числа n и подставляет n. Формы передаются
+// на русском: для одного (1, 21), нескольких (2-4, 22-24) и многих (5-20)
+// предметов, например N(5, "%d задача", "%d задачи", "%d задач").
+// Перевод ищется в каталоге по форме one.
+func (l Lang) N(n int, one, few, many string) string {
+	forms := []string{one, few, many}
+	if tr, ok := plurals[l][one]; ok {
+		forms = tr
+	}
+	return fmt.Sprintf(forms[pluralForm(n, len(forms))], n)
+}
+
+// pluralForm - индекс формы для числа n: по правилам английского языка,
+// если форм две, и русского, если их три.
+func pluralForm(n, forms int) int {
+	if n < 0 {
+		n = -n
+	}
+	if forms == 2 {
+		// Английский: one и other
+		if n == 1 {
+			return 0
+		}
+		return 1
+	}
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		return 0
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return 1
+	default:
+		return 2
+	}
+}
+
+type langKey struct{}
+
+// WithLang сохраняет язык пользователя в контексте обработки запроса.
+func WithLang(ctx context.Context, l Lang) context.Context {
+	return context.WithValue(ctx, langKey{}, l)
+}
+
+// FromContext возвращает язык из контекста или Default.
+func FromContext(ctx context.Context) Lang {
+	if l, ok := ctx.Value(langKey{}).(Lang); ok {
+		return l
+	}
+	return Default
+}
+
+// Error - ошибка с переводимым текстом. Error() возвращает исходный текст,
+// а Lang.Error - перевод для пользователя.
+type Error struct {
+	Msg  string
+	Args []any
+}
+
+// Errorf создает переводимую ошибку. Аргументы подставляются как в fmt.Sprintf.
+func Errorf(msg string, args ...any) error {
+	return &Error{Msg: msg, Args: args}
+}
+
+func (e *Error) Error() string {
+	if len(e.Args) == 0 {
+		return e.Msg
+	}
+	return fmt.Sprintf(e.Msg, e.Args...)
+}
+
+// Error возвращает перевод ошибки err. ok == false, если в цепочке err
+// нет переводимой ошибки - такой текст нельзя показывать пользователю.
+func (l Lang) Error(err error) (string, bool) {
+	var e *Error
+	if !errors.As(err, &e) {
+		return "", false
+	}
+	return l.T(e.Msg, e.Args...), true
+}
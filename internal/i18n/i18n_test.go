@@ -0,0 +1,161 @@
+package i18n
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestT(t *testing.T) {
+	assert.Equal(t, "Task not found", EN.T("Задача не найдена"))
+	assert.Equal(t, "Задача не найдена", RU.T("Задача не найдена"))
+	assert.Equal(t, "📩 Task: Купить хлеб", EN.T("📩 Задача: %s", "Купить хлеб"))
+	assert.Equal(t, "Нет перевода", EN.T("Нет перевода"), "строка без перевода выводится как есть")
+	assert.Equal(t, "Требуется авторизация", RU.T("Unauthorized"))
+}
+
+func TestN(t *testing.T) {
+	forms := func(l Lang, n int) string {
+		return l.N(n, "за %d минуту", "за %d минуты", "за %d минут")
+	}
+
+	for n, want := range map[int]string{
+		1: "за 1 минуту", 2: "за 2 минуты", 4: "за 4 минуты", 5: "за 5 минут",
+		11: "за 11 минут", 12: "за 12 минут", 14: "за 14 минут", 21: "за 21 минуту",
+		22: "за 22 минуты", 25: "за 25 минут", 101: "за 101 минуту", 111: "за 111 минут", 0: "за 0 минут",
+	} {
+		assert.Equal(t, want, forms(RU, n), n)
+	}
+	assert.Equal(t, "1 minute before", forms(EN, 1))
+	assert.Equal(t, "21 minutes before", forms(EN, 21))
+}
+
+func TestFromTelegram(t *testing.T) {
+	for code, want := range map[string]Lang{
+		"":      RU,
+		"ru":    RU,
+		"uk":    RU,
+		"en":    EN,
+		"en-US": EN,
+		"de":    EN,
+	} {
+		assert.Equal(t, want, FromTelegram(code), code)
+	}
+}
+
+func TestFromAcceptLanguage(t *testing.T) {
+	for header, want := range map[string]Lang{
+		"":                        EN,
+		"ru-RU,ru;q=0.9,en;q=0.8": RU,
+		"en-GB,en;q=0.9,ru;q=0.8": EN,
+		"de-DE,ru;q=0.5":          RU,
+		"ja":                      EN,
+		"not a header;;;":         EN,
+	} {
+		assert.Equal(t, want, FromAcceptLanguage(header, EN), header)
+	}
+	assert.Equal(t, RU, FromAcceptLanguage("", RU))
+}
+
+func TestContext(t *testing.T) {
+	assert.Equal(t, Default, FromContext(context.Background()))
+	assert.Equal(t, EN, FromContext(WithLang(context.Background(), EN)))
+}
+
+func TestError(t *testing.T) {
+	err := fmt.Errorf("create: %w", Errorf("язык %q не поддерживается", "de"))
+	assert.Equal(t, `create: язык "de" не поддерживается`, err.Error())
+
+	msg, ok := EN.Error(err)
+	require.True(t, ok)
+	assert.Equal(t, `language "de" is not supported`, msg)
+
+	_, ok = EN.Error(errors.New("connection refused"))
+	assert.False(t, ok, "непереводимые ошибки не показываются пользователю")
+}
+
+var verbRe = regexp.MustCompile(`%[-+# 0]*\d*[a-zA-Z]`)
+
+// TestCatalogVerbs проверяет, что перевод принимает те же аргументы, что и исходный текст.
+func TestCatalogVerbs(t *testing.T) {
+	for lang, catalog := range catalogs {
+		for msg, tr := range catalog {
+			assert.Equal(t, verbRe.FindAllString(msg, -1), verbRe.FindAllString(tr, -1), "%s: %q", lang, msg)
+		}
+	}
+	for msg, forms := range enPlurals {
+		assert.Len(t, forms, 2, msg)
+		for _, f := range forms {
+			assert.Equal(t, []string{"%d"}, verbRe.FindAllString(f, -1), msg)
+		}
+	}
+}
+
+// TestCatalogCoversSources ищет в коде проекта строковые литералы, переданные
+// в T, N, Errorf, httpError и хелперы ошибок сервиса, и проверяет, что у каждого есть перевод.
+func TestCatalogCoversSources(t *testing.T) {
+	root := filepath.Join("..", "..")
+	fset := token.NewFileSet()
+	checked := 0
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return err
+		}
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			var name string
+			switch fn := call.Fun.(type) {
+			case *ast.SelectorExpr:
+				name = fn.Sel.Name
+				// fmt.Errorf и прочие Errorf не переводятся
+				if pkg, ok := fn.X.(*ast.Ident); name == "Errorf" && (!ok || pkg.Name != "i18n") {
+					return true
+				}
+			case *ast.Ident:
+				name = fn.Name
+			}
+			arg := map[string]int{"T": 0, "Errorf": 0, "N": 1, "httpError": 2, "paramError": 3, "invalid": 1, "addField": 1, "errorf": 1}
+			i, ok := arg[name]
+			if !ok || len(call.Args) <= i {
+				return true
+			}
+			lit, ok := call.Args[i].(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				return true
+			}
+			msg, _ := strconv.Unquote(lit.Value)
+			checked++
+			if name == "N" {
+				assert.Contains(t, enPlurals, msg, fset.Position(lit.Pos()))
+				return true
+			}
+			_, inEN := en[msg]
+			_, inRU := ru[msg]
+			assert.True(t, inEN || inRU, "нет перевода для %q (%s)", msg, fset.Position(lit.Pos()))
+			return true
+		})
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Greater(t, checked, 50)
+}
@@ -0,0 +1,31 @@
+package i18n
+
+// ru - русский перевод ошибок HTTP API.
+var ru = map[string]string{
+	"Unauthorized":                                              "Требуется авторизация",
+	"Token is invalidated (logged out)":                         "Токен отозван (выполнен выход)",
+	"Internal Server Error":                                     "Внутренняя ошибка сервера",
+	"JSON decode error":                                         "Некорректный JSON",
+	"invalid request body":                                      "Некорректное тело запроса",
+	"Invalid request body":                                      "Некорректное тело запроса",
+	"Logout failed":                                             "Не удалось выйти",
+	"Invalid date, expected YYYY-MM-DD":                         "Некорректная дата, ожидается ГГГГ-ММ-ДД",
+	"Invalid due_after, expected RFC 3339 or YYYY-MM-DD":        "Некорректный due_after, ожидается RFC 3339 или ГГГГ-ММ-ДД",
+	"Invalid due_before, expected RFC 3339 or YYYY-MM-DD":       "Некорректный due_before, ожидается RFC 3339 или ГГГГ-ММ-ДД",
+	"Invalid limit":                                             "Некорректный limit",
+	"Request validation failed":                                 "Запрос не прошел проверку",
+	"Task not found":                                            "Задача не найдена",
+	"Invalid task ID":                                           "Некорректный ID задачи",
+	"Tasks can only be created for the authenticated user":      "Создавать задачи можно только для себя",
+	"Idempotency-Key must be at most 255 characters":            "Idempotency-Key должен быть не длиннее 255 символов",
+	"Request body is too large":                                 "Слишком большое тело запроса",
+	"A request with this Idempotency-Key is still in progress":  "Запрос с этим Idempotency-Key еще выполняется",
+	"Idempotency-Key was already used with a different request": "Этот Idempotency-Key уже использован для другого запроса",
+	"Event stream is not available":                             "Поток событий недоступен",
+	"WebSocket upgrade required":                                "Нужно подключение по WebSocket",
+	"Webhook not found":                                         "Вебхук не найден",
+	"Invalid webhook ID":                                        "Некорректный ID вебхука",
+	"Task was modified, fetch it again":                         "Задача изменилась, загрузите ее заново",
+	"Too many requests, try again later":                        "Слишком много запросов, повторите позже",
+	"Task was modified by another request":                      "Задачу одновременно изменил другой запрос",
+}
@@ -2,18 +2,55 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"task-traker/internal/domain"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// taskColumns - колонки задачи в порядке полей domain.Task.
+const taskColumns = "id, user_id, title, deadline, notified, created_at, priority, remind_before, tags, completed_at, source_url, project, version, updated_at"
+
 type Repository struct {
 	DB *pgxpool.Pool
+	// tx - транзакция репозитория, переданного в функцию InTx; nil - запросы
+	// идут через пул.
+	tx pgx.Tx
+}
+
+// querier - общие методы пула и транзакции.
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func (r *Repository) db() querier {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.DB
+}
+
+// InTx выполняет fn в транзакции. Внутри транзакции InTx создает точку
+// сохранения, и ошибка fn откатывает только изменения, сделанные в fn.
+func (r *Repository) InTx(ctx context.Context, fn func(domain.TaskRepository) error) error {
+	var db interface {
+		Begin(context.Context) (pgx.Tx, error)
+	} = r.DB
+	if r.tx != nil {
+		db = r.tx
+	}
+	return pgx.BeginFunc(ctx, db, func(tx pgx.Tx) error {
+		return fn(&Repository{DB: r.DB, tx: tx})
+	})
 }
 
 func InitDB(ctx context.Context) (*Repository, error) {
@@ -38,15 +75,21 @@ func InitDB(ctx context.Context) (*Repository, error) {
 
 func (r *Repository) Create(ctx context.Context, task *domain.Task) error {
 	query := `
-	INSERT INTO tasks (user_id, title, deadline)
-	VALUES ($1, $2, $3);
+	INSERT INTO tasks (user_id, title, deadline, priority, remind_before, tags, source_url, project)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	RETURNING id, created_at, version, updated_at;
 	`
-	_, err := r.DB.Exec(
+	err := r.db().QueryRow(
 		ctx,
 		query,
 		task.UserID,
 		task.Title,
-		task.Deadline)
+		task.Deadline,
+		task.Priority,
+		task.RemindBefore,
+		tags(task.Tags),
+		task.SourceURL,
+		task.Project).Scan(&task.ID, &task.CreatedAt, &task.Version, &task.UpdatedAt)
 
 	if err != nil {
 		return err
@@ -56,12 +99,13 @@ func (r *Repository) Create(ctx context.Context, task *domain.Task) error {
 
 func (r *Repository) GetActiveTasks(ctx context.Context) ([]domain.Task, error) {
 	query := `
-		SELECT id, user_id, title, deadline, notified, created_at
+		SELECT ` + taskColumns + `
 		FROM tasks
 		WHERE notified = false
-			AND deadline <= (NOW() + INTERVAL '15 minutes');
+			AND completed_at IS NULL
+			AND deadline <= (NOW() + make_interval(mins => remind_before));
 	`
-	rows, err := r.DB.Query(ctx, query)
+	rows, err := r.db().Query(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка GetActiveTasks: %w", err)
 	}
@@ -72,12 +116,12 @@ func (r *Repository) GetActiveTasks(ctx context.Context) ([]domain.Task, error)
 
 func (r *Repository) GetTasksByUserID(ctx context.Context, userID int64) ([]domain.Task, error) {
 	query := `
-	SELECT id, user_id, title, deadline, notified, created_at
+	SELECT ` + taskColumns + `
 	FROM tasks
-	WHERE notified = false AND user_id = $1
+	WHERE completed_at IS NULL AND user_id = $1
 	ORDER BY deadline;
 	`
-	rows, err := r.DB.Query(ctx, query, userID)
+	rows, err := r.db().Query(ctx, query, userID)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка GetTasksByUserID: %w", err)
 	}
@@ -86,10 +130,264 @@ func (r *Repository) GetTasksByUserID(ctx context.Context, userID int64) ([]doma
 	return pgx.CollectRows(rows, pgx.RowToStructByName[domain.Task])
 }
 
+func (r *Repository) GetTasksByDeadline(ctx context.Context, userID int64, from, to time.Time) ([]domain.Task, error) {
+	query := `
+	SELECT ` + taskColumns + `
+	FROM tasks
+	WHERE completed_at IS NULL AND user_id = $1 AND deadline >= $2 AND deadline < $3
+	ORDER BY deadline;
+	`
+	rows, err := r.db().Query(ctx, query, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка GetTasksByDeadline: %w", err)
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[domain.Task])
+}
+
+// likeEscaper экранирует спецсимволы шаблона LIKE; экранирующий символ
+// по умолчанию - обратная косая черта.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func (r *Repository) SearchTasks(ctx context.Context, userID int64, query string, limit int) ([]domain.Task, error) {
+	return r.listTasks(ctx, "SearchTasks", domain.TaskFilter{UserID: userID, Query: query, Limit: limit})
+}
+
+func (r *Repository) GetUserTags(ctx context.Context, userID int64) ([]string, error) {
+	query := `
+	SELECT tag
+	FROM tasks, unnest(tags) AS tag
+	WHERE user_id = $1 AND completed_at IS NULL
+	GROUP BY tag
+	ORDER BY count(*) DESC, tag;
+	`
+	rows, err := r.db().Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка GetUserTags: %w", err)
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+func (r *Repository) ListTasks(ctx context.Context, filter domain.TaskFilter) ([]domain.Task, error) {
+	return r.listTasks(ctx, "ListTasks", filter)
+}
+
+func (r *Repository) listTasks(ctx context.Context, op string, filter domain.TaskFilter) ([]domain.Task, error) {
+	query, args := buildListQuery(filter)
+	rows, err := r.db().Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка %s: %w", op, err)
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[domain.Task])
+}
+
+// sortColumns - колонки для сортировки. В SQL попадают только они, а не
+// значение из запроса.
+var sortColumns = map[domain.TaskSort]string{
+	domain.SortDeadline:     "deadline",
+	domain.SortDeadlineDesc: "deadline",
+	domain.SortCreated:      "created_at",
+	domain.SortCreatedDesc:  "created_at",
+}
+
+// buildListQuery строит запрос для TaskFilter. Все значения фильтра
+// передаются параметрами, а в текст запроса попадают только условия
+// и имена колонок из этого файла.
+func buildListQuery(f domain.TaskFilter) (string, []any) {
+	var w whereBuilder
+	w.add("user_id = $?", f.UserID)
+	switch f.Status {
+	case domain.StatusCompleted:
+		w.add("completed_at IS NOT NULL")
+	case domain.StatusAll:
+	default:
+		w.add("completed_at IS NULL")
+	}
+	if !f.DueAfter.IsZero() {
+		w.add("deadline >= $?", f.DueAfter)
+	}
+	if !f.DueBefore.IsZero() {
+		w.add("deadline < $?", f.DueBefore)
+	}
+	// Каждое слово должно найтись в названии или совпасть с тегом ("дом" или "#дом")
+	for _, word := range strings.Fields(strings.ToLower(f.Query)) {
+		w.add("(title ILIKE $? OR $? = ANY(tags))", "%"+likeEscaper.Replace(word)+"%", strings.TrimPrefix(word, "#"))
+	}
+	if f.Tag != "" {
+		w.add("$? = ANY(tags)", strings.ToLower(f.Tag))
+	}
+	if f.Project != "" {
+		w.add("project = $?", f.Project)
+	}
+
+	column, ok := sortColumns[f.Sort]
+	if !ok {
+		column = sortColumns[domain.SortDeadline]
+	}
+	order, cmp := "ASC", ">"
+	if f.Sort.Desc() {
+		order, cmp = "DESC", "<"
+	}
+	if f.After != nil {
+		w.add(fmt.Sprintf("(%s, id) %s ($?, $?)", column, cmp), f.After.Key, f.After.ID)
+	}
+
+	query := `
+	SELECT ` + taskColumns + `
+	FROM tasks
+	WHERE ` + strings.Join(w.conds, " AND ") + fmt.Sprintf(`
+	ORDER BY %[1]s %[2]s, id %[2]s`, column, order)
+	if f.Limit > 0 {
+		w.args = append(w.args, f.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(w.args))
+	}
+	return query, w.args
+}
+
+// whereBuilder собирает условия WHERE с нумерованными параметрами.
+type whereBuilder struct {
+	conds []string
+	args  []any
+}
+
+// add добавляет условие cond; каждый "$?" в нем по очереди заменяется
+// номером параметра из args.
+func (w *whereBuilder) add(cond string, args ...any) {
+	for _, arg := range args {
+		w.args = append(w.args, arg)
+		cond = strings.Replace(cond, "$?", fmt.Sprintf("$%d", len(w.args)), 1)
+	}
+	w.conds = append(w.conds, cond)
+}
+
+func (r *Repository) GetTaskHistory(ctx context.Context, userID int64, from, to time.Time) ([]domain.Task, error) {
+	query := `
+	SELECT ` + taskColumns + `
+	FROM tasks
+	WHERE user_id = $1
+		AND ((created_at >= $4 AND created_at < $5)
+			OR (completed_at >= $4 AND completed_at < $5)
+			OR (deadline >= $2 AND deadline < $3))
+	ORDER BY created_at;
+	`
+	// deadline хранит показания часов, как from и to, а created_at и
+	// completed_at - моменты времени
+	rows, err := r.db().Query(ctx, query, userID, from, to, instant(from), instant(to))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка GetTaskHistory: %w", err)
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[domain.Task])
+}
+
+// instant переводит время в соглашении service.Now (показания часов сервера
+// с пометкой UTC) в момент времени для сравнения с колонками TIMESTAMPTZ.
+func instant(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.Local)
+}
+
+func (r *Repository) GetTaskByID(ctx context.Context, userID int64, id int) (domain.Task, error) {
+	query := `
+	SELECT ` + taskColumns + `
+	FROM tasks
+	WHERE id = $1 AND user_id = $2;
+	`
+	rows, err := r.db().Query(ctx, query, id, userID)
+	if err != nil {
+		return domain.Task{}, fmt.Errorf("ошибка GetTaskByID: %w", err)
+	}
+	defer rows.Close()
+
+	task, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[domain.Task])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.Task{}, domain.ErrTaskNotFound
+	}
+	return task, err
+}
+
+func (r *Repository) Update(ctx context.Context, task *domain.Task) error {
+	// При переносе дедлайна или времени напоминания задача снова ждет уведомления
+	query := `
+	UPDATE tasks
+	SET title = $3,
+		deadline = $4,
+		priority = $5,
+		remind_before = $6,
+		tags = $7,
+		project = $8,
+		notified = notified AND deadline = $4 AND remind_before = $6,
+		version = version + 1,
+		updated_at = NOW()
+	WHERE id = $1 AND user_id = $2 AND version = $9
+	RETURNING version, updated_at;
+	`
+	err := r.db().QueryRow(ctx, query,
+		task.ID,
+		task.UserID,
+		task.Title,
+		task.Deadline,
+		task.Priority,
+		task.RemindBefore,
+		tags(task.Tags),
+		task.Project,
+		task.Version).Scan(&task.Version, &task.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return r.notChanged(ctx, "Update", task.UserID, task.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка Update: %w", err)
+	}
+	return nil
+}
+
+// notChanged объясняет, почему запрос с условием на версию не затронул
+// задачу: ее нет у пользователя (ErrTaskNotFound) или версия уже другая
+// (ErrVersionConflict).
+func (r *Repository) notChanged(ctx context.Context, op string, userID int64, id int) error {
+	var exists bool
+	query := "SELECT EXISTS (SELECT 1 FROM tasks WHERE id = $1 AND user_id = $2);"
+	if err := r.db().QueryRow(ctx, query, id, userID).Scan(&exists); err != nil {
+		return fmt.Errorf("ошибка %s: %w", op, err)
+	}
+	if !exists {
+		return domain.ErrTaskNotFound
+	}
+	return domain.ErrVersionConflict
+}
+
+func (r *Repository) Complete(ctx context.Context, userID int64, id int) error {
+	query := `
+	UPDATE tasks SET completed_at = NOW(), version = version + 1, updated_at = NOW()
+	WHERE id = $1 AND user_id = $2 AND completed_at IS NULL;
+	`
+	res, err := r.db().Exec(ctx, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка Complete: %w", err)
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrTaskNotFound
+	}
+	return nil
+}
+
+// tags заменяет nil на пустой массив: колонка tags NOT NULL.
+func tags(t []string) []string {
+	if t == nil {
+		return []string{}
+	}
+	return t
+}
+
 func (r *Repository) MarkAsNotified(ctx context.Context, taskID int) error {
-	query := `UPDATE tasks SET notified = true
+	query := `UPDATE tasks SET notified = true, version = version + 1, updated_at = NOW()
 			  WHERE id = $1;`
-	_, err := r.DB.Exec(ctx, query, taskID)
+	_, err := r.db().Exec(ctx, query, taskID)
 	if err != nil {
 		return fmt.Errorf("marcAsNotified error: %v", err)
 	}
@@ -122,10 +420,16 @@ func (r *Repository) MarkAsNotified(ctx context.Context, taskID int) error {
 // 	return nil
 // }
 
-func (r *Repository) DeleteByID(ctx context.Context, id string) error {
-	query := "DELETE FROM tasks WHERE id = $1;"
-	_, err := r.DB.Exec(ctx, query, id)
-	return err
+func (r *Repository) Delete(ctx context.Context, userID int64, id, version int) error {
+	query := "DELETE FROM tasks WHERE id = $1 AND user_id = $2 AND ($3 = 0 OR version = $3);"
+	res, err := r.db().Exec(ctx, query, id, userID, version)
+	if err != nil {
+		return fmt.Errorf("ошибка Delete: %w", err)
+	}
+	if res.RowsAffected() == 0 {
+		return r.notChanged(ctx, "Delete", userID, id)
+	}
+	return nil
 }
 
 func (r *Repository) SaveAuthCode(ctx context.Context, userID int64, code string, expiry time.Time) error {
@@ -135,7 +439,7 @@ func (r *Repository) SaveAuthCode(ctx context.Context, userID int64, code string
         ON CONFLICT (user_id) DO UPDATE
         SET code = EXCLUDED.code, expires_at = EXCLUDED.expires_at
     `
-	_, err := r.DB.Exec(ctx, query, userID, code, expiry)
+	_, err := r.db().Exec(ctx, query, userID, code, expiry)
 	return err
 }
 
@@ -144,7 +448,7 @@ func (r *Repository) VerifyAuthCode(ctx context.Context, userID int64, code stri
 	var expiresAt time.Time
 
 	query := `SELECT code, expires_at FROM auth_codes WHERE user_id = $1`
-	err := r.DB.QueryRow(ctx, query, userID).Scan(&dbCode, &expiresAt)
+	err := r.db().QueryRow(ctx, query, userID).Scan(&dbCode, &expiresAt)
 
 	if err != nil {
 		return false, err
@@ -154,7 +458,171 @@ func (r *Repository) VerifyAuthCode(ctx context.Context, userID int64, code stri
 		return false, nil
 	}
 
-	r.DB.Exec(ctx, "DELETE FROM auth_codes WHERE user_id = $1", userID)
+	r.db().Exec(ctx, "DELETE FROM auth_codes WHERE user_id = $1", userID)
 
 	return true, nil
 }
+
+func (r *Repository) GetLanguage(ctx context.Context, userID int64) (string, error) {
+	var lang string
+	err := r.db().QueryRow(ctx, `SELECT language FROM user_settings WHERE user_id = $1`, userID).Scan(&lang)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("ошибка GetLanguage: %w", err)
+	}
+	return lang, nil
+}
+
+func (r *Repository) SetLanguage(ctx context.Context, userID int64, lang string) error {
+	query := `
+	INSERT INTO user_settings (user_id, language)
+	VALUES ($1, $2)
+	ON CONFLICT (user_id) DO UPDATE
+	SET language = EXCLUDED.language, updated_at = NOW()
+	`
+	_, err := r.db().Exec(ctx, query, userID, lang)
+	return err
+}
+
+// webhookColumns и deliveryColumns - колонки в порядке полей
+// domain.Webhook и domain.WebhookDelivery.
+const (
+	webhookColumns  = "id, user_id, url, secret, events, created_at"
+	deliveryColumns = "id, webhook_id, user_id, event, payload, status, attempts, response_code, error, next_attempt_at, created_at, delivered_at"
+)
+
+func (r *Repository) CreateWebhook(ctx context.Context, hook *domain.Webhook) error {
+	query := `
+	INSERT INTO webhooks (user_id, url, secret, events)
+	VALUES ($1, $2, $3, $4)
+	RETURNING id, created_at;
+	`
+	err := r.db().QueryRow(ctx, query, hook.UserID, hook.URL, hook.Secret, tags(hook.Events)).Scan(&hook.ID, &hook.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("ошибка CreateWebhook: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) ListWebhooks(ctx context.Context, userID int64) ([]domain.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE user_id = $1 ORDER BY id;`
+	rows, err := r.db().Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка ListWebhooks: %w", err)
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[domain.Webhook])
+}
+
+func (r *Repository) GetWebhook(ctx context.Context, userID int64, id int) (domain.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = $1 AND user_id = $2;`
+	rows, err := r.db().Query(ctx, query, id, userID)
+	if err != nil {
+		return domain.Webhook{}, fmt.Errorf("ошибка GetWebhook: %w", err)
+	}
+	defer rows.Close()
+
+	hook, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[domain.Webhook])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.Webhook{}, domain.ErrWebhookNotFound
+	}
+	return hook, err
+}
+
+func (r *Repository) DeleteWebhook(ctx context.Context, userID int64, id int) error {
+	res, err := r.db().Exec(ctx, "DELETE FROM webhooks WHERE id = $1 AND user_id = $2;", id, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка DeleteWebhook: %w", err)
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (r *Repository) EnqueueWebhookEvent(ctx context.Context, userID int64, event string, payload []byte) (int, error) {
+	query := `
+	INSERT INTO webhook_deliveries (webhook_id, user_id, event, payload)
+	SELECT id, user_id, $2, $3
+	FROM webhooks
+	WHERE user_id = $1 AND (cardinality(events) = 0 OR $2 = ANY(events));
+	`
+	res, err := r.db().Exec(ctx, query, userID, event, payload)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка EnqueueWebhookEvent: %w", err)
+	}
+	return int(res.RowsAffected()), nil
+}
+
+func (r *Repository) CreateWebhookDelivery(ctx context.Context, d *domain.WebhookDelivery) error {
+	query := `
+	INSERT INTO webhook_deliveries (webhook_id, user_id, event, payload, status, next_attempt_at)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING id, created_at;
+	`
+	err := r.db().QueryRow(ctx, query, d.WebhookID, d.UserID, d.Event, d.Payload, d.Status, d.NextAttemptAt).
+		Scan(&d.ID, &d.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("ошибка CreateWebhookDelivery: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.WebhookDelivery, error) {
+	// SKIP LOCKED: реплики разбирают очередь, не дожидаясь друг друга
+	query := `
+	UPDATE webhook_deliveries SET next_attempt_at = $2
+	WHERE id IN (
+		SELECT id FROM webhook_deliveries
+		WHERE status = 'pending' AND next_attempt_at <= $1
+		ORDER BY next_attempt_at
+		LIMIT $3
+		FOR UPDATE SKIP LOCKED)
+	RETURNING ` + deliveryColumns + `;
+	`
+	rows, err := r.db().Query(ctx, query, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка ClaimWebhookDeliveries: %w", err)
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[domain.WebhookDelivery])
+}
+
+func (r *Repository) UpdateWebhookDelivery(ctx context.Context, d *domain.WebhookDelivery) error {
+	query := `
+	UPDATE webhook_deliveries
+	SET status = $2,
+		attempts = $3,
+		response_code = $4,
+		error = $5,
+		next_attempt_at = $6,
+		delivered_at = $7
+	WHERE id = $1;
+	`
+	_, err := r.db().Exec(ctx, query, d.ID, d.Status, d.Attempts, d.ResponseCode, d.Error, d.NextAttemptAt, d.DeliveredAt)
+	if err != nil {
+		return fmt.Errorf("ошибка UpdateWebhookDelivery: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) ListWebhookDeliveries(ctx context.Context, userID int64, webhookID int, limit int) ([]domain.WebhookDelivery, error) {
+	query := `
+	SELECT ` + deliveryColumns + `
+	FROM webhook_deliveries
+	WHERE webhook_id = $1 AND user_id = $2
+	ORDER BY id DESC
+	LIMIT $3;
+	`
+	rows, err := r.db().Query(ctx, query, webhookID, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка ListWebhookDeliveries: %w", err)
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[domain.WebhookDelivery])
+}
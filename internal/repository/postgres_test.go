@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"task-traker/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildListQuery(t *testing.T) {
+	after := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	query, args := buildListQuery(domain.TaskFilter{
+		UserID:   1,
+		Status:   domain.StatusCompleted,
+		DueAfter: after,
+		Query:    "50%_off #Дом",
+		Tag:      "Work",
+		Sort:     domain.SortCreatedDesc,
+		After:    &domain.TaskCursor{Key: after, ID: 7},
+		Limit:    11,
+	})
+
+	assert.Contains(t, query, "WHERE user_id = $1 AND completed_at IS NOT NULL AND deadline >= $2"+
+		" AND (title ILIKE $3 OR $4 = ANY(tags)) AND (title ILIKE $5 OR $6 = ANY(tags))"+
+		" AND $7 = ANY(tags) AND (created_at, id) < ($8, $9)")
+	assert.Contains(t, query, "ORDER BY created_at DESC, id DESC LIMIT $10")
+	assert.Equal(t, []any{int64(1), after, `%50\%\_off%`, "50%_off", "%#дом%", "дом", "work", after, 7, 11}, args)
+}
+
+func TestBuildListQuery_Defaults(t *testing.T) {
+	query, args := buildListQuery(domain.TaskFilter{UserID: 1, Sort: "1; DROP TABLE tasks"})
+
+	assert.Contains(t, query, "WHERE user_id = $1 AND completed_at IS NULL")
+	assert.Contains(t, query, "ORDER BY deadline ASC, id ASC")
+	assert.NotContains(t, query, "LIMIT")
+	assert.NotContains(t, query, "DROP")
+	assert.Equal(t, []any{int64(1)}, args)
+}
+
+func TestInstant(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("UTC+3", 3*60*60)
+	t.Cleanup(func() { time.Local = local })
+
+	// 00:00 по часам сервера в UTC+3 - это 21:00 UTC предыдущего дня
+	wall := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	assert.True(t, time.Date(2026, 3, 9, 21, 0, 0, 0, time.UTC).Equal(instant(wall)))
+}
@@ -2,12 +2,17 @@ package repository
 
 import (
 	"context"
+	"crypto/rand"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
+// ErrKeyNotFound - GetToken не нашел ключ.
+var ErrKeyNotFound = redis.Nil
+
 type RedisRepo struct {
 	Client *redis.Client
 }
@@ -34,3 +39,104 @@ func (r *RedisRepo) GetToken(ctx context.Context, key string) (string, error) {
 func (r *RedisRepo) DeleteToken(ctx context.Context, key string) error {
 	return r.Client.Del(ctx, key).Err()
 }
+
+// SetTokenNX сохраняет значение, только если ключа еще нет, и сообщает,
+// было ли оно сохранено.
+func (r *RedisRepo) SetTokenNX(ctx context.Context, key string, value any, expiration time.Duration) (bool, error) {
+	return r.Client.SetNX(ctx, key, value, expiration).Result()
+}
+
+// incrScript - INCR ключа KEYS[1] и PEXPIRE на ARGV[1] мс для нового
+// счетчика. Одним скриптом, чтобы счетчик не остался без срока, если
+// соединение оборвется между командами.
+var incrScript = redis.NewScript(`
+local n = redis.call('INCR', KEYS[1])
+if n == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return n
+`)
+
+// Incr увеличивает счетчик key на 1 и возвращает новое значение. Новый
+// счетчик живет expiration; повторные вызовы срок не продлевают.
+func (r *RedisRepo) Incr(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	return incrScript.Run(ctx, r.Client, []string{key}, expiration.Milliseconds()).Int64()
+}
+
+// rateScript - скользящее окно в sorted set KEYS[1]: каждый разрешенный
+// запрос - элемент с временем в мс. KEYS[2] - блокировка ключа после
+// превышения лимита. ARGV: now, window и lockout в мс, limit, id запроса.
+// Возвращает {1, 0}, если запрос разрешен, иначе {0, мс до повтора}.
+var rateScript = redis.NewScript(`
+local locked = redis.call('PTTL', KEYS[2])
+if locked > 0 then
+	return {0, locked}
+end
+local now, window = tonumber(ARGV[1]), tonumber(ARGV[2])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+if redis.call('ZCARD', KEYS[1]) < tonumber(ARGV[4]) then
+	redis.call('ZADD', KEYS[1], now, ARGV[5])
+	redis.call('PEXPIRE', KEYS[1], window)
+	return {1, 0}
+end
+local lockout = tonumber(ARGV[3])
+if lockout > 0 then
+	redis.call('DEL', KEYS[1])
+	redis.call('SET', KEYS[2], 1, 'PX', lockout)
+	return {0, lockout}
+end
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+return {0, tonumber(oldest[2]) + window - now}
+`)
+
+// AllowRate учитывает запрос now по ключу key и сообщает, укладывается ли
+// он в limit запросов за скользящее окно window. Если нет, retryAfter -
+// когда можно повторить. При lockout > 0 превышение лимита блокирует ключ
+// на lockout целиком. Отклоненные запросы в окно не попадают.
+func (r *RedisRepo) AllowRate(ctx context.Context, key string, now time.Time, limit int, window, lockout time.Duration) (ok bool, retryAfter time.Duration, err error) {
+	res, err := rateScript.Run(ctx, r.Client, []string{key, key + ":lock"},
+		now.UnixMilli(), window.Milliseconds(), lockout.Milliseconds(), limit,
+		strconv.FormatInt(now.UnixNano(), 36)+rand.Text()[:8]).Int64Slice()
+	if err != nil {
+		return false, 0, err
+	}
+	return res[0] == 1, time.Duration(res[1]) * time.Millisecond, nil
+}
+
+// Publish отправляет сообщение в канал Redis pub/sub.
+func (r *RedisRepo) Publish(ctx context.Context, channel string, message any) error {
+	return r.Client.Publish(ctx, channel, message).Err()
+}
+
+// Subscribe подписывается на канал Redis pub/sub. Сообщения приходят
+// в возвращенный канал; он закрывается после отмены ctx.
+func (r *RedisRepo) Subscribe(ctx context.Context, channel string) (<-chan string, error) {
+	ps := r.Client.Subscribe(ctx, channel)
+	// Ждем подтверждения, чтобы не потерять сообщения сразу после возврата
+	if _, err := ps.Receive(ctx); err != nil {
+		ps.Close()
+		return nil, err
+	}
+	out := make(chan string)
+	go func() {
+		defer close(out)
+		defer ps.Close()
+		msgs := ps.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-msgs:
+				if !ok {
+					return
+				}
+				select {
+				case out <- msg.Payload:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
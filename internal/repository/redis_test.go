@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllowRate_SlidingWindow(t *testing.T) {
+	r := NewRedisRepo(miniredis.RunT(t).Addr())
+	ctx := context.Background()
+	t0 := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	allow := func(at time.Duration) (bool, time.Duration) {
+		ok, retry, err := r.AllowRate(ctx, "rl", t0.Add(at), 2, time.Minute, 0)
+		require.NoError(t, err)
+		return ok, retry
+	}
+
+	ok, _ := allow(0)
+	assert.True(t, ok)
+	ok, _ = allow(10 * time.Second)
+	assert.True(t, ok)
+	ok, retry := allow(20 * time.Second)
+	assert.False(t, ok)
+	assert.Equal(t, 40*time.Second, retry)
+
+	// Первый запрос вышел из окна, второй еще в нем
+	ok, _ = allow(61 * time.Second)
+	assert.True(t, ok)
+	ok, retry = allow(62 * time.Second)
+	assert.False(t, ok)
+	assert.Equal(t, 8*time.Second, retry)
+}
+
+func TestAllowRate_Lockout(t *testing.T) {
+	mr := miniredis.RunT(t)
+	r := NewRedisRepo(mr.Addr())
+	ctx := context.Background()
+	now := time.Now()
+
+	for range 2 {
+		ok, _, err := r.AllowRate(ctx, "rl", now, 2, time.Minute, time.Hour)
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+	ok, retry, err := r.AllowRate(ctx, "rl", now, 2, time.Minute, time.Hour)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, time.Hour, retry)
+
+	// Блокировка держится дольше окна
+	ok, _, err = r.AllowRate(ctx, "rl", now.Add(2*time.Minute), 2, time.Minute, time.Hour)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	mr.FastForward(time.Hour)
+	ok, _, err = r.AllowRate(ctx, "rl", now.Add(time.Hour), 2, time.Minute, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestIncr_ExpiresOnlyNewCounter(t *testing.T) {
+	mr := miniredis.RunT(t)
+	r := NewRedisRepo(mr.Addr())
+	ctx := context.Background()
+
+	n, err := r.Incr(ctx, "counter", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	assert.Equal(t, time.Minute, mr.TTL("counter"))
+
+	mr.FastForward(30 * time.Second)
+	n, err = r.Incr(ctx, "counter", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	assert.Equal(t, 30*time.Second, mr.TTL("counter"))
+}
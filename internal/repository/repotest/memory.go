@@ -0,0 +1,443 @@
+// Package repotest содержит потокобезопасную in-memory реализацию
+// domain.TaskRepository для тестов обработчиков.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"task-traker/internal/domain"
+)
+
+type MemoryRepo struct {
+	mu         sync.Mutex
+	tasks      []domain.Task
+	nextID     int
+	authCodes  map[int64]authCode
+	languages  map[int64]string
+	webhooks   []domain.Webhook
+	deliveries []domain.WebhookDelivery
+	nextHookID int
+	lastDeliv  int64
+}
+
+type authCode struct {
+	code      string
+	expiresAt time.Time
+}
+
+var _ domain.TaskRepository = (*MemoryRepo)(nil)
+
+func NewMemoryRepo() *MemoryRepo {
+	return &MemoryRepo{nextID: 1, nextHookID: 1, authCodes: make(map[int64]authCode), languages: make(map[int64]string)}
+}
+
+// Tasks возвращает копию всех сохраненных задач.
+func (r *MemoryRepo) Tasks() []domain.Task {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.tasks)
+}
+
+// SetTimes задает время создания и выполнения задачи id, которые иначе
+// ставит сам репозиторий.
+func (r *MemoryRepo) SetTimes(id int, created time.Time, completed *time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.tasks {
+		if r.tasks[i].ID == id {
+			r.tasks[i].CreatedAt, r.tasks[i].CompletedAt = created, completed
+		}
+	}
+}
+
+func (r *MemoryRepo) Create(ctx context.Context, task *domain.Task) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	task.ID = r.nextID
+	r.nextID++
+	task.CreatedAt = time.Now()
+	task.Version, task.UpdatedAt = 1, task.CreatedAt
+	r.tasks = append(r.tasks, *task)
+	return nil
+}
+
+// InTx запоминает задачи и восстанавливает их, если fn вернула ошибку.
+// В отличие от транзакции в Postgres, изменения видны другим вызовам сразу.
+func (r *MemoryRepo) InTx(ctx context.Context, fn func(domain.TaskRepository) error) error {
+	r.mu.Lock()
+	tasks, nextID := slices.Clone(r.tasks), r.nextID
+	r.mu.Unlock()
+
+	err := fn(r)
+	if err != nil {
+		r.mu.Lock()
+		r.tasks, r.nextID = tasks, nextID
+		r.mu.Unlock()
+	}
+	return err
+}
+
+func (r *MemoryRepo) GetActiveTasks(ctx context.Context) ([]domain.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	var res []domain.Task
+	for _, t := range r.tasks {
+		if !t.Notified && t.CompletedAt == nil && !t.Deadline.After(now.Add(time.Duration(t.RemindBefore)*time.Minute)) {
+			res = append(res, t)
+		}
+	}
+	return res, nil
+}
+
+func (r *MemoryRepo) MarkAsNotified(ctx context.Context, taskID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.tasks {
+		if r.tasks[i].ID == taskID {
+			r.tasks[i].Notified = true
+			touch(&r.tasks[i])
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepo) GetTasksByUserID(ctx context.Context, userID int64) ([]domain.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var res []domain.Task
+	for _, t := range r.tasks {
+		if t.CompletedAt == nil && t.UserID == userID {
+			res = append(res, t)
+		}
+	}
+	slices.SortFunc(res, func(a, b domain.Task) int { return a.Deadline.Compare(b.Deadline) })
+	return res, nil
+}
+
+func (r *MemoryRepo) GetTasksByDeadline(ctx context.Context, userID int64, from, to time.Time) ([]domain.Task, error) {
+	tasks, _ := r.GetTasksByUserID(ctx, userID)
+	return slices.DeleteFunc(tasks, func(t domain.Task) bool {
+		return t.Deadline.Before(from) || !t.Deadline.Before(to)
+	}), nil
+}
+
+func (r *MemoryRepo) SearchTasks(ctx context.Context, userID int64, query string, limit int) ([]domain.Task, error) {
+	return r.ListTasks(ctx, domain.TaskFilter{UserID: userID, Query: query, Limit: limit})
+}
+
+func (r *MemoryRepo) GetUserTags(ctx context.Context, userID int64) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	count := make(map[string]int)
+	for _, t := range r.tasks {
+		if t.UserID == userID && t.CompletedAt == nil {
+			for _, tag := range t.Tags {
+				count[tag]++
+			}
+		}
+	}
+	tags := make([]string, 0, len(count))
+	for tag := range count {
+		tags = append(tags, tag)
+	}
+	slices.SortFunc(tags, func(a, b string) int {
+		if count[a] != count[b] {
+			return count[b] - count[a]
+		}
+		return strings.Compare(a, b)
+	})
+	return tags, nil
+}
+
+func (r *MemoryRepo) ListTasks(ctx context.Context, f domain.TaskFilter) ([]domain.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	words := strings.Fields(strings.ToLower(f.Query))
+	matches := func(t domain.Task) bool {
+		switch {
+		case t.UserID != f.UserID:
+			return false
+		case f.Status == domain.StatusCompleted && t.CompletedAt == nil:
+			return false
+		case f.Status != domain.StatusCompleted && f.Status != domain.StatusAll && t.CompletedAt != nil:
+			return false
+		case !f.DueAfter.IsZero() && t.Deadline.Before(f.DueAfter):
+			return false
+		case !f.DueBefore.IsZero() && !t.Deadline.Before(f.DueBefore):
+			return false
+		case f.Tag != "" && !slices.Contains(t.Tags, strings.ToLower(f.Tag)):
+			return false
+		case f.Project != "" && t.Project != f.Project:
+			return false
+		}
+		title := strings.ToLower(t.Title)
+		return !slices.ContainsFunc(words, func(w string) bool {
+			return !strings.Contains(title, w) && !slices.Contains(t.Tags, strings.TrimPrefix(w, "#"))
+		})
+	}
+	// compare упорядочивает задачи по ключу сортировки и id
+	compare := func(a domain.Task, key time.Time, id int) int {
+		c := f.Sort.Key(a).Compare(key)
+		if c == 0 {
+			c = a.ID - id
+		}
+		if f.Sort.Desc() {
+			c = -c
+		}
+		return c
+	}
+
+	var res []domain.Task
+	for _, t := range r.tasks {
+		if matches(t) && (f.After == nil || compare(t, f.After.Key, f.After.ID) > 0) {
+			res = append(res, t)
+		}
+	}
+	slices.SortFunc(res, func(a, b domain.Task) int { return compare(a, f.Sort.Key(b), b.ID) })
+	if f.Limit > 0 && len(res) > f.Limit {
+		res = res[:f.Limit]
+	}
+	return res, nil
+}
+
+func (r *MemoryRepo) GetTaskHistory(ctx context.Context, userID int64, from, to time.Time) ([]domain.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Как и в Postgres, CreatedAt и CompletedAt - моменты времени, а срок
+	// и границы - показания часов сервера с пометкой UTC
+	in := func(t time.Time) bool { return !t.Before(from) && t.Before(to) }
+	instant := func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.Local)
+	}
+	inInstants := func(t time.Time) bool { return !t.Before(instant(from)) && t.Before(instant(to)) }
+	var res []domain.Task
+	for _, t := range r.tasks {
+		if t.UserID == userID && (inInstants(t.CreatedAt) || (t.CompletedAt != nil && inInstants(*t.CompletedAt)) || in(t.Deadline)) {
+			res = append(res, t)
+		}
+	}
+	return res, nil
+}
+
+func (r *MemoryRepo) GetTaskByID(ctx context.Context, userID int64, id int) (domain.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range r.tasks {
+		if t.ID == id && t.UserID == userID {
+			return t, nil
+		}
+	}
+	return domain.Task{}, domain.ErrTaskNotFound
+}
+
+func (r *MemoryRepo) Update(ctx context.Context, task *domain.Task) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, t := range r.tasks {
+		if t.ID == task.ID && t.UserID == task.UserID {
+			if t.Version != task.Version {
+				return domain.ErrVersionConflict
+			}
+			task.Notified = t.Notified && t.Deadline.Equal(task.Deadline) && t.RemindBefore == task.RemindBefore
+			task.CreatedAt = t.CreatedAt
+			task.CompletedAt = t.CompletedAt
+			touch(task)
+			r.tasks[i] = *task
+			return nil
+		}
+	}
+	return domain.ErrTaskNotFound
+}
+
+// touch отмечает изменение задачи, как UPDATE в Postgres.
+func touch(t *domain.Task) {
+	t.Version++
+	t.UpdatedAt = time.Now()
+}
+
+func (r *MemoryRepo) Complete(ctx context.Context, userID int64, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, t := range r.tasks {
+		if t.ID == id && t.UserID == userID && t.CompletedAt == nil {
+			now := time.Now()
+			r.tasks[i].CompletedAt = &now
+			touch(&r.tasks[i])
+			return nil
+		}
+	}
+	return domain.ErrTaskNotFound
+}
+
+func (r *MemoryRepo) Delete(ctx context.Context, userID int64, id, version int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := slices.IndexFunc(r.tasks, func(t domain.Task) bool { return t.ID == id && t.UserID == userID })
+	if i < 0 {
+		return domain.ErrTaskNotFound
+	}
+	if version != 0 && r.tasks[i].Version != version {
+		return domain.ErrVersionConflict
+	}
+	r.tasks = slices.Delete(r.tasks, i, i+1)
+	return nil
+}
+
+func (r *MemoryRepo) SaveAuthCode(ctx context.Context, userID int64, code string, expiry time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.authCodes[userID] = authCode{code: code, expiresAt: expiry}
+	return nil
+}
+
+func (r *MemoryRepo) VerifyAuthCode(ctx context.Context, userID int64, code string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	saved, ok := r.authCodes[userID]
+	if !ok {
+		return false, errors.New("no rows in result set")
+	}
+	if saved.code != code || time.Now().After(saved.expiresAt) {
+		return false, nil
+	}
+	delete(r.authCodes, userID)
+	return true, nil
+}
+
+func (r *MemoryRepo) GetLanguage(ctx context.Context, userID int64) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.languages[userID], nil
+}
+
+func (r *MemoryRepo) SetLanguage(ctx context.Context, userID int64, lang string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.languages[userID] = lang
+	return nil
+}
+
+// Deliveries возвращает копию всех доставок вебхуков.
+func (r *MemoryRepo) Deliveries() []domain.WebhookDelivery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.deliveries)
+}
+
+func (r *MemoryRepo) CreateWebhook(ctx context.Context, hook *domain.Webhook) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	hook.ID = r.nextHookID
+	r.nextHookID++
+	hook.CreatedAt = time.Now()
+	r.webhooks = append(r.webhooks, *hook)
+	return nil
+}
+
+func (r *MemoryRepo) ListWebhooks(ctx context.Context, userID int64) ([]domain.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var res []domain.Webhook
+	for _, h := range r.webhooks {
+		if h.UserID == userID {
+			res = append(res, h)
+		}
+	}
+	return res, nil
+}
+
+func (r *MemoryRepo) GetWebhook(ctx context.Context, userID int64, id int) (domain.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, h := range r.webhooks {
+		if h.ID == id && h.UserID == userID {
+			return h, nil
+		}
+	}
+	return domain.Webhook{}, domain.ErrWebhookNotFound
+}
+
+func (r *MemoryRepo) DeleteWebhook(ctx context.Context, userID int64, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := len(r.webhooks)
+	r.webhooks = slices.DeleteFunc(r.webhooks, func(h domain.Webhook) bool { return h.ID == id && h.UserID == userID })
+	if len(r.webhooks) == n {
+		return domain.ErrWebhookNotFound
+	}
+	r.deliveries = slices.DeleteFunc(r.deliveries, func(d domain.WebhookDelivery) bool { return d.WebhookID == id })
+	return nil
+}
+
+func (r *MemoryRepo) EnqueueWebhookEvent(ctx context.Context, userID int64, event string, payload []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, h := range r.webhooks {
+		if h.UserID == userID && (len(h.Events) == 0 || slices.Contains(h.Events, event)) {
+			r.addDelivery(&domain.WebhookDelivery{
+				WebhookID: h.ID, UserID: userID, Event: event, Payload: payload,
+				Status: domain.DeliveryPending, NextAttemptAt: time.Now(),
+			})
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *MemoryRepo) CreateWebhookDelivery(ctx context.Context, d *domain.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.addDelivery(d)
+	return nil
+}
+
+// addDelivery сохраняет доставку; вызывается под r.mu.
+func (r *MemoryRepo) addDelivery(d *domain.WebhookDelivery) {
+	r.lastDeliv++
+	d.ID = r.lastDeliv
+	d.CreatedAt = time.Now()
+	r.deliveries = append(r.deliveries, *d)
+}
+
+func (r *MemoryRepo) ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var res []domain.WebhookDelivery
+	for i, d := range r.deliveries {
+		if len(res) < limit && d.Status == domain.DeliveryPending && !d.NextAttemptAt.After(now) {
+			r.deliveries[i].NextAttemptAt = now.Add(lease)
+			res = append(res, r.deliveries[i])
+		}
+	}
+	return res, nil
+}
+
+func (r *MemoryRepo) UpdateWebhookDelivery(ctx context.Context, d *domain.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.deliveries {
+		if r.deliveries[i].ID == d.ID {
+			r.deliveries[i] = *d
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepo) ListWebhookDeliveries(ctx context.Context, userID int64, webhookID int, limit int) ([]domain.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var res []domain.WebhookDelivery
+	for i := len(r.deliveries) - 1; i >= 0 && len(res) < limit; i-- {
+		if d := r.deliveries[i]; d.WebhookID == webhookID && d.UserID == userID {
+			res = append(res, d)
+		}
+	}
+	return res, nil
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"crypto/subtle"
 	"errors"
 	"fmt"
 	"math/rand"
@@ -14,6 +15,14 @@ import (
 
 var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
 
+const (
+	// otpTTL - сколько действует код входа из бота.
+	otpTTL = 5 * time.Minute
+	// MaxLoginAttempts - сколько неверных кодов можно ввести, прежде чем
+	// код входа перестанет действовать и понадобится новый.
+	MaxLoginAttempts = 5
+)
+
 type TokenPair struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
@@ -51,16 +60,36 @@ func (s *TaskService) GenerateAuthCode(ctx context.Context, userID int64) (strin
 
 	key := fmt.Sprintf("otp:%d", userID)
 
-	err := s.Redis.SetToken(ctx, key, code, time.Minute*5)
+	err := s.Redis.SetToken(ctx, key, code, otpTTL)
+	if err != nil {
+		return "", err
+	}
+	// У нового кода свой счет неверных попыток
+	err = s.Redis.DeleteToken(ctx, fmt.Sprintf("otp_attempts:%d", userID))
 
 	return code, err
 }
 
+// Login обменивает код входа из бота на пару токенов. Попытка
+// учитывается до сравнения кода: параллельные запросы не проверят больше
+// MaxLoginAttempts кодов, даже если придут одновременно.
 func (s *TaskService) Login(ctx context.Context, userID int64, code string) (*TokenPair, error) {
 	key := fmt.Sprintf("otp:%d", userID)
+	attemptsKey := fmt.Sprintf("otp_attempts:%d", userID)
+	attempt, err := s.Redis.Incr(ctx, attemptsKey, otpTTL)
+	if err != nil {
+		return nil, err
+	}
+	if attempt > MaxLoginAttempts {
+		// Код уже удалила попытка MaxLoginAttempts
+		return nil, errTooManyAttempts()
+	}
 	savedCode, err := s.Redis.GetToken(ctx, key)
-	if err != nil || savedCode != code {
-		return nil, errors.New("invalid or expired code")
+	if err != nil {
+		return nil, errorf(ErrUnauthorized, "неверный или просроченный код")
+	}
+	if subtle.ConstantTimeCompare([]byte(savedCode), []byte(code)) != 1 {
+		return nil, s.loginFailed(ctx, userID, attempt)
 	}
 
 	accessToken, err := s.GenerateToken(userID, time.Minute*15)
@@ -76,10 +105,29 @@ func (s *TaskService) Login(ctx context.Context, userID int64, code string) (*To
 	}
 
 	s.Redis.DeleteToken(ctx, key)
+	s.Redis.DeleteToken(ctx, attemptsKey)
 
 	return &TokenPair{AccessToken: accessToken, RefreshToken: refreshToken}, nil
 }
 
+// loginFailed отвечает на неверный код, введенный попыткой attempt.
+// После MaxLoginAttempts неверных кодов код входа удаляется: перебрать
+// миллион вариантов за несколько попыток нельзя, а новый код выдаст
+// только бот. Счетчик попыток остается до нового кода, так что
+// опоздавшие параллельные запросы тоже отклоняются.
+func (s *TaskService) loginFailed(ctx context.Context, userID int64, attempt int64) error {
+	if attempt < MaxLoginAttempts {
+		return errorf(ErrUnauthorized, "неверный или просроченный код")
+	}
+	s.Redis.DeleteToken(ctx, fmt.Sprintf("otp:%d", userID))
+	return errTooManyAttempts()
+}
+
+// errTooManyAttempts - код входа исчерпал MaxLoginAttempts попыток.
+func errTooManyAttempts() error {
+	return errorf(ErrUnauthorized, "слишком много неверных попыток, получите новый код командой /login")
+}
+
 func (s *TaskService) Logout(ctx context.Context, accessToken string, refreshToken string) error {
 	// Удаляем Refresh токен из Redis (он больше не валиден)
 	refreshKey := fmt.Sprintf("refresh:%s", refreshToken)
@@ -96,7 +144,7 @@ func (s *TaskService) Refresh(ctx context.Context, oldRefreshToken string) (*Tok
 	// 1. Ищем Refresh токен в Redis
 	val, err := s.Redis.GetToken(ctx, refreshKey)
 	if err != nil {
-		return nil, errors.New("refresh token expired or invalid")
+		return nil, errorf(ErrUnauthorized, "refresh-токен недействителен или истек")
 	}
 
 	// 2. Достаем userID из значения
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"task-traker/internal/domain"
+	"task-traker/internal/events"
+)
+
+// MaxBatchSize - наибольшее число операций в одном пакете.
+const MaxBatchSize = 100
+
+// BatchMode - что делать, если операция пакета не удалась.
+type BatchMode string
+
+const (
+	// BatchAtomic - все или ничего: ошибка одной операции откатывает пакет.
+	BatchAtomic BatchMode = "atomic"
+	// BatchBestEffort - неудачная операция откатывается, остальные сохраняются.
+	BatchBestEffort BatchMode = "best_effort"
+)
+
+type BatchOpType string
+
+const (
+	BatchCreate   BatchOpType = "create"
+	BatchUpdate   BatchOpType = "update"
+	BatchDelete   BatchOpType = "delete"
+	BatchComplete BatchOpType = "complete"
+)
+
+// BatchOp - операция пакета. ID нужен всем операциям, кроме create.
+// В update меняются только переданные поля; Deadline - в формате
+// ДД.ММ.ГГГГ ЧЧ:ММ, как в ParseDeadline.
+type BatchOp struct {
+	Op       BatchOpType      `json:"op"`
+	ID       int              `json:"id,omitempty"`
+	Title    *string          `json:"title,omitempty"`
+	Deadline *string          `json:"deadline,omitempty"`
+	Project  *string          `json:"project,omitempty"`
+	Priority *domain.Priority `json:"priority,omitempty"`
+}
+
+type BatchStatus string
+
+const (
+	BatchOK     BatchStatus = "ok"
+	BatchFailed BatchStatus = "failed"
+	// BatchRolledBack - операция выполнилась, но пакет в режиме atomic откатился.
+	BatchRolledBack BatchStatus = "rolled_back"
+	// BatchSkipped - операция не выполнялась: пакет в режиме atomic уже
+	// откатился из-за более ранней.
+	BatchSkipped BatchStatus = "skipped"
+)
+
+// BatchResult - итог одной операции. ID - id задачи, в том числе созданной.
+type BatchResult struct {
+	Status BatchStatus
+	ID     int
+	// Err - причина статуса BatchFailed.
+	Err error
+}
+
+// errBatchAborted откатывает транзакцию пакета в режиме atomic.
+var errBatchAborted = errors.New("batch aborted")
+
+// Batch выполняет операции над задачами пользователя в одной транзакции
+// и возвращает итог каждой в том же порядке. Ошибки операций (неверные
+// поля, ненайденная задача, конфликт) попадают в результаты, а ошибка
+// Batch означает, что пакет не принят целиком: неверный запрос или сбой
+// базы в любой из операций, даже в режиме best_effort.
+func (t TaskService) Batch(ctx context.Context, userID int64, mode BatchMode, ops []BatchOp) ([]BatchResult, error) {
+	var v ValidationError
+	if mode != BatchAtomic && mode != BatchBestEffort {
+		v.addField("mode", "неизвестный режим %q", mode)
+	}
+	if len(ops) == 0 || len(ops) > MaxBatchSize {
+		v.addField("operations", "в пакете должно быть от 1 до %d операций", MaxBatchSize)
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	results := make([]BatchResult, len(ops))
+	tasks := make([]domain.Task, len(ops))
+	err := t.Repo.InTx(ctx, func(repo domain.TaskRepository) error {
+		for i, op := range ops {
+			var task domain.Task
+			var err error
+			if mode == BatchBestEffort {
+				// Точка сохранения: неудачная операция не прерывает транзакцию
+				err = repo.InTx(ctx, func(repo domain.TaskRepository) error {
+					task, err = t.batchOp(ctx, repo, userID, op)
+					return err
+				})
+			} else {
+				task, err = t.batchOp(ctx, repo, userID, op)
+			}
+			if err != nil && !isBatchOpError(err) {
+				return err
+			}
+			if err != nil {
+				results[i] = BatchResult{Status: BatchFailed, ID: op.ID, Err: err}
+				if mode == BatchAtomic {
+					return errBatchAborted
+				}
+				continue
+			}
+			results[i] = BatchResult{Status: BatchOK, ID: task.ID}
+			tasks[i] = task
+		}
+		return nil
+	})
+	if errors.Is(err, errBatchAborted) {
+		for i := range results {
+			switch results[i].Status {
+			case BatchOK:
+				results[i].Status = BatchRolledBack
+				if ops[i].Op == BatchCreate {
+					results[i].ID = 0
+				}
+			case "":
+				results[i] = BatchResult{Status: BatchSkipped, ID: ops[i].ID}
+			}
+		}
+		return results, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// События - только после фиксации транзакции
+	for i, op := range ops {
+		if results[i].Status != BatchOK {
+			continue
+		}
+		switch op.Op {
+		case BatchCreate:
+			t.publish(ctx, events.TaskCreated, userID, tasks[i].ID, &tasks[i])
+		case BatchUpdate:
+			t.publish(ctx, events.TaskUpdated, userID, tasks[i].ID, &tasks[i])
+		case BatchComplete:
+			t.publish(ctx, events.TaskCompleted, userID, tasks[i].ID, nil)
+		case BatchDelete:
+			t.publish(ctx, events.TaskDeleted, userID, tasks[i].ID, nil)
+		}
+	}
+	return results, nil
+}
+
+// isBatchOpError сообщает, что err - ошибка самой операции, а не сбой:
+// клиент может исправить операцию, а повторять пакет без изменений незачем.
+func isBatchOpError(err error) bool {
+	var verr *ValidationError
+	return errors.As(err, &verr) ||
+		errors.Is(err, domain.ErrTaskNotFound) ||
+		errors.Is(err, domain.ErrVersionConflict) ||
+		errors.Is(err, ErrNotFound) ||
+		errors.Is(err, ErrConflict)
+}
+
+// batchOp выполняет одну операцию пакета через repo и возвращает задачу
+// после нее; для delete и complete в задаче заполнен только ID.
+// События не публикуются: транзакция еще может откатиться.
+func (t TaskService) batchOp(ctx context.Context, repo domain.TaskRepository, userID int64, op BatchOp) (domain.Task, error) {
+	s := TaskService{Repo: repo, Redis: t.Redis}
+	switch op.Op {
+	case BatchCreate:
+		task := domain.Task{UserID: userID, RemindBefore: domain.DefaultRemindBefore}
+		if err := applyBatchOp(&task, op, true); err != nil {
+			return domain.Task{}, err
+		}
+		err := s.AddTask(ctx, &task)
+		return task, err
+	case BatchUpdate:
+		task, err := repo.GetTaskByID(ctx, userID, op.ID)
+		if err != nil {
+			return domain.Task{ID: op.ID}, err
+		}
+		if err := applyBatchOp(&task, op, false); err != nil {
+			return task, err
+		}
+		return task, s.UpdateTask(ctx, &task)
+	case BatchDelete:
+		return domain.Task{ID: op.ID}, repo.Delete(ctx, userID, op.ID, 0)
+	case BatchComplete:
+		return domain.Task{ID: op.ID}, repo.Complete(ctx, userID, op.ID)
+	default:
+		return domain.Task{ID: op.ID}, invalid("op", "неизвестная операция %q", op.Op)
+	}
+}
+
+// applyBatchOp переносит поля операции в задачу. Для create срок обязателен.
+func applyBatchOp(task *domain.Task, op BatchOp, create bool) error {
+	if create && op.Deadline == nil {
+		return invalid("deadline", "не указан срок задачи")
+	}
+	return TaskPatch{Title: op.Title, Deadline: op.Deadline, Project: op.Project, Priority: op.Priority}.apply(task)
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"task-traker/internal/domain"
+	"task-traker/internal/events"
+	"task-traker/internal/repository/repotest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+func batchFixture(t *testing.T) (TaskService, *repotest.MemoryRepo, domain.Task) {
+	t.Helper()
+	repo := repotest.NewMemoryRepo()
+	existing := domain.Task{UserID: 1, Title: "Купить хлеб", Deadline: time.Now().Add(time.Hour)}
+	require.NoError(t, repo.Create(context.Background(), &existing))
+	return TaskService{Repo: repo}, repo, existing
+}
+
+func TestBatch_Atomic(t *testing.T) {
+	s, repo, existing := batchFixture(t)
+	deadline := time.Now().Add(2 * time.Hour).Format(TIME_FORMAT)
+
+	results, err := s.Batch(context.Background(), 1, BatchAtomic, []BatchOp{
+		{Op: BatchCreate, Title: ptr("Позвонить маме"), Deadline: &deadline},
+		{Op: BatchUpdate, ID: existing.ID, Title: ptr("Купить батон"), Priority: ptr(domain.PriorityHigh)},
+		{Op: BatchComplete, ID: existing.ID},
+	})
+	require.NoError(t, err)
+	for _, r := range results {
+		assert.Equal(t, BatchOK, r.Status)
+	}
+	assert.Equal(t, existing.ID, results[1].ID)
+
+	tasks := repo.Tasks()
+	require.Len(t, tasks, 2)
+	assert.Equal(t, "Купить батон", tasks[0].Title)
+	assert.Equal(t, domain.PriorityHigh, tasks[0].Priority)
+	assert.NotNil(t, tasks[0].CompletedAt)
+	assert.Equal(t, results[0].ID, tasks[1].ID)
+	assert.Equal(t, "Позвонить маме", tasks[1].Title)
+}
+
+func TestBatch_AtomicRollsBack(t *testing.T) {
+	s, repo, existing := batchFixture(t)
+	deadline := time.Now().Add(2 * time.Hour).Format(TIME_FORMAT)
+
+	results, err := s.Batch(context.Background(), 1, BatchAtomic, []BatchOp{
+		{Op: BatchCreate, Title: ptr("Позвонить маме"), Deadline: &deadline},
+		{Op: BatchDelete, ID: existing.ID},
+		{Op: BatchComplete, ID: 999},
+		{Op: BatchCreate, Title: ptr("Оплатить счет"), Deadline: &deadline},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []BatchStatus{BatchRolledBack, BatchRolledBack, BatchFailed, BatchSkipped},
+		[]BatchStatus{results[0].Status, results[1].Status, results[2].Status, results[3].Status})
+	assert.Zero(t, results[0].ID)
+	assert.ErrorIs(t, results[2].Err, domain.ErrTaskNotFound)
+	assert.Equal(t, []domain.Task{existing}, repo.Tasks())
+}
+
+func TestBatch_BestEffort(t *testing.T) {
+	s, repo, existing := batchFixture(t)
+	deadline := time.Now().Add(2 * time.Hour).Format(TIME_FORMAT)
+
+	results, err := s.Batch(context.Background(), 1, BatchBestEffort, []BatchOp{
+		{Op: BatchCreate, Title: ptr(" "), Deadline: &deadline},
+		{Op: BatchCreate, Title: ptr("Позвонить маме"), Deadline: &deadline},
+		{Op: "archive", ID: existing.ID},
+		{Op: BatchDelete, ID: existing.ID},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, BatchFailed, results[0].Status)
+	var verr *ValidationError
+	require.ErrorAs(t, results[0].Err, &verr)
+	assert.Equal(t, "title", verr.Fields[0].Field)
+	assert.Equal(t, BatchOK, results[1].Status)
+	assert.Equal(t, BatchFailed, results[2].Status)
+	assert.Equal(t, BatchOK, results[3].Status)
+
+	tasks := repo.Tasks()
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "Позвонить маме", tasks[0].Title)
+}
+
+func TestBatch_OtherUsersTasks(t *testing.T) {
+	s, repo, existing := batchFixture(t)
+
+	results, err := s.Batch(context.Background(), 2, BatchBestEffort, []BatchOp{
+		{Op: BatchUpdate, ID: existing.ID, Title: ptr("Чужая")},
+		{Op: BatchDelete, ID: existing.ID},
+	})
+	require.NoError(t, err)
+	for _, r := range results {
+		assert.Equal(t, BatchFailed, r.Status)
+		assert.ErrorIs(t, r.Err, domain.ErrTaskNotFound)
+	}
+	assert.Equal(t, []domain.Task{existing}, repo.Tasks())
+}
+
+func TestBatch_Validation(t *testing.T) {
+	s, _, _ := batchFixture(t)
+
+	_, err := s.Batch(context.Background(), 1, "sometimes", nil)
+
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.Len(t, verr.Fields, 2)
+
+	_, err = s.Batch(context.Background(), 1, BatchAtomic, make([]BatchOp, MaxBatchSize+1))
+	assert.ErrorAs(t, err, &verr)
+}
+
+// brokenRepo - репозиторий, в котором Complete всегда падает, как при
+// сбое базы. Транзакции тоже отдают brokenRepo.
+type brokenRepo struct {
+	domain.TaskRepository
+}
+
+var errDBDown = errors.New("connection refused")
+
+func (r brokenRepo) Complete(context.Context, int64, int) error { return errDBDown }
+
+func (r brokenRepo) InTx(ctx context.Context, fn func(domain.TaskRepository) error) error {
+	return r.TaskRepository.InTx(ctx, func(repo domain.TaskRepository) error {
+		return fn(brokenRepo{repo})
+	})
+}
+
+func TestBatch_DatabaseErrorFailsBatch(t *testing.T) {
+	for _, mode := range []BatchMode{BatchAtomic, BatchBestEffort} {
+		t.Run(string(mode), func(t *testing.T) {
+			s, repo, existing := batchFixture(t)
+			s.Repo = brokenRepo{repo}
+			deadline := time.Now().Add(2 * time.Hour).Format(TIME_FORMAT)
+
+			// Сбой базы - не ошибка операции: пакет не принят целиком
+			results, err := s.Batch(context.Background(), 1, mode, []BatchOp{
+				{Op: BatchCreate, Title: ptr("Позвонить маме"), Deadline: &deadline},
+				{Op: BatchComplete, ID: existing.ID},
+			})
+
+			assert.ErrorIs(t, err, errDBDown)
+			assert.Nil(t, results)
+			assert.Equal(t, []domain.Task{existing}, repo.Tasks())
+		})
+	}
+}
+
+func TestBatch_PublishesOnlyCommitted(t *testing.T) {
+	s, _, existing := batchFixture(t)
+	s.Events = events.NewBus(nil)
+	sub := s.Events.Subscribe(1)
+	defer sub.Close()
+	deadline := time.Now().Add(2 * time.Hour).Format(TIME_FORMAT)
+
+	_, err := s.Batch(context.Background(), 1, BatchAtomic, []BatchOp{
+		{Op: BatchCreate, Title: ptr("Позвонить маме"), Deadline: &deadline},
+		{Op: BatchComplete, ID: 999},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, sub.C)
+
+	_, err = s.Batch(context.Background(), 1, BatchBestEffort, []BatchOp{
+		{Op: BatchComplete, ID: 999},
+		{Op: BatchComplete, ID: existing.ID},
+	})
+	require.NoError(t, err)
+	require.Len(t, sub.C, 1)
+	e := <-sub.C
+	assert.Equal(t, events.TaskCompleted, e.Type)
+	assert.Equal(t, existing.ID, e.TaskID)
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"task-traker/internal/i18n"
+)
+
+// Виды ошибок сервиса, по которым API выбирает HTTP-статус. Проверяются
+// через errors.Is; текст для пользователя берется из переводимой ошибки
+// в той же цепочке (i18n.Lang.Error). Ненайденная задача -
+// domain.ErrTaskNotFound, ошибки входных данных - *ValidationError.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrUnauthorized = errors.New("unauthorized")
+)
+
+// kindError - ошибка вида kind с переводимым текстом.
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string   { return e.err.Error() }
+func (e *kindError) Unwrap() []error { return []error{e.kind, e.err} }
+
+// errorf создает ошибку вида kind (ErrNotFound, ErrConflict, ErrUnauthorized)
+// с переводимым текстом, как i18n.Errorf.
+func errorf(kind error, msg string, args ...any) error {
+	return &kindError{kind: kind, err: i18n.Errorf(msg, args...)}
+}
+
+// FieldError - ошибка значения одного поля.
+type FieldError struct {
+	Field string
+	// Err - переводимая ошибка (i18n.Errorf).
+	Err error
+}
+
+// ValidationError - входные данные не прошли проверку. Содержит ошибки
+// всех неверных полей, а не только первого.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Fields))
+	for i, f := range e.Fields {
+		errs[i] = f.Err
+	}
+	return errs
+}
+
+// addField добавляет ошибку поля field с переводимым текстом msg.
+func (e *ValidationError) addField(field, msg string, args ...any) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Err: i18n.Errorf(msg, args...)})
+}
+
+// err возвращает e или nil, если ошибок нет.
+func (e *ValidationError) err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// invalid - ошибка проверки одного поля.
+func invalid(field, msg string, args ...any) error {
+	e := &ValidationError{}
+	e.addField(field, msg, args...)
+	return e
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"task-traker/internal/domain"
+)
+
+const (
+	// DefaultListLimit и MaxListLimit - размер страницы списка задач
+	// по умолчанию и наибольший.
+	DefaultListLimit = 50
+	MaxListLimit     = 100
+)
+
+// TaskPage - страница списка задач. NextCursor пуст на последней странице.
+type TaskPage struct {
+	Tasks      []domain.Task `json:"tasks"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+// ListTasks возвращает страницу задач по фильтру. cursor - значение
+// NextCursor предыдущей страницы или "" для первой; он действителен только
+// для той же сортировки.
+func (t TaskService) ListTasks(ctx context.Context, filter domain.TaskFilter, cursor string) (TaskPage, error) {
+	switch filter.Status {
+	case "":
+		filter.Status = domain.StatusActive
+	case domain.StatusActive, domain.StatusCompleted, domain.StatusAll:
+	default:
+		return TaskPage{}, invalid("status", "неизвестный статус %q", filter.Status)
+	}
+	switch filter.Sort {
+	case "":
+		filter.Sort = domain.SortDeadline
+	case domain.SortDeadline, domain.SortDeadlineDesc, domain.SortCreated, domain.SortCreatedDesc:
+	default:
+		return TaskPage{}, invalid("sort", "неизвестная сортировка %q", filter.Sort)
+	}
+	limit := filter.Limit
+	if limit == 0 {
+		limit = DefaultListLimit
+	}
+	if limit < 0 || limit > MaxListLimit {
+		return TaskPage{}, invalid("limit", "размер страницы должен быть от 1 до %d", MaxListLimit)
+	}
+	if cursor != "" {
+		after, err := decodeCursor(cursor, filter.Sort)
+		if err != nil {
+			return TaskPage{}, err
+		}
+		filter.After = &after
+	}
+
+	// Лишняя задача показывает, что есть следующая страница
+	filter.Limit = limit + 1
+	tasks, err := t.Repo.ListTasks(ctx, filter)
+	if err != nil {
+		return TaskPage{}, err
+	}
+	page := TaskPage{Tasks: tasks}
+	if len(tasks) > limit {
+		page.Tasks = tasks[:limit]
+		page.NextCursor = encodeCursor(filter.Sort, page.Tasks[limit-1])
+	}
+	if page.Tasks == nil {
+		page.Tasks = []domain.Task{}
+	}
+	return page, nil
+}
+
+// cursorData - содержимое курсора. Сортировка сохраняется, чтобы курсор
+// от одного порядка не применили к другому.
+type cursorData struct {
+	Sort domain.TaskSort   `json:"s"`
+	Key  domain.TaskCursor `json:"c"`
+}
+
+func encodeCursor(sort domain.TaskSort, last domain.Task) string {
+	data, _ := json.Marshal(cursorData{Sort: sort, Key: domain.TaskCursor{Key: sort.Key(last), ID: last.ID}})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(cursor string, sort domain.TaskSort) (domain.TaskCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	var data cursorData
+	if err == nil {
+		err = json.Unmarshal(raw, &data)
+	}
+	if err != nil || data.Key.ID == 0 {
+		return domain.TaskCursor{}, invalid("cursor", "некорректный курсор")
+	}
+	if data.Sort != sort {
+		return domain.TaskCursor{}, invalid("cursor", "курсор получен для другой сортировки")
+	}
+	return data.Key, nil
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"task-traker/internal/domain"
+	"task-traker/internal/i18n"
+	"task-traker/internal/repository/repotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskService_ListTasksPagination(t *testing.T) {
+	repo := repotest.NewMemoryRepo()
+	s := TaskService{Repo: repo}
+	ctx := context.Background()
+	deadline := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	// У задач 2 и 3 одинаковый срок: порядок между ними задает id
+	for i, d := range []int{0, 1, 1, 2, 3} {
+		repo.Create(ctx, &domain.Task{UserID: 1, Title: "Задача", Deadline: deadline.AddDate(0, 0, d), Project: []string{"work", "home"}[i%2]})
+	}
+	repo.Create(ctx, &domain.Task{UserID: 2, Title: "Чужая", Deadline: deadline})
+
+	ids := func(tasks []domain.Task) []int {
+		var res []int
+		for _, t := range tasks {
+			res = append(res, t.ID)
+		}
+		return res
+	}
+	var got []int
+	cursor := ""
+	for range 3 {
+		page, err := s.ListTasks(ctx, domain.TaskFilter{UserID: 1, Limit: 2}, cursor)
+		require.NoError(t, err)
+		got = append(got, ids(page.Tasks)...)
+		cursor = page.NextCursor
+		if cursor == "" {
+			break
+		}
+	}
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, got)
+	assert.Empty(t, cursor)
+
+	page, err := s.ListTasks(ctx, domain.TaskFilter{UserID: 1, Sort: domain.SortDeadlineDesc, Limit: 2}, "")
+	require.NoError(t, err)
+	assert.Equal(t, []int{5, 4}, ids(page.Tasks))
+	page, err = s.ListTasks(ctx, domain.TaskFilter{UserID: 1, Sort: domain.SortDeadlineDesc, Limit: 2}, page.NextCursor)
+	require.NoError(t, err)
+	assert.Equal(t, []int{3, 2}, ids(page.Tasks))
+
+	page, err = s.ListTasks(ctx, domain.TaskFilter{UserID: 1, Project: "home", DueAfter: deadline.AddDate(0, 0, 1)}, "")
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 4}, ids(page.Tasks))
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestTaskService_ListTasksValidation(t *testing.T) {
+	s := TaskService{Repo: repotest.NewMemoryRepo()}
+	ctx := context.Background()
+
+	page, err := s.ListTasks(ctx, domain.TaskFilter{UserID: 1}, "")
+	require.NoError(t, err)
+	assert.NotNil(t, page.Tasks, "пустой список должен кодироваться в JSON как []")
+
+	tests := []struct {
+		name   string
+		filter domain.TaskFilter
+		cursor string
+		want   string
+	}{
+		{"Status", domain.TaskFilter{Status: "done"}, "", `unknown status "done"`},
+		{"Sort", domain.TaskFilter{Sort: "title"}, "", `unknown sort order "title"`},
+		{"Limit", domain.TaskFilter{Limit: MaxListLimit + 1}, "", "the page size must be between 1 and 100"},
+		{"Cursor", domain.TaskFilter{}, "garbage", "invalid cursor"},
+		{"Cursor for other sort", domain.TaskFilter{Sort: domain.SortCreated},
+			encodeCursor(domain.SortDeadline, domain.Task{ID: 1}), "the cursor belongs to a different sort order"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.ListTasks(ctx, tt.filter, tt.cursor)
+			msg, ok := i18n.EN.Error(err)
+			require.True(t, ok, "ошибка должна быть переводимой: %v", err)
+			assert.Equal(t, tt.want, msg)
+		})
+	}
+}
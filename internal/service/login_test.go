@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"task-traker/internal/repository"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/alicebob/miniredis/v2/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogin_InvalidatesCodeAfterFailedAttempts(t *testing.T) {
+	mr := miniredis.RunT(t)
+	s := &TaskService{Redis: repository.NewRedisRepo(mr.Addr())}
+	ctx := context.Background()
+	const userID = 42
+
+	code, err := s.GenerateAuthCode(ctx, userID)
+	require.NoError(t, err)
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+
+	for range MaxLoginAttempts - 1 {
+		_, err = s.Login(ctx, userID, wrong)
+		assert.ErrorIs(t, err, ErrUnauthorized)
+		assert.True(t, mr.Exists("otp:42"))
+	}
+	_, err = s.Login(ctx, userID, wrong)
+	require.ErrorIs(t, err, ErrUnauthorized)
+	assert.Contains(t, err.Error(), "слишком много неверных попыток")
+	assert.False(t, mr.Exists("otp:42"))
+
+	// Старый код больше не подходит, даже верный
+	_, err = s.Login(ctx, userID, code)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	// Новый код сбрасывает счетчик
+	code, err = s.GenerateAuthCode(ctx, userID)
+	require.NoError(t, err)
+	_, err = s.Login(ctx, userID, wrong)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	tokens, err := s.Login(ctx, userID, code)
+	require.NoError(t, err)
+	assert.NotEmpty(t, tokens.AccessToken)
+	assert.False(t, mr.Exists("otp_attempts:42"))
+}
+
+func TestLogin_ParallelGuessesAreLimited(t *testing.T) {
+	mr := miniredis.RunT(t)
+	s := &TaskService{Redis: repository.NewRedisRepo(mr.Addr())}
+	ctx := context.Background()
+	const userID = 42
+
+	code, err := s.GenerateAuthCode(ctx, userID)
+	require.NoError(t, err)
+
+	// Чтение кода медленное, чтобы параллельные запросы пересекались
+	var reads atomic.Int32
+	mr.Server().SetPreHook(func(_ *server.Peer, cmd string, args ...string) bool {
+		if cmd == "GET" && len(args) == 1 && args[0] == "otp:42" {
+			reads.Add(1)
+			time.Sleep(20 * time.Millisecond)
+		}
+		return false
+	})
+
+	// Перебор параллельными запросами: кодов сравнивается не больше
+	// MaxLoginAttempts, остальные попытки отклоняются до сравнения
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Go(func() {
+			_, err := s.Login(ctx, userID, wrong)
+			assert.ErrorIs(t, err, ErrUnauthorized)
+		})
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, int(reads.Load()), MaxLoginAttempts)
+	assert.False(t, mr.Exists("otp:42"))
+	_, err = s.Login(ctx, userID, code)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"task-traker/internal/domain"
+	"time"
+)
+
+// ErrNoDeadline - в тексте быстрой задачи не нашлось даты или времени.
+var ErrNoDeadline = errors.New("в сообщении не найдены дата или время")
+
+// defaultQuickHour - время по умолчанию, если в быстрой задаче указана только дата.
+const defaultQuickHour = 9
+
+var (
+	clockRe = regexp.MustCompile(`^([01]?\d|2[0-3]):([0-5]\d)$`)
+	dateRe  = regexp.MustCompile(`^(\d{1,2})\.(\d{1,2})(?:\.(\d{4}))?$`)
+)
+
+var relativeDays = map[string]int{
+	"today":       0,
+	"сегодня":     0,
+	"tomorrow":    1,
+	"завтра":      1,
+	"послезавтра": 2,
+}
+
+var priorities = map[string]domain.Priority{
+	"!high":    domain.PriorityHigh,
+	"!h":       domain.PriorityHigh,
+	"!высокий": domain.PriorityHigh,
+	"!важно":   domain.PriorityHigh,
+	"!normal":  domain.PriorityNormal,
+	"!обычный": domain.PriorityNormal,
+	"!low":     domain.PriorityLow,
+	"!l":       domain.PriorityLow,
+	"!низкий":  domain.PriorityLow,
+	"!неважно": domain.PriorityLow,
+}
+
+// ParseQuickTask разбирает задачу из одной строки, например
+// "Купить молоко завтра 18:00 #дом !high": дата (сегодня/завтра/ДД.ММ[.ГГГГ]),
+// время ЧЧ:ММ, теги #тег и приоритет !high/!low. Остальные слова - название.
+// Если дата и время не найдены, возвращает разобранную задачу и ErrNoDeadline.
+func ParseQuickTask(text string, now time.Time) (domain.Task, error) {
+	var (
+		task         domain.Task
+		title        []string
+		date         time.Time
+		hasDate      bool
+		hour, minute int
+		hasTime      bool
+	)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	for _, word := range strings.Fields(text) {
+		lower := strings.ToLower(word)
+		if days, ok := relativeDays[lower]; ok && !hasDate {
+			date, hasDate = today.AddDate(0, 0, days), true
+			continue
+		}
+		if m := clockRe.FindStringSubmatch(lower); m != nil && !hasTime {
+			hour, _ = strconv.Atoi(m[1])
+			minute, _ = strconv.Atoi(m[2])
+			hasTime = true
+			continue
+		}
+		if m := dateRe.FindStringSubmatch(lower); m != nil && !hasDate {
+			if d, ok := parseQuickDate(m, today); ok {
+				date, hasDate = d, true
+				continue
+			}
+		}
+		if p, ok := priorities[lower]; ok {
+			task.Priority = p
+			continue
+		}
+		if tag, ok := strings.CutPrefix(lower, "#"); ok {
+			if tag = strings.TrimRight(tag, ".,;:!?"); tag != "" {
+				if !slices.Contains(task.Tags, tag) {
+					task.Tags = append(task.Tags, tag)
+				}
+				continue
+			}
+		}
+		title = append(title, word)
+	}
+	task.Title = strings.Join(title, " ")
+
+	if !hasDate && !hasTime {
+		return task, ErrNoDeadline
+	}
+	if !hasTime {
+		hour = defaultQuickHour
+	}
+	if !hasDate {
+		// Только время: сегодня, а если оно уже прошло - завтра
+		date = today
+		if !date.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute).After(now) {
+			date = date.AddDate(0, 0, 1)
+		}
+	}
+	task.Deadline = date.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+
+	if task.Deadline.Before(now) {
+		return task, invalid("deadline", "время выполнения не должно быть в прошлом")
+	}
+	return task, nil
+}
+
+// parseQuickDate разбирает ДД.ММ[.ГГГГ]. Дата без года, которая уже
+// прошла в этом году, относится к следующему.
+func parseQuickDate(m []string, today time.Time) (time.Time, bool) {
+	day, _ := strconv.Atoi(m[1])
+	month, _ := strconv.Atoi(m[2])
+	year := today.Year()
+	if m[3] != "" {
+		year, _ = strconv.Atoi(m[3])
+	}
+	d := time.Date(year, time.Month(month), day, 0, 0, 0, 0, today.Location())
+	// time.Date нормализует 31.02 в март - такие даты считаем словами названия
+	if d.Day() != day || int(d.Month()) != month {
+		return time.Time{}, false
+	}
+	if m[3] == "" && d.Before(today) {
+		d = d.AddDate(1, 0, 0)
+	}
+	return d, true
+}
+
+// QuickAdd создает задачу из одной строки (см. ParseQuickTask).
+func (t TaskService) QuickAdd(ctx context.Context, userID int64, text string) (domain.Task, error) {
+	task, err := ParseQuickTask(text, Now())
+	if err != nil {
+		return task, err
+	}
+	task.UserID = userID
+	task.RemindBefore = domain.DefaultRemindBefore
+	err = t.AddTask(ctx, &task)
+	return task, err
+}
+
+// maxRemindDelay - самое далекое напоминание, которое можно поставить через /remind.
+const maxRemindDelay = 365 * 24 * time.Hour
+
+var delayRe = regexp.MustCompile(`^(\d+)\s*(m|min|м|мин|h|ч|d|д)$`)
+
+var delayUnits = map[string]time.Duration{
+	"m": time.Minute, "min": time.Minute, "м": time.Minute, "мин": time.Minute,
+	"h": time.Hour, "ч": time.Hour,
+	"d": 24 * time.Hour, "д": 24 * time.Hour,
+}
+
+// ParseDelay разбирает срок напоминания вида "30m", "2h", "1d", "2ч",
+// "1д" или составной "1h30m".
+func ParseDelay(s string) (time.Duration, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	var d time.Duration
+	if m := delayRe.FindStringSubmatch(s); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		d = time.Duration(n) * delayUnits[m[2]]
+	} else {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, invalid("delay", "не удалось разобрать срок %q", s)
+		}
+	}
+	if d <= 0 || d > maxRemindDelay {
+		return 0, invalid("delay", "срок напоминания должен быть от минуты до года")
+	}
+	return d, nil
+}
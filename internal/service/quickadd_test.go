@@ -0,0 +1,116 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"task-traker/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQuickTask(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		input        string
+		wantTitle    string
+		wantDeadline time.Time
+		wantTags     []string
+		wantPriority domain.Priority
+		wantErr      error
+	}{
+		{
+			name:         "Full",
+			input:        "Buy milk tomorrow 18:00 #home !high",
+			wantTitle:    "Buy milk",
+			wantDeadline: time.Date(2026, 3, 11, 18, 0, 0, 0, time.UTC),
+			wantTags:     []string{"home"},
+			wantPriority: domain.PriorityHigh,
+		},
+		{
+			name:         "Russian words and date",
+			input:        "Позвонить маме 15.03 #Семья #семья !низкий",
+			wantTitle:    "Позвонить маме",
+			wantDeadline: time.Date(2026, 3, 15, 9, 0, 0, 0, time.UTC),
+			wantTags:     []string{"семья"},
+			wantPriority: domain.PriorityLow,
+		},
+		{
+			name:         "Time already passed today",
+			input:        "Отчет 10:00",
+			wantTitle:    "Отчет",
+			wantDeadline: time.Date(2026, 3, 11, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name:         "Date without year in the past",
+			input:        "Продлить домен 01.02",
+			wantTitle:    "Продлить домен",
+			wantDeadline: time.Date(2027, 2, 1, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:         "Full date",
+			input:        "Сдать отчет 20.04.2026 11:20",
+			wantTitle:    "Сдать отчет",
+			wantDeadline: time.Date(2026, 4, 20, 11, 20, 0, 0, time.UTC),
+		},
+		{
+			name:      "No date",
+			input:     "Купить хлеб #дом",
+			wantTitle: "Купить хлеб",
+			wantTags:  []string{"дом"},
+			wantErr:   ErrNoDeadline,
+		},
+		{
+			name:      "Invalid date is a word",
+			input:     "Версия 31.02",
+			wantTitle: "Версия 31.02",
+			wantErr:   ErrNoDeadline,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task, err := ParseQuickTask(tt.input, now)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantDeadline, task.Deadline)
+			}
+			assert.Equal(t, tt.wantTitle, task.Title)
+			assert.Equal(t, tt.wantTags, task.Tags)
+			assert.Equal(t, tt.wantPriority, task.Priority)
+		})
+	}
+}
+
+func TestParseQuickTask_PastDeadline(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 30, 0, 0, time.UTC)
+
+	_, err := ParseQuickTask("Встреча сегодня 11:00", now)
+
+	assert.Error(t, err)
+}
+
+func TestParseDelay(t *testing.T) {
+	cases := map[string]time.Duration{
+		"30m":   30 * time.Minute,
+		"2h":    2 * time.Hour,
+		"2ч":    2 * time.Hour,
+		"1д":    24 * time.Hour,
+		"1h30m": 90 * time.Minute,
+	}
+	for in, want := range cases {
+		got, err := ParseDelay(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+
+	for _, in := range []string{"", "завтра", "0m", "-1h", "400d"} {
+		_, err := ParseDelay(in)
+		assert.Error(t, err, in)
+	}
+}
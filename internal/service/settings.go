@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"task-traker/internal/i18n"
+)
+
+// Language возвращает язык пользователя: выбранный через /lang, а если он
+// не выбран - определенный по language_code клиента Telegram.
+func (t TaskService) Language(ctx context.Context, userID int64, telegramCode string) i18n.Lang {
+	saved, err := t.Repo.GetLanguage(ctx, userID)
+	if err != nil {
+		slog.Warn("Language lookup failed", "user_id", userID, "error", err)
+	}
+	if lang, ok := i18n.Parse(saved); ok {
+		return lang
+	}
+	return i18n.FromTelegram(telegramCode)
+}
+
+// SetLanguage сохраняет выбранный пользователем язык.
+func (t TaskService) SetLanguage(ctx context.Context, userID int64, lang i18n.Lang) error {
+	if _, ok := i18n.Parse(string(lang)); !ok {
+		return invalid("language", "язык %q не поддерживается", lang)
+	}
+	return t.Repo.SetLanguage(ctx, userID, string(lang))
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"math"
+	"task-traker/internal/domain"
+	"time"
+)
+
+// maxStatsPeriod - самый длинный период, за который считается статистика.
+const maxStatsPeriod = 366 * 24 * time.Hour
+
+// Stats - статистика задач пользователя за период [From, To).
+type Stats struct {
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	Created   int       `json:"created"`
+	Completed int       `json:"completed"`
+	// CompletedOnTime - сколько задач выполнено не позже срока.
+	CompletedOnTime int `json:"completed_on_time"`
+	// OnTimeRate - доля выполненных в срок среди выполненных, от 0 до 1.
+	OnTimeRate float64 `json:"on_time_rate"`
+	// AvgLatenessMinutes - среднее опоздание задач, выполненных после срока.
+	AvgLatenessMinutes float64 `json:"avg_lateness_minutes"`
+	// Overdue - невыполненные задачи, срок которых прошел в этом периоде.
+	Overdue int `json:"overdue"`
+	// CurrentStreak - сколько дней подряд до конца периода выполнялась хотя бы
+	// одна задача. Если сегодня задач еще не было, серия считается со вчера.
+	CurrentStreak int `json:"current_streak"`
+	LongestStreak int `json:"longest_streak"`
+	// Weeks - созданные и выполненные задачи по неделям с понедельника.
+	Weeks []WeekStats `json:"weeks"`
+}
+
+type WeekStats struct {
+	Start     time.Time `json:"start"`
+	Created   int       `json:"created"`
+	Completed int       `json:"completed"`
+}
+
+// Stats считает статистику задач пользователя за период [from, to).
+func (t TaskService) Stats(ctx context.Context, userID int64, from, to time.Time) (Stats, error) {
+	if !from.Before(to) {
+		return Stats{}, invalid("from", "начало периода должно быть раньше конца")
+	}
+	if to.Sub(from) > maxStatsPeriod {
+		return Stats{}, invalid("from", "период статистики не может быть больше года")
+	}
+	tasks, err := t.Repo.GetTaskHistory(ctx, userID, from, to)
+	if err != nil {
+		return Stats{}, err
+	}
+	return computeStats(tasks, from, to, Now()), nil
+}
+
+func computeStats(tasks []domain.Task, from, to, now time.Time) Stats {
+	s := Stats{From: from, To: to}
+	first := WeekStart(from)
+	for week := first; week.Before(to); week = week.AddDate(0, 0, 7) {
+		s.Weeks = append(s.Weeks, WeekStats{Start: week})
+	}
+	in := func(t time.Time) bool { return !t.Before(from) && t.Before(to) }
+	weekOf := func(t time.Time) *WeekStats {
+		return &s.Weeks[int(WeekStart(t).Sub(first)/(7*24*time.Hour))]
+	}
+
+	activeDays := make(map[time.Time]bool)
+	var late int
+	var lateness time.Duration
+	for _, task := range tasks {
+		if created := wallClock(task.CreatedAt); in(created) {
+			s.Created++
+			weekOf(created).Created++
+		}
+		if task.CompletedAt == nil {
+			if in(task.Deadline) && task.Deadline.Before(now) {
+				s.Overdue++
+			}
+			continue
+		}
+		done := wallClock(*task.CompletedAt)
+		if !in(done) {
+			continue
+		}
+		s.Completed++
+		weekOf(done).Completed++
+		activeDays[startOfDay(done)] = true
+		if done.After(task.Deadline) {
+			late++
+			lateness += done.Sub(task.Deadline)
+		} else {
+			s.CompletedOnTime++
+		}
+	}
+	if s.Completed > 0 {
+		s.OnTimeRate = float64(s.CompletedOnTime) / float64(s.Completed)
+	}
+	if late > 0 {
+		s.AvgLatenessMinutes = math.Round(lateness.Minutes() / float64(late))
+	}
+	s.CurrentStreak, s.LongestStreak = streaks(activeDays, startOfDay(from), startOfDay(minTime(to.Add(-time.Nanosecond), now)))
+	return s
+}
+
+// streaks считает текущую серию дней с выполненными задачами, которая
+// заканчивается в день last (или накануне), и самую длинную серию с first по last.
+func streaks(days map[time.Time]bool, first, last time.Time) (current, longest int) {
+	run := 0
+	for d := first; !d.After(last); d = d.AddDate(0, 0, 1) {
+		if days[d] {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	d := last
+	if !days[d] {
+		d = d.AddDate(0, 0, -1)
+	}
+	for ; !d.Before(first) && days[d]; d = d.AddDate(0, 0, -1) {
+		current++
+	}
+	return current, longest
+}
+
+// WeekStart возвращает начало недели (понедельник, 00:00), в которую попадает t.
+func WeekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return startOfDay(t).AddDate(0, 0, -offset)
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
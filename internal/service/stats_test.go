@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"task-traker/internal/domain"
+	"task-traker/internal/repository/repotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeStats(t *testing.T) {
+	// created_at и completed_at приходят из TIMESTAMPTZ в местном времени,
+	// сроки - показания часов в UTC
+	local := func(day, hour int) time.Time { return time.Date(2026, 3, day, hour, 0, 0, 0, time.Local) }
+	localPtr := func(day, hour int) *time.Time { t := local(day, hour); return &t }
+	utc := func(day, hour int) time.Time { return time.Date(2026, 3, day, hour, 0, 0, 0, time.UTC) }
+
+	tasks := []domain.Task{
+		{CreatedAt: local(2, 10), Deadline: utc(3, 18), CompletedAt: localPtr(3, 10)},
+		{CreatedAt: local(3, 10), Deadline: utc(4, 12), CompletedAt: localPtr(4, 14)},
+		{CreatedAt: local(9, 10), Deadline: utc(10, 12), CompletedAt: localPtr(11, 12)},
+		{CreatedAt: local(10, 10), Deadline: utc(12, 10)},
+		{CreatedAt: local(11, 10), Deadline: utc(20, 10)},
+		{CreatedAt: local(-8, 10), Deadline: utc(13, 10), CompletedAt: localPtr(12, 9)},
+	}
+	from, to := utc(2, 0), utc(16, 0)
+
+	s := computeStats(tasks, from, to, utc(12, 12))
+	assert.Equal(t, 5, s.Created)
+	assert.Equal(t, 4, s.Completed)
+	assert.Equal(t, 2, s.CompletedOnTime)
+	assert.Equal(t, 0.5, s.OnTimeRate)
+	assert.Equal(t, float64(120+1440)/2, s.AvgLatenessMinutes)
+	assert.Equal(t, 1, s.Overdue)
+	assert.Equal(t, 2, s.CurrentStreak)
+	assert.Equal(t, 2, s.LongestStreak)
+	assert.Equal(t, []WeekStats{
+		{Start: utc(2, 0), Created: 2, Completed: 2},
+		{Start: utc(9, 0), Created: 3, Completed: 2},
+	}, s.Weeks)
+
+	// Сегодня еще ничего не выполнено - серия продолжается со вчера
+	assert.Equal(t, 2, computeStats(tasks, from, to, utc(13, 10)).CurrentStreak)
+	assert.Equal(t, 0, computeStats(tasks, from, to, utc(14, 10)).CurrentStreak)
+}
+
+func TestTaskService_StatsPeriodEdgesInLocalZone(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("UTC+3", 3*60*60)
+	t.Cleanup(func() { time.Local = local })
+	repo := repotest.NewMemoryRepo()
+	s := TaskService{Repo: repo}
+	ctx := context.Background()
+
+	// Задача создана и выполнена в первые минуты периода по часам сервера,
+	// то есть еще накануне по UTC
+	task := domain.Task{UserID: 1, Title: "Купить хлеб", Deadline: time.Now().AddDate(1, 0, 0)}
+	require.NoError(t, repo.Create(ctx, &task))
+	from := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	created := time.Date(2026, 3, 10, 0, 10, 0, 0, time.Local)
+	completed := created.Add(10 * time.Minute)
+	repo.SetTimes(task.ID, created, &completed)
+
+	stats, err := s.Stats(ctx, 1, from, from.AddDate(0, 0, 7))
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Created)
+	assert.Equal(t, 1, stats.Completed)
+}
+
+func TestComputeStats_Empty(t *testing.T) {
+	from := time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)
+	s := computeStats(nil, from, from.AddDate(0, 0, 7), from)
+	assert.Zero(t, s.OnTimeRate)
+	assert.Zero(t, s.CurrentStreak)
+	// Период со среды по среду задевает две недели
+	require.Len(t, s.Weeks, 2)
+	assert.Equal(t, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), s.Weeks[0].Start)
+}
+
+func TestTaskService_StatsValidatesPeriod(t *testing.T) {
+	s := TaskService{Repo: &MockRepo{}}
+	now := time.Now()
+
+	_, err := s.Stats(context.Background(), 1, now, now)
+	assert.EqualError(t, err, "начало периода должно быть раньше конца")
+
+	_, err = s.Stats(context.Background(), 1, now.AddDate(-2, 0, 0), now)
+	assert.EqualError(t, err, "период статистики не может быть больше года")
+
+	_, err = s.Stats(context.Background(), 1, now.AddDate(0, 0, -7), now)
+	assert.NoError(t, err)
+}
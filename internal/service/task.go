@@ -2,8 +2,10 @@ package service
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"strings"
 	"task-traker/internal/domain"
+	"task-traker/internal/events"
 	"task-traker/internal/repository"
 	"time"
 )
@@ -11,26 +13,204 @@ import (
 type TaskService struct {
 	Repo  domain.TaskRepository
 	Redis *repository.RedisRepo
+	// Events получает события об изменении задач; nil - события не нужны.
+	Events *events.Bus
+	// Webhooks доставляет те же события на вебхуки пользователей.
+	Webhooks *WebhookDispatcher
 }
 
 func (t TaskService) CreateTask(ctx context.Context, userID int64, title, deadlineStr string) error {
-	deadline, err := ParseTime(deadlineStr)
+	deadline, err := ParseDeadline(deadlineStr)
 	if err != nil {
 		return err
 	}
-	if time.Since(deadline) > 0 {
-		return fmt.Errorf("время выполнения не должно быть в прошлом")
-	}
 
 	task := domain.Task{
-		UserID:   userID,
-		Title:    title,
-		Deadline: deadline,
+		UserID:       userID,
+		Title:        title,
+		Deadline:     deadline,
+		RemindBefore: domain.DefaultRemindBefore,
+	}
+
+	return t.AddTask(ctx, &task)
+}
+
+// AddTask проверяет и сохраняет задачу; id созданной задачи записывается в task.ID.
+func (t TaskService) AddTask(ctx context.Context, task *domain.Task) error {
+	var v ValidationError
+	if strings.TrimSpace(task.Title) == "" {
+		v.addField("title", "название задачи не может быть пустым")
+	}
+	if task.Deadline.Before(Now()) {
+		v.addField("deadline", "время выполнения не должно быть в прошлом")
+	}
+	if task.Priority < domain.PriorityLow || task.Priority > domain.PriorityHigh {
+		v.addField("priority", "некорректный приоритет")
+	}
+	if err := v.err(); err != nil {
+		return err
+	}
+	if err := t.Repo.Create(ctx, task); err != nil {
+		return err
+	}
+	t.publish(ctx, events.TaskCreated, task.UserID, task.ID, task)
+	return nil
+}
+
+// GetTask возвращает задачу пользователя. Чужая задача не отличается
+// от несуществующей: domain.ErrTaskNotFound.
+func (t TaskService) GetTask(ctx context.Context, userID int64, id int) (domain.Task, error) {
+	return t.Repo.GetTaskByID(ctx, userID, id)
+}
+
+// CompleteTask отмечает задачу пользователя выполненной.
+func (t TaskService) CompleteTask(ctx context.Context, userID int64, id int) error {
+	if err := t.Repo.Complete(ctx, userID, id); err != nil {
+		return err
+	}
+	t.publish(ctx, events.TaskCompleted, userID, id, nil)
+	return nil
+}
+
+// DeleteTask удаляет задачу пользователя. Чужая задача не отличается
+// от несуществующей: domain.ErrTaskNotFound.
+func (t TaskService) DeleteTask(ctx context.Context, userID int64, id int) error {
+	return t.DeleteTaskVersion(ctx, userID, id, 0)
+}
+
+// DeleteTaskVersion удаляет задачу пользователя, только если ее версия
+// равна version (0 - любая); иначе - domain.ErrVersionConflict.
+func (t TaskService) DeleteTaskVersion(ctx context.Context, userID int64, id, version int) error {
+	if err := t.Repo.Delete(ctx, userID, id, version); err != nil {
+		return err
+	}
+	t.publish(ctx, events.TaskDeleted, userID, id, nil)
+	return nil
+}
+
+// TaskPatch - изменение задачи: меняются только переданные поля.
+// Deadline - в формате ДД.ММ.ГГГГ ЧЧ:ММ, как в ParseDeadline.
+type TaskPatch struct {
+	Title    *string          `json:"title,omitempty"`
+	Deadline *string          `json:"deadline,omitempty"`
+	Project  *string          `json:"project,omitempty"`
+	Priority *domain.Priority `json:"priority,omitempty"`
+}
+
+// apply переносит поля изменения в задачу.
+func (p TaskPatch) apply(task *domain.Task) error {
+	if p.Title != nil {
+		task.Title = *p.Title
+	}
+	if p.Project != nil {
+		task.Project = strings.TrimSpace(*p.Project)
+	}
+	if p.Priority != nil {
+		task.Priority = *p.Priority
+	}
+	if p.Deadline != nil {
+		deadline, err := ParseDeadline(*p.Deadline)
+		if err != nil {
+			return err
+		}
+		task.Deadline = deadline
 	}
+	return nil
+}
+
+// patchAttempts - сколько раз PatchTask без версии перечитывает задачу,
+// если ее одновременно изменил другой запрос.
+const patchAttempts = 3
 
-	err = t.Repo.Create(ctx, &task)
+// PatchTask меняет переданные поля задачи пользователя и возвращает задачу
+// после изменения. Если version не 0, задача меняется, только пока ее
+// версия равна version, иначе - domain.ErrVersionConflict. Без version
+// изменение применяется к последней версии задачи.
+func (t TaskService) PatchTask(ctx context.Context, userID int64, id, version int, patch TaskPatch) (domain.Task, error) {
+	for attempt := 1; ; attempt++ {
+		task, err := t.Repo.GetTaskByID(ctx, userID, id)
+		if err != nil {
+			return domain.Task{}, err
+		}
+		if version != 0 && task.Version != version {
+			return domain.Task{}, domain.ErrVersionConflict
+		}
+		if err := patch.apply(&task); err != nil {
+			return domain.Task{}, err
+		}
+		err = t.UpdateTask(ctx, &task)
+		if errors.Is(err, domain.ErrVersionConflict) && version == 0 && attempt < patchAttempts {
+			continue
+		}
+		if err != nil {
+			return domain.Task{}, err
+		}
+		return task, nil
+	}
+}
 
-	return err
+// UpdateTask проверяет и сохраняет изменения задачи. Задача обновляется,
+// только если task.UserID совпадает с владельцем, а task.Version - с ее
+// версией: изменение копии, которую успели изменить, возвращает
+// domain.ErrVersionConflict.
+func (t TaskService) UpdateTask(ctx context.Context, task *domain.Task) error {
+	var v ValidationError
+	if strings.TrimSpace(task.Title) == "" {
+		v.addField("title", "название задачи не может быть пустым")
+	}
+	if task.Priority < domain.PriorityLow || task.Priority > domain.PriorityHigh {
+		v.addField("priority", "некорректный приоритет")
+	}
+	if task.RemindBefore < 0 {
+		v.addField("remind_before", "время напоминания не может быть отрицательным")
+	}
+	if err := v.err(); err != nil {
+		return err
+	}
+	if err := t.Repo.Update(ctx, task); err != nil {
+		return err
+	}
+	t.publish(ctx, events.TaskUpdated, task.UserID, task.ID, task)
+	return nil
+}
+
+// publish сообщает подписчикам пользователя об изменении задачи. task
+// копируется: вызывающий может менять свою задачу дальше.
+func (t TaskService) publish(ctx context.Context, typ events.Type, userID int64, id int, task *domain.Task) {
+	e := events.Event{Type: typ, UserID: userID, TaskID: id}
+	if task != nil {
+		copied := *task
+		e.Task = &copied
+	}
+	t.Events.Publish(ctx, e)
+	t.Webhooks.Enqueue(e)
+}
+
+// Now возвращает текущее время по часам сервера, но в зоне UTC. Сроки задач
+// хранятся как время без часового пояса (TIMESTAMP) и ParseTime разбирает
+// их в UTC, поэтому сравнивать их нужно с этим значением, а не с time.Now().
+func Now() time.Time {
+	return wallClock(time.Now())
+}
+
+// wallClock переводит момент t в соглашение Now: показания часов сервера
+// в зоне UTC. Так можно сравнивать со сроком время из колонок TIMESTAMPTZ
+// (created_at, completed_at).
+func wallClock(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// ParseDeadline разбирает срок выполнения и проверяет, что он не в прошлом.
+func ParseDeadline(s string) (time.Time, error) {
+	deadline, err := ParseTime(s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if deadline.Before(Now()) {
+		return time.Time{}, invalid("deadline", "время выполнения не должно быть в прошлом")
+	}
+	return deadline, nil
 }
 
 func ParseTime(s string) (time.Time, error) {
@@ -38,7 +218,7 @@ func ParseTime(s string) (time.Time, error) {
 	const layout = "2.1.2006 15:04"
 	parsedTime, err := time.Parse(layout, s)
 	if err != nil {
-		return time.Time{}, fmt.Errorf("некорректно введенная строка времени, %v", err)
+		return time.Time{}, invalid("deadline", "некорректно введенная строка времени, %v", err)
 	}
 	return parsedTime, nil
 }
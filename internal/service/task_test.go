@@ -4,10 +4,14 @@ import (
 	"context"
 	"fmt"
 	"task-traker/internal/domain"
+	"task-traker/internal/events"
+	"task-traker/internal/i18n"
+	"task-traker/internal/repository/repotest"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const TIME_FORMAT = "02.01.2006 15:04"
@@ -53,12 +57,67 @@ func (m *MockRepo) Create(ctx context.Context, task *domain.Task) error {
 func (m *MockRepo) GetTasksByUserID(ctx context.Context, userID int64) ([]domain.Task, error) {
 	return nil, nil
 }
+func (m *MockRepo) GetTasksByDeadline(ctx context.Context, userID int64, from, to time.Time) ([]domain.Task, error) {
+	return nil, nil
+}
+func (m *MockRepo) SearchTasks(ctx context.Context, userID int64, query string, limit int) ([]domain.Task, error) {
+	return nil, nil
+}
+func (m *MockRepo) GetUserTags(ctx context.Context, userID int64) ([]string, error) {
+	return nil, nil
+}
+func (m *MockRepo) GetTaskHistory(ctx context.Context, userID int64, from, to time.Time) ([]domain.Task, error) {
+	return nil, m.errToReturn
+}
+func (m *MockRepo) ListTasks(ctx context.Context, filter domain.TaskFilter) ([]domain.Task, error) {
+	return nil, m.errToReturn
+}
 func (m *MockRepo) GetActiveTasks(ctx context.Context) ([]domain.Task, error) { return nil, nil }
 func (m *MockRepo) MarkAsNotified(ctx context.Context, taskID int) error      { return nil }
-func (m *MockRepo) DeleteByID(ctx context.Context, id string) error           { return nil }
+func (m *MockRepo) InTx(ctx context.Context, fn func(domain.TaskRepository) error) error {
+	return fn(m)
+}
+func (m *MockRepo) Delete(ctx context.Context, userID int64, id, version int) error { return nil }
+func (m *MockRepo) GetTaskByID(ctx context.Context, userID int64, id int) (domain.Task, error) {
+	return domain.Task{}, domain.ErrTaskNotFound
+}
+func (m *MockRepo) Complete(ctx context.Context, userID int64, id int) error { return nil }
+func (m *MockRepo) Update(ctx context.Context, task *domain.Task) error {
+	m.saveCalled = true
+	return m.errToReturn
+}
+func (m *MockRepo) GetLanguage(ctx context.Context, userID int64) (string, error) { return "", nil }
+func (m *MockRepo) SetLanguage(ctx context.Context, userID int64, lang string) error {
+	return m.errToReturn
+}
 func (m *MockRepo) VerifyAuthCode(ctx context.Context, userID int64, code string) (bool, error) {
 	return true, nil
 }
+func (m *MockRepo) CreateWebhook(ctx context.Context, hook *domain.Webhook) error {
+	return m.errToReturn
+}
+func (m *MockRepo) ListWebhooks(ctx context.Context, userID int64) ([]domain.Webhook, error) {
+	return nil, m.errToReturn
+}
+func (m *MockRepo) GetWebhook(ctx context.Context, userID int64, id int) (domain.Webhook, error) {
+	return domain.Webhook{}, domain.ErrWebhookNotFound
+}
+func (m *MockRepo) DeleteWebhook(ctx context.Context, userID int64, id int) error { return nil }
+func (m *MockRepo) EnqueueWebhookEvent(ctx context.Context, userID int64, event string, payload []byte) (int, error) {
+	return 0, m.errToReturn
+}
+func (m *MockRepo) CreateWebhookDelivery(ctx context.Context, d *domain.WebhookDelivery) error {
+	return m.errToReturn
+}
+func (m *MockRepo) ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.WebhookDelivery, error) {
+	return nil, nil
+}
+func (m *MockRepo) UpdateWebhookDelivery(ctx context.Context, d *domain.WebhookDelivery) error {
+	return nil
+}
+func (m *MockRepo) ListWebhookDeliveries(ctx context.Context, userID int64, webhookID int, limit int) ([]domain.WebhookDelivery, error) {
+	return nil, m.errToReturn
+}
 
 func TestCreateTask_RepoError(t *testing.T) {
 	mock := &MockRepo{errToReturn: fmt.Errorf("database connection lost")}
@@ -115,3 +174,130 @@ func TestCreateTask_Success(t *testing.T) {
 	assert.NoError(t, err)
 	assert.True(t, mock.saveCalled)
 }
+
+func TestUpdateTask_Validation(t *testing.T) {
+	tests := []struct {
+		name    string
+		task    domain.Task
+		wantErr bool
+	}{
+		{"Valid", domain.Task{Title: "Купить хлеб", Priority: domain.PriorityHigh, RemindBefore: 30}, false},
+		{"Empty title", domain.Task{Title: "  "}, true},
+		{"Unknown priority", domain.Task{Title: "Купить хлеб", Priority: 5}, true},
+		{"Negative reminder", domain.Task{Title: "Купить хлеб", RemindBefore: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &MockRepo{}
+			s := TaskService{Repo: mock}
+
+			err := s.UpdateTask(context.Background(), &tt.task)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.False(t, mock.saveCalled)
+			} else {
+				assert.NoError(t, err)
+				assert.True(t, mock.saveCalled)
+			}
+		})
+	}
+}
+
+func TestAddTask_ReportsAllFields(t *testing.T) {
+	mock := &MockRepo{}
+	s := TaskService{Repo: mock}
+
+	err := s.AddTask(context.Background(), &domain.Task{Title: " ", Deadline: time.Now().Add(-time.Hour), Priority: 7})
+
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	fields := make([]string, len(verr.Fields))
+	for i, f := range verr.Fields {
+		fields[i] = f.Field
+	}
+	assert.Equal(t, []string{"title", "deadline", "priority"}, fields)
+	assert.False(t, mock.saveCalled)
+
+	msg, ok := i18n.EN.Error(err)
+	assert.True(t, ok)
+	assert.Equal(t, "task title cannot be empty", msg)
+}
+
+func TestErrorKinds(t *testing.T) {
+	err := errorf(ErrUnauthorized, "неверный или просроченный код")
+
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	assert.NotErrorIs(t, err, ErrConflict)
+	assert.Equal(t, "неверный или просроченный код", err.Error())
+	msg, ok := i18n.EN.Error(err)
+	assert.True(t, ok)
+	assert.Equal(t, "invalid or expired code", msg)
+}
+
+func TestTaskService_PublishesEvents(t *testing.T) {
+	repo := repotest.NewMemoryRepo()
+	s := TaskService{Repo: repo, Events: events.NewBus(nil)}
+	sub := s.Events.Subscribe(1)
+	defer sub.Close()
+	ctx := context.Background()
+
+	task := domain.Task{UserID: 1, Title: "Купить хлеб", Deadline: time.Now().Add(time.Hour)}
+	require.NoError(t, s.AddTask(ctx, &task))
+	task.Title = "Купить батон"
+	require.NoError(t, s.UpdateTask(ctx, &task))
+	require.NoError(t, s.CompleteTask(ctx, 1, task.ID))
+	require.NoError(t, s.DeleteTask(ctx, 1, task.ID))
+	// Неудачные изменения событий не дают
+	assert.Error(t, s.DeleteTask(ctx, 1, task.ID))
+
+	var types []events.Type
+	for range 4 {
+		e := <-sub.C
+		assert.Equal(t, task.ID, e.TaskID)
+		types = append(types, e.Type)
+	}
+	assert.Equal(t, []events.Type{events.TaskCreated, events.TaskUpdated, events.TaskCompleted, events.TaskDeleted}, types)
+	assert.Empty(t, sub.C)
+}
+
+func TestUpdateTask_StaleCopy(t *testing.T) {
+	repo := repotest.NewMemoryRepo()
+	s := TaskService{Repo: repo}
+	ctx := context.Background()
+	task := domain.Task{UserID: 1, Title: "Купить хлеб", Deadline: time.Now().Add(time.Hour)}
+	require.NoError(t, s.AddTask(ctx, &task))
+	stale := task
+
+	task.Title = "Купить батон"
+	require.NoError(t, s.UpdateTask(ctx, &task))
+	assert.Equal(t, 2, task.Version)
+
+	stale.Title = "Купить молоко"
+	assert.ErrorIs(t, s.UpdateTask(ctx, &stale), domain.ErrVersionConflict)
+	assert.ErrorIs(t, s.DeleteTaskVersion(ctx, 1, task.ID, 1), domain.ErrVersionConflict)
+	assert.Equal(t, "Купить батон", repo.Tasks()[0].Title)
+
+	require.NoError(t, s.DeleteTaskVersion(ctx, 1, task.ID, 2))
+	assert.ErrorIs(t, s.DeleteTaskVersion(ctx, 1, task.ID, 2), domain.ErrTaskNotFound)
+}
+
+func TestPatchTask(t *testing.T) {
+	repo := repotest.NewMemoryRepo()
+	s := TaskService{Repo: repo}
+	ctx := context.Background()
+	task := domain.Task{UserID: 1, Title: "Купить хлеб", Deadline: time.Now().Add(time.Hour)}
+	require.NoError(t, s.AddTask(ctx, &task))
+
+	title, deadline := "Купить батон", time.Now().Add(48*time.Hour).Format(TIME_FORMAT)
+	got, err := s.PatchTask(ctx, 1, task.ID, 1, TaskPatch{Title: &title, Deadline: &deadline})
+	require.NoError(t, err)
+	assert.Equal(t, "Купить батон", got.Title)
+	assert.Equal(t, deadline, got.Deadline.Format(TIME_FORMAT))
+	assert.Equal(t, 2, got.Version)
+
+	_, err = s.PatchTask(ctx, 1, task.ID, 1, TaskPatch{Title: &title})
+	assert.ErrorIs(t, err, domain.ErrVersionConflict)
+	_, err = s.PatchTask(ctx, 2, task.ID, 0, TaskPatch{Title: &title})
+	assert.ErrorIs(t, err, domain.ErrTaskNotFound)
+}
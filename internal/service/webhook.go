@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"slices"
+	"task-traker/internal/domain"
+	"task-traker/internal/events"
+	"time"
+)
+
+const (
+	// MaxWebhooks - сколько подписок может быть у одного пользователя.
+	MaxWebhooks = 10
+	// MinWebhookSecret - наименьшая длина своего секрета подписки.
+	MinWebhookSecret = 16
+	// MaxWebhookDeliveries - сколько последних доставок отдает журнал.
+	MaxWebhookDeliveries = 100
+	// WebhookPing - тип тестового события из PingWebhook.
+	WebhookPing = "ping"
+)
+
+// CreateWebhook подписывает пользователя на события eventTypes (пустой
+// список - все события). Если secret пуст, секрет генерируется; он
+// возвращается в подписке и нужен получателю для проверки подписи.
+func (t TaskService) CreateWebhook(ctx context.Context, userID int64, rawURL, secret string, eventTypes []string) (domain.Webhook, error) {
+	var v ValidationError
+	if u, err := url.Parse(rawURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.addField("url", "адрес вебхука должен быть абсолютным URL http или https")
+	}
+	if secret != "" && len(secret) < MinWebhookSecret {
+		v.addField("secret", "секрет должен быть не короче %d символов", MinWebhookSecret)
+	}
+	for _, typ := range eventTypes {
+		if !slices.Contains(events.Types, events.Type(typ)) {
+			v.addField("events", "неизвестный тип события %q", typ)
+		}
+	}
+	if err := v.err(); err != nil {
+		return domain.Webhook{}, err
+	}
+
+	hooks, err := t.Repo.ListWebhooks(ctx, userID)
+	if err != nil {
+		return domain.Webhook{}, err
+	}
+	if len(hooks) >= MaxWebhooks {
+		return domain.Webhook{}, errorf(ErrConflict, "можно создать не больше %d вебхуков", MaxWebhooks)
+	}
+	if secret == "" {
+		secret = rand.Text()
+	}
+	hook := domain.Webhook{UserID: userID, URL: rawURL, Secret: secret, Events: slices.Compact(slices.Sorted(slices.Values(eventTypes)))}
+	if err := t.Repo.CreateWebhook(ctx, &hook); err != nil {
+		return domain.Webhook{}, err
+	}
+	return hook, nil
+}
+
+// ListWebhooks возвращает подписки пользователя.
+func (t TaskService) ListWebhooks(ctx context.Context, userID int64) ([]domain.Webhook, error) {
+	return t.Repo.ListWebhooks(ctx, userID)
+}
+
+// DeleteWebhook удаляет подписку пользователя; недоставленные события
+// по ней больше не отправляются.
+func (t TaskService) DeleteWebhook(ctx context.Context, userID int64, id int) error {
+	return t.Repo.DeleteWebhook(ctx, userID, id)
+}
+
+// WebhookDeliveries возвращает до limit последних доставок подписки.
+func (t TaskService) WebhookDeliveries(ctx context.Context, userID int64, id, limit int) ([]domain.WebhookDelivery, error) {
+	if limit <= 0 || limit > MaxWebhookDeliveries {
+		limit = MaxWebhookDeliveries
+	}
+	if _, err := t.Repo.GetWebhook(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	return t.Repo.ListWebhookDeliveries(ctx, userID, id, limit)
+}
+
+// webhookPing - тело тестового события.
+type webhookPing struct {
+	Type      string    `json:"type"`
+	WebhookID int       `json:"webhook_id"`
+	Time      time.Time `json:"time"`
+}
+
+// PingWebhook сразу отправляет на подписку тестовое событие ping и
+// возвращает итог попытки. Ping не повторяется, но попадает в журнал.
+// Попытка длится не дольше Webhooks.PingTimeout: медленный получатель
+// дает неудачную доставку, а не зависший запрос к API.
+func (t TaskService) PingWebhook(ctx context.Context, userID int64, id int) (domain.WebhookDelivery, error) {
+	hook, err := t.Repo.GetWebhook(ctx, userID, id)
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+	payload, err := json.Marshal(webhookPing{Type: WebhookPing, WebhookID: hook.ID, Time: time.Now().UTC()})
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+	d := domain.WebhookDelivery{
+		WebhookID: hook.ID,
+		UserID:    userID,
+		Event:     WebhookPing,
+		Payload:   payload,
+		Status:    domain.DeliveryPending,
+		// Воркер не должен взять ping, пока идет эта попытка
+		NextAttemptAt: time.Now().Add(webhookLease),
+	}
+	if err := t.Repo.CreateWebhookDelivery(ctx, &d); err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+	t.Webhooks.attempt(ctx, t.Repo, hook, &d, 1, t.Webhooks.pingTimeout())
+	return d, nil
+}
+
+// SignWebhook - подпись тела вебхука: HMAC-SHA256 секретом подписки от
+// строки "<timestamp>.<body>" в hex. Получатель сверяет ее с заголовком
+// X-Webhook-Signature (без префикса "sha256=") и отбрасывает запросы
+// со старым X-Webhook-Timestamp.
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
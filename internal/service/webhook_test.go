@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"task-traker/internal/domain"
+	"task-traker/internal/events"
+	"task-traker/internal/repository/repotest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// webhookReceiver - получатель вебхуков, который проверяет подпись.
+type webhookReceiver struct {
+	srv    *httptest.Server
+	status int
+	got    chan *http.Request
+	bodies chan []byte
+}
+
+func newWebhookReceiver(t *testing.T, secret string) *webhookReceiver {
+	t.Helper()
+	rcv := &webhookReceiver{status: http.StatusOK, got: make(chan *http.Request, 10), bodies: make(chan []byte, 10)}
+	rcv.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		sig := "sha256=" + SignWebhook(secret, r.Header.Get("X-Webhook-Timestamp"), body)
+		assert.Equal(t, sig, r.Header.Get("X-Webhook-Signature"))
+		rcv.got <- r
+		rcv.bodies <- body
+		w.WriteHeader(rcv.status)
+	}))
+	t.Cleanup(rcv.srv.Close)
+	return rcv
+}
+
+func TestCreateWebhook_Validation(t *testing.T) {
+	s := TaskService{Repo: repotest.NewMemoryRepo()}
+
+	_, err := s.CreateWebhook(context.Background(), 1, "ftp://example.com", "short", []string{"task.created", "task.exploded"})
+
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	var fields []string
+	for _, f := range verr.Fields {
+		fields = append(fields, f.Field)
+	}
+	assert.Equal(t, []string{"url", "secret", "events"}, fields)
+}
+
+func TestCreateWebhook_GeneratesSecretAndLimits(t *testing.T) {
+	repo := repotest.NewMemoryRepo()
+	s := TaskService{Repo: repo}
+	ctx := context.Background()
+
+	hook, err := s.CreateWebhook(ctx, 1, "https://example.com/hook", "", []string{"task.deleted", "task.created", "task.created"})
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, len(hook.Secret), MinWebhookSecret)
+	assert.Equal(t, []string{"task.created", "task.deleted"}, hook.Events)
+
+	for range MaxWebhooks - 1 {
+		_, err = s.CreateWebhook(ctx, 1, "https://example.com/hook", "", nil)
+		require.NoError(t, err)
+	}
+	_, err = s.CreateWebhook(ctx, 1, "https://example.com/hook", "", nil)
+	assert.ErrorIs(t, err, ErrConflict)
+	// Предел - на пользователя
+	_, err = s.CreateWebhook(ctx, 2, "https://example.com/hook", "", nil)
+	assert.NoError(t, err)
+}
+
+func TestWebhookDispatcher_DeliversSignedEvents(t *testing.T) {
+	repo := repotest.NewMemoryRepo()
+	const secret = "0123456789abcdef"
+	rcv := newWebhookReceiver(t, secret)
+	d := NewWebhookDispatcher(repo)
+	d.Client = rcv.srv.Client()
+	s := TaskService{Repo: repo, Webhooks: d}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go d.Run(ctx)
+
+	_, err := s.CreateWebhook(ctx, 1, rcv.srv.URL, secret, []string{string(events.TaskCreated)})
+	require.NoError(t, err)
+	task := domain.Task{UserID: 1, Title: "Купить хлеб", Deadline: time.Now().Add(time.Hour)}
+	require.NoError(t, s.AddTask(ctx, &task))
+	// Подписка не ждет task.completed
+	require.NoError(t, s.CompleteTask(ctx, 1, task.ID))
+
+	select {
+	case r := <-rcv.got:
+		assert.Equal(t, "task.created", r.Header.Get("X-Webhook-Event"))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+	case <-time.After(2 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+	var e events.Event
+	require.NoError(t, json.Unmarshal(<-rcv.bodies, &e))
+	assert.Equal(t, task.ID, e.TaskID)
+	assert.Equal(t, "Купить хлеб", e.Task.Title)
+
+	require.Eventually(t, func() bool {
+		deliveries := repo.Deliveries()
+		return len(deliveries) == 1 && deliveries[0].Status == domain.DeliveryDelivered
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestWebhookDispatcher_RetriesWithBackoff(t *testing.T) {
+	repo := repotest.NewMemoryRepo()
+	const secret = "0123456789abcdef"
+	rcv := newWebhookReceiver(t, secret)
+	rcv.status = http.StatusBadGateway
+	d := NewWebhookDispatcher(repo)
+	d.Client = rcv.srv.Client()
+	ctx := context.Background()
+
+	hook := domain.Webhook{UserID: 1, URL: rcv.srv.URL, Secret: secret}
+	require.NoError(t, repo.CreateWebhook(ctx, &hook))
+	n, err := repo.EnqueueWebhookEvent(ctx, 1, "task.deleted", []byte(`{}`))
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	d.deliverDue(ctx)
+	del := repo.Deliveries()[0]
+	assert.Equal(t, domain.DeliveryPending, del.Status)
+	assert.Equal(t, 1, del.Attempts)
+	assert.Equal(t, http.StatusBadGateway, del.ResponseCode)
+	assert.Contains(t, del.Error, "502")
+	assert.WithinDuration(t, time.Now().Add(webhookBackoff), del.NextAttemptAt, 5*time.Second)
+
+	// Повтор еще не наступил
+	d.deliverDue(ctx)
+	assert.Equal(t, 1, repo.Deliveries()[0].Attempts)
+
+	// Последняя попытка переводит доставку в failed
+	del.Attempts = MaxWebhookAttempts - 1
+	d.attempt(ctx, repo, hook, &del, MaxWebhookAttempts, 0)
+	assert.Equal(t, domain.DeliveryFailed, repo.Deliveries()[0].Status)
+	assert.Equal(t, MaxWebhookAttempts, repo.Deliveries()[0].Attempts)
+}
+
+func TestPingWebhook(t *testing.T) {
+	repo := repotest.NewMemoryRepo()
+	const secret = "0123456789abcdef"
+	rcv := newWebhookReceiver(t, secret)
+	d := NewWebhookDispatcher(repo)
+	d.Client = rcv.srv.Client()
+	s := TaskService{Repo: repo, Webhooks: d}
+	ctx := context.Background()
+
+	hook, err := s.CreateWebhook(ctx, 1, rcv.srv.URL, secret, nil)
+	require.NoError(t, err)
+
+	del, err := s.PingWebhook(ctx, 1, hook.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.DeliveryDelivered, del.Status)
+	assert.Equal(t, http.StatusOK, del.ResponseCode)
+	assert.Equal(t, "ping", (<-rcv.got).Header.Get("X-Webhook-Event"))
+
+	_, err = s.PingWebhook(ctx, 2, hook.ID)
+	assert.ErrorIs(t, err, domain.ErrWebhookNotFound)
+
+	deliveries, err := s.WebhookDeliveries(ctx, 1, hook.ID, 0)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, del.ID, deliveries[0].ID)
+}
+
+func TestWebhookClient_RejectsInternalAddresses(t *testing.T) {
+	repo := repotest.NewMemoryRepo()
+	rcv := newWebhookReceiver(t, "0123456789abcdef")
+	// Диспетчер по умолчанию не ходит на локальные адреса
+	s := TaskService{Repo: repo, Webhooks: NewWebhookDispatcher(repo)}
+	ctx := context.Background()
+
+	hook, err := s.CreateWebhook(ctx, 1, rcv.srv.URL, "", nil)
+	require.NoError(t, err)
+	del, err := s.PingWebhook(ctx, 1, hook.ID)
+	require.NoError(t, err)
+
+	assert.Equal(t, domain.DeliveryFailed, del.Status)
+	assert.Contains(t, del.Error, "is not public")
+	assert.Empty(t, rcv.got)
+}
+
+func TestSignWebhook(t *testing.T) {
+	// echo -n '1700000000.{}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(t, "b8569b78799ff9e3cbff0fc2d63a33a2b57f3282abd07c37ae5e8e7d79a5f163",
+		SignWebhook("secret", "1700000000", []byte("{}")))
+}
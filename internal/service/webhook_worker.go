@@ -0,0 +1,265 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync"
+	"syscall"
+	"task-traker/internal/domain"
+	"task-traker/internal/events"
+	"time"
+)
+
+const (
+	// MaxWebhookAttempts - сколько раз отправляется событие, прежде чем
+	// доставка получит статус failed.
+	MaxWebhookAttempts = 6
+	// webhookBackoff - пауза перед первым повтором; каждая следующая
+	// вчетверо длиннее: 30 с, 2 мин, 8 мин, 32 мин, 2 ч 8 мин.
+	webhookBackoff = 30 * time.Second
+	// webhookLease - на сколько взятая доставка скрыта от других воркеров.
+	// Больше webhookTimeout, чтобы попытка успела завершиться.
+	webhookLease   = time.Minute
+	webhookTimeout = 10 * time.Second
+	// webhookPingTimeout - сколько ждать ответа на ping. Ping идет внутри
+	// запроса к API, поэтому срок короче webhookTimeout.
+	webhookPingTimeout = 5 * time.Second
+	// webhookPoll - как часто воркер ищет доставки, чей повтор наступил.
+	webhookPoll  = 5 * time.Second
+	webhookBatch = 20
+	// webhookQueue - сколько событий ждет записи в журнал доставок.
+	webhookQueue = 1024
+	// webhookMaxError - длина сохраняемого текста ошибки попытки.
+	webhookMaxError = 500
+)
+
+// WebhookDispatcher доставляет события задач на вебхуки пользователей.
+// Enqueue не блокирует TaskService: событие попадает в очередь, Run
+// записывает его в журнал доставок и отправляет, повторяя неудачные
+// попытки с растущей паузой. Журнал хранится в базе, поэтому повторы
+// переживают перезапуск, а несколько реплик делят доставки между собой.
+// Нулевой *WebhookDispatcher события отбрасывает.
+type WebhookDispatcher struct {
+	// Client отправляет запросы; по умолчанию - NewWebhookClient.
+	Client *http.Client
+	// PingTimeout ограничивает попытку PingWebhook; по умолчанию
+	// webhookPingTimeout.
+	PingTimeout time.Duration
+
+	repo  domain.TaskRepository
+	queue chan events.Event
+	// wake будит отправку, когда в журнале появились новые доставки.
+	wake chan struct{}
+}
+
+func NewWebhookDispatcher(repo domain.TaskRepository) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		Client:      NewWebhookClient(),
+		PingTimeout: webhookPingTimeout,
+		repo:        repo,
+		queue:       make(chan events.Event, webhookQueue),
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// NewWebhookClient - HTTP-клиент для вебхуков. Он не следует редиректам
+// и не подключается к локальным и внутренним адресам, иначе через вебхук
+// можно было бы обращаться к сервисам в сети сервера.
+func NewWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: webhookTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := addrPort.Addr().Unmap(); !ip.IsGlobalUnicast() || ip.IsPrivate() {
+				return fmt.Errorf("webhook address %s is not public", ip)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Через прокси проверка адреса потеряла бы смысл
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   webhookTimeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Enqueue ставит событие в очередь доставки. Если очередь переполнена,
+// событие отбрасывается с записью в лог: изменение задачи уже сохранено.
+func (d *WebhookDispatcher) Enqueue(e events.Event) {
+	if d == nil {
+		return
+	}
+	select {
+	case d.queue <- e:
+	default:
+		slog.Error("Webhook queue is full, event dropped", "type", e.Type, "task_id", e.TaskID)
+	}
+}
+
+// Run записывает события из очереди в журнал и отправляет доставки,
+// пока не отменен ctx.
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	go d.store(ctx)
+
+	ticker := time.NewTicker(webhookPoll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+		d.deliverDue(ctx)
+	}
+}
+
+// store создает доставки событий из очереди.
+func (d *WebhookDispatcher) store(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-d.queue:
+			payload, err := json.Marshal(e)
+			if err != nil {
+				slog.Error("JSON encode error", "error", err)
+				continue
+			}
+			n, err := d.repo.EnqueueWebhookEvent(ctx, e.UserID, string(e.Type), payload)
+			if err != nil {
+				slog.Error("Webhook enqueue failed", "type", e.Type, "task_id", e.TaskID, "error", err)
+				continue
+			}
+			if n > 0 {
+				select {
+				case d.wake <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}
+}
+
+// deliverDue отправляет все доставки, чья попытка наступила. Доставки
+// одной пачки отправляются параллельно.
+func (d *WebhookDispatcher) deliverDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		batch, err := d.repo.ClaimWebhookDeliveries(ctx, time.Now(), webhookLease, webhookBatch)
+		if err != nil {
+			slog.Error("Webhook claim failed", "error", err)
+			return
+		}
+		var wg sync.WaitGroup
+		for _, del := range batch {
+			wg.Go(func() {
+				hook, err := d.repo.GetWebhook(ctx, del.UserID, del.WebhookID)
+				if err != nil {
+					// Подписку удалили, ее доставки удалены вместе с ней
+					slog.Warn("Webhook not found for delivery", "delivery_id", del.ID, "error", err)
+					return
+				}
+				d.attempt(ctx, d.repo, hook, &del, MaxWebhookAttempts, 0)
+			})
+		}
+		wg.Wait()
+		if len(batch) < webhookBatch {
+			return
+		}
+	}
+}
+
+// pingTimeout - срок попытки ping; у нулевого *WebhookDispatcher
+// и без PingTimeout - webhookPingTimeout.
+func (d *WebhookDispatcher) pingTimeout() time.Duration {
+	if d == nil || d.PingTimeout <= 0 {
+		return webhookPingTimeout
+	}
+	return d.PingTimeout
+}
+
+// attempt отправляет доставку del на hook и сохраняет итог через repo.
+// После maxAttempts неудач доставка получает статус failed, иначе
+// следующая попытка откладывается по webhookBackoff. timeout, если не 0,
+// ограничивает отправку (журнал пишется уже без него).
+func (d *WebhookDispatcher) attempt(ctx context.Context, repo domain.TaskRepository, hook domain.Webhook, del *domain.WebhookDelivery, maxAttempts int, timeout time.Duration) {
+	del.Attempts++
+	postCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		postCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	code, err := d.post(postCtx, hook, del)
+	now := time.Now()
+	del.ResponseCode = code
+	if err == nil {
+		del.Status, del.Error, del.DeliveredAt = domain.DeliveryDelivered, "", &now
+	} else {
+		del.Error = err.Error()
+		if len(del.Error) > webhookMaxError {
+			del.Error = del.Error[:webhookMaxError]
+		}
+		if del.Attempts >= maxAttempts {
+			del.Status = domain.DeliveryFailed
+		} else {
+			del.NextAttemptAt = now.Add(webhookBackoff << (2 * (del.Attempts - 1)))
+		}
+		slog.Warn("Webhook delivery failed", "delivery_id", del.ID, "attempt", del.Attempts, "error", err)
+	}
+	if err := repo.UpdateWebhookDelivery(ctx, del); err != nil {
+		slog.Error("Webhook delivery update failed", "delivery_id", del.ID, "error", err)
+	}
+}
+
+// post отправляет одну попытку и возвращает статус ответа. Успех - только
+// ответ 2xx.
+func (d *WebhookDispatcher) post(ctx context.Context, hook domain.Webhook, del *domain.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(del.Payload))
+	if err != nil {
+		return 0, err
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "task-tracker-webhooks/1.0")
+	req.Header.Set("X-Webhook-Event", del.Event)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatInt(del.ID, 10))
+	req.Header.Set("X-Webhook-Timestamp", ts)
+	req.Header.Set("X-Webhook-Signature", "sha256="+SignWebhook(hook.Secret, ts, del.Payload))
+
+	var client *http.Client
+	if d != nil {
+		client = d.Client
+	}
+	if client == nil {
+		client = NewWebhookClient()
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Дочитываем тело, чтобы соединение вернулось в пул
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected response %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
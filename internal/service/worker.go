@@ -2,13 +2,20 @@ package service
 
 import (
 	"context"
-	"fmt"
 	"log/slog"
+	"sync"
+	"task-traker/internal/domain"
+	"task-traker/internal/events"
 	"task-traker/pkg/telegram"
 	"time"
 )
 
-func (s *TaskService) StartNotificationWorker(ctx context.Context, bot *telegram.Client) {
+// reminderSenders - сколько напоминаний отправляется одновременно. Частоту
+// ограничивает очередь отправки, а отправители лишь держат ее заполненной:
+// больше горутин не ускорит рассылку, только удлинит очередь.
+const reminderSenders = 32
+
+func (s *TaskService) StartNotificationWorker(ctx context.Context, bot telegram.Messenger) {
 	ticker := time.NewTicker(1 * time.Minute)
 	defer ticker.Stop()
 
@@ -22,13 +29,42 @@ func (s *TaskService) StartNotificationWorker(ctx context.Context, bot *telegram
 				slog.Error("worker error", "err", err)
 				continue
 			}
+			s.sendReminders(ctx, bot, tasks)
+		}
+	}
+}
 
-			for _, task := range tasks {
-				msg := fmt.Sprintf("⏰Напоминание: %s", task.Title)
-				if err := bot.SendMessage(task.UserID, msg); err == nil {
+// sendReminders отправляет напоминания reminderSenders отправителями и
+// возвращается после всех отправок, поэтому следующая проверка не отправит
+// задачу дважды.
+func (s *TaskService) sendReminders(ctx context.Context, bot telegram.Messenger, tasks []domain.Task) {
+	due := make(chan domain.Task)
+	var wg sync.WaitGroup
+	for range min(reminderSenders, len(tasks)) {
+		wg.Go(func() {
+			for task := range due {
+				if err := bot.SendFormatted(task.UserID, s.reminderText(ctx, task), nil); err == nil {
 					s.Repo.MarkAsNotified(ctx, task.ID)
+					task.Notified = true
+					s.publish(ctx, events.TaskReminded, task.UserID, task.ID, &task)
 				}
 			}
-		}
+		})
+	}
+	for _, task := range tasks {
+		due <- task
+	}
+	close(due)
+	wg.Wait()
+}
+
+// reminderText - текст напоминания на языке пользователя. Название задачи
+// экранируется, поэтому любые символы в нем не ломают разметку.
+func (s *TaskService) reminderText(ctx context.Context, task domain.Task) telegram.Formatted {
+	lang := s.Language(ctx, task.UserID, "")
+	b := telegram.NewBuilder(telegram.ModeHTML).Format(lang.T("⏰Напоминание: %s"), telegram.Bold(task.Title))
+	if task.SourceURL != "" {
+		b.Format("\n🔗 %s", telegram.Link(lang.T("Исходное сообщение"), task.SourceURL))
 	}
+	return b.Message()
 }
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"task-traker/internal/domain"
+	"task-traker/internal/repository/repotest"
+	"task-traker/pkg/telegram"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowMessenger считает одновременные отправки напоминаний.
+type slowMessenger struct {
+	telegram.Messenger
+
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+	sent     int
+}
+
+func (m *slowMessenger) SendFormatted(chatID int64, msg telegram.Formatted, keyboard any) error {
+	m.mu.Lock()
+	m.inFlight++
+	m.peak = max(m.peak, m.inFlight)
+	m.mu.Unlock()
+
+	time.Sleep(time.Millisecond)
+
+	m.mu.Lock()
+	m.inFlight--
+	m.sent++
+	m.mu.Unlock()
+	return nil
+}
+
+func TestSendReminders_BoundedSenders(t *testing.T) {
+	repo := repotest.NewMemoryRepo()
+	s := TaskService{Repo: repo}
+	ctx := context.Background()
+	var tasks []domain.Task
+	for range 5 * reminderSenders {
+		task := domain.Task{UserID: 1, Title: "Купить хлеб", Deadline: time.Now().Add(time.Minute)}
+		require.NoError(t, repo.Create(ctx, &task))
+		tasks = append(tasks, task)
+	}
+
+	bot := &slowMessenger{}
+	s.sendReminders(ctx, bot, tasks)
+
+	assert.Equal(t, 5*reminderSenders, bot.sent)
+	assert.LessOrEqual(t, bot.peak, reminderSenders)
+	for _, task := range repo.Tasks() {
+		assert.True(t, task.Notified)
+	}
+}
@@ -0,0 +1,3 @@
+ALTER TABLE tasks ADD COLUMN priority SMALLINT NOT NULL DEFAULT 0;
+-- за сколько минут до дедлайна прислать напоминание
+ALTER TABLE tasks ADD COLUMN remind_before INTEGER NOT NULL DEFAULT 15;
@@ -0,0 +1,4 @@
+ALTER TABLE tasks ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE tasks ADD COLUMN completed_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_tasks_tags ON tasks USING GIN (tags);
@@ -0,0 +1 @@
+ALTER TABLE tasks ADD COLUMN source_url TEXT NOT NULL DEFAULT '';
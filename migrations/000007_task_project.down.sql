@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_tasks_user_created;
+DROP INDEX IF EXISTS idx_tasks_user_deadline;
+ALTER TABLE tasks DROP COLUMN IF EXISTS project;
@@ -0,0 +1,5 @@
+ALTER TABLE tasks ADD COLUMN project TEXT NOT NULL DEFAULT '';
+
+-- Индексы для сортировки и keyset-пагинации списка задач
+CREATE INDEX idx_tasks_user_deadline ON tasks (user_id, deadline, id);
+CREATE INDEX idx_tasks_user_created ON tasks (user_id, created_at, id);
@@ -0,0 +1,5 @@
+-- Версия задачи для ETag и оптимистичной блокировки
+ALTER TABLE tasks ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE tasks ADD COLUMN updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW();
+
+UPDATE tasks SET updated_at = COALESCE(completed_at, created_at);
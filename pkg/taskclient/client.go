@@ -0,0 +1,265 @@
+// Package taskclient - типизированный клиент REST API трекера задач.
+// Методы соответствуют операциям из openapi.json сервера; ответы с ошибкой
+// возвращаются как *Error.
+package taskclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	// Token - access-токен для эндпоинтов, требующих авторизации.
+	Token string
+	// Language - Accept-Language запросов, язык текстов ошибок ("" - английский).
+	Language string
+}
+
+// New создает клиента для сервера по адресу baseURL, например
+// "http://localhost:8080". httpClient может быть nil - тогда используется
+// http.DefaultClient.
+func New(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{baseURL: strings.TrimRight(baseURL, "/"), httpClient: httpClient}
+}
+
+// Login обменивает код из команды /login бота на пару токенов.
+func (c *Client) Login(ctx context.Context, userID int64, code string) (TokenPair, error) {
+	var tokens TokenPair
+	body := map[string]any{"user_id": userID, "code": code}
+	err := c.do(ctx, http.MethodPost, "/login", nil, nil, body, &tokens)
+	return tokens, err
+}
+
+// Refresh обменивает refresh-токен на новую пару; старый больше не действует.
+func (c *Client) Refresh(ctx context.Context, refreshToken string) (TokenPair, error) {
+	var tokens TokenPair
+	body := map[string]string{"refresh_token": refreshToken}
+	err := c.do(ctx, http.MethodPost, "/auth/refresh", nil, nil, body, &tokens)
+	return tokens, err
+}
+
+// Logout отзывает Token и refreshToken.
+func (c *Client) Logout(ctx context.Context, refreshToken string) error {
+	body := map[string]string{"refresh_token": refreshToken}
+	return c.do(ctx, http.MethodPost, "/logout", nil, nil, body, nil)
+}
+
+// ListTasks возвращает страницу задач. Следующую страницу вернет вызов
+// с теми же параметрами и Cursor = NextCursor.
+func (c *Client) ListTasks(ctx context.Context, p ListParams) (TaskPage, error) {
+	q := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			q.Set(key, value)
+		}
+	}
+	set("status", p.Status)
+	if !p.DueAfter.IsZero() {
+		set("due_after", p.DueAfter.Format(time.RFC3339))
+	}
+	if !p.DueBefore.IsZero() {
+		set("due_before", p.DueBefore.Format(time.RFC3339))
+	}
+	set("q", p.Query)
+	set("tag", p.Tag)
+	set("project", p.Project)
+	set("sort", p.Sort)
+	if p.Limit > 0 {
+		set("limit", strconv.Itoa(p.Limit))
+	}
+	set("cursor", p.Cursor)
+
+	var page TaskPage
+	err := c.do(ctx, http.MethodGet, "/tasks", q, nil, nil, &page)
+	return page, err
+}
+
+// CreateTask создает задачу пользователя, которому выдан Token.
+func (c *Client) CreateTask(ctx context.Context, req CreateTaskRequest) error {
+	header := http.Header{}
+	if req.IdempotencyKey != "" {
+		header.Set("Idempotency-Key", req.IdempotencyKey)
+	}
+	return c.do(ctx, http.MethodPost, "/tasks", nil, header, req, nil)
+}
+
+// Batch выполняет пакет операций в одной транзакции. Ошибки отдельных
+// операций возвращаются в BatchResult.Error, а не ошибкой метода.
+func (c *Client) Batch(ctx context.Context, req BatchRequest) (BatchResponse, error) {
+	header := http.Header{}
+	if req.IdempotencyKey != "" {
+		header.Set("Idempotency-Key", req.IdempotencyKey)
+	}
+	var resp BatchResponse
+	err := c.do(ctx, http.MethodPost, "/tasks/batch", nil, header, req, &resp)
+	return resp, err
+}
+
+func (c *Client) GetTask(ctx context.Context, id int) (Task, error) {
+	var task Task
+	err := c.do(ctx, http.MethodGet, "/tasks/"+strconv.Itoa(id), nil, nil, nil, &task)
+	return task, err
+}
+
+// UpdateTask меняет заданные поля задачи и возвращает ее после изменения.
+func (c *Client) UpdateTask(ctx context.Context, id int, req UpdateTaskRequest) (Task, error) {
+	var task Task
+	err := c.do(ctx, http.MethodPatch, "/tasks/"+strconv.Itoa(id), nil, ifMatch(req.IfVersion), req, &task)
+	return task, err
+}
+
+func (c *Client) DeleteTask(ctx context.Context, id int) error {
+	return c.DeleteTaskVersion(ctx, id, 0)
+}
+
+// DeleteTaskVersion удаляет задачу, только если ее версия все еще version
+// (0 - любая); иначе сервер отвечает 412, см. IsPreconditionFailed.
+func (c *Client) DeleteTaskVersion(ctx context.Context, id, version int) error {
+	return c.do(ctx, http.MethodDelete, "/tasks/"+strconv.Itoa(id), nil, ifMatch(version), nil, nil)
+}
+
+// ifMatch - заголовок If-Match с ETag версии задачи; для 0 - без заголовка.
+func ifMatch(version int) http.Header {
+	if version == 0 {
+		return nil
+	}
+	return http.Header{"If-Match": {`"` + strconv.Itoa(version) + `"`}}
+}
+
+// Stats возвращает статистику за дни с from по to включительно. Нулевые
+// from и to - значения сервера по умолчанию (последние четыре недели).
+func (c *Client) Stats(ctx context.Context, from, to time.Time) (Stats, error) {
+	q := url.Values{}
+	if !from.IsZero() {
+		q.Set("from", from.Format(time.DateOnly))
+	}
+	if !to.IsZero() {
+		q.Set("to", to.Format(time.DateOnly))
+	}
+	var stats Stats
+	err := c.do(ctx, http.MethodGet, "/stats", q, nil, nil, &stats)
+	return stats, err
+}
+
+func (c *Client) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	var resp struct {
+		Webhooks []Webhook `json:"webhooks"`
+	}
+	err := c.do(ctx, http.MethodGet, "/webhooks", nil, nil, nil, &resp)
+	return resp.Webhooks, err
+}
+
+// CreateWebhook подписывается на события задач. Секрет подписи есть
+// только в возвращенном Webhook; проверить подпись поможет VerifyWebhook.
+func (c *Client) CreateWebhook(ctx context.Context, req CreateWebhookRequest) (Webhook, error) {
+	var hook Webhook
+	err := c.do(ctx, http.MethodPost, "/webhooks", nil, nil, req, &hook)
+	return hook, err
+}
+
+func (c *Client) DeleteWebhook(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodDelete, "/webhooks/"+strconv.Itoa(id), nil, nil, nil, nil)
+}
+
+// WebhookDeliveries возвращает до limit последних доставок вебхука
+// (0 - значение сервера по умолчанию), новые первыми.
+func (c *Client) WebhookDeliveries(ctx context.Context, id, limit int) ([]WebhookDelivery, error) {
+	q := url.Values{}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	var resp struct {
+		Deliveries []WebhookDelivery `json:"deliveries"`
+	}
+	err := c.do(ctx, http.MethodGet, "/webhooks/"+strconv.Itoa(id)+"/deliveries", q, nil, nil, &resp)
+	return resp.Deliveries, err
+}
+
+// PingWebhook отправляет на вебхук тестовое событие и возвращает итог
+// попытки; ошибка получателя - в Status и Error доставки, а не в error.
+func (c *Client) PingWebhook(ctx context.Context, id int) (WebhookDelivery, error) {
+	var d WebhookDelivery
+	err := c.do(ctx, http.MethodPost, "/webhooks/"+strconv.Itoa(id)+"/ping", nil, nil, nil, &d)
+	return d, err
+}
+
+// do отправляет запрос с заголовками header и телом body в JSON (nil - без
+// тела) и разбирает ответ в out (nil - ответ не нужен).
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, header http.Header, body, out any) error {
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reqBody)
+	if err != nil {
+		return err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	if c.Language != "" {
+		req.Header.Set("Accept-Language", c.Language)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return decodeError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("taskclient: decode %s %s response: %w", method, path, err)
+	}
+	return nil
+}
+
+// decodeError разбирает ответ с ошибкой. Если тело не problem+json,
+// в Error остаются только статус, его текст и Retry-After.
+func decodeError(resp *http.Response) error {
+	e := &Error{}
+	if data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<16)); err == nil {
+		_ = json.Unmarshal(data, e)
+	}
+	e.Status = resp.StatusCode
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		e.RetryAfter = time.Duration(secs) * time.Second
+	}
+	if e.Title == "" {
+		e.Title = http.StatusText(resp.StatusCode)
+	}
+	return e
+}
@@ -0,0 +1,259 @@
+package taskclient_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	httpHandler "task-traker/internal/delivery/http"
+	"task-traker/internal/repository"
+	"task-traker/internal/repository/repotest"
+	"task-traker/internal/service"
+	"task-traker/pkg/taskclient"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testUserID = 42
+
+// newServer запускает настоящий API поверх репозитория в памяти и возвращает
+// клиента, вошедшего как testUserID.
+func newServer(t *testing.T) (*taskclient.Client, *service.TaskService) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	s := &service.TaskService{Repo: repotest.NewMemoryRepo(), Redis: repository.NewRedisRepo(mr.Addr())}
+	srv := httptest.NewServer(httpHandler.NewHandler(s).InitRouter())
+	t.Cleanup(srv.Close)
+
+	ctx := context.Background()
+	code, err := s.GenerateAuthCode(ctx, testUserID)
+	require.NoError(t, err)
+	c := taskclient.New(srv.URL, srv.Client())
+	tokens, err := c.Login(ctx, testUserID, code)
+	require.NoError(t, err)
+	c.Token = tokens.AccessToken
+	return c, s
+}
+
+func TestClient_TaskLifecycle(t *testing.T) {
+	c, _ := newServer(t)
+	ctx := context.Background()
+	deadline := time.Now().Add(2 * time.Hour)
+
+	for _, title := range []string{"Купить хлеб", "Позвонить маме", "Оплатить счет"} {
+		require.NoError(t, c.CreateTask(ctx, taskclient.CreateTaskRequest{
+			Title:    title,
+			Deadline: taskclient.FormatDeadline(deadline),
+			Project:  "дом",
+		}))
+		deadline = deadline.Add(time.Hour)
+	}
+
+	page, err := c.ListTasks(ctx, taskclient.ListParams{Project: "дом", Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, page.Tasks, 2)
+	assert.Equal(t, "Купить хлеб", page.Tasks[0].Title)
+	assert.Equal(t, int64(testUserID), page.Tasks[0].UserID)
+	require.NotEmpty(t, page.NextCursor)
+
+	next, err := c.ListTasks(ctx, taskclient.ListParams{Project: "дом", Limit: 2, Cursor: page.NextCursor})
+	require.NoError(t, err)
+	require.Len(t, next.Tasks, 1)
+	assert.Empty(t, next.NextCursor)
+
+	require.NoError(t, c.DeleteTask(ctx, next.Tasks[0].ID))
+	page, err = c.ListTasks(ctx, taskclient.ListParams{})
+	require.NoError(t, err)
+	assert.Len(t, page.Tasks, 2)
+}
+
+func TestClient_UpdateTaskVersion(t *testing.T) {
+	c, _ := newServer(t)
+	ctx := context.Background()
+	require.NoError(t, c.CreateTask(ctx, taskclient.CreateTaskRequest{
+		Title:    "Купить хлеб",
+		Deadline: taskclient.FormatDeadline(time.Now().Add(time.Hour)),
+	}))
+	page, err := c.ListTasks(ctx, taskclient.ListParams{})
+	require.NoError(t, err)
+	task, err := c.GetTask(ctx, page.Tasks[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, task.Version)
+
+	title, priority := "Купить батон", taskclient.PriorityHigh
+	updated, err := c.UpdateTask(ctx, task.ID, taskclient.UpdateTaskRequest{Title: &title, Priority: &priority, IfVersion: task.Version})
+	require.NoError(t, err)
+	assert.Equal(t, "Купить батон", updated.Title)
+	assert.Equal(t, taskclient.PriorityHigh, updated.Priority)
+	assert.Equal(t, 2, updated.Version)
+
+	// Копия задачи устарела
+	title = "Купить молоко"
+	_, err = c.UpdateTask(ctx, task.ID, taskclient.UpdateTaskRequest{Title: &title, IfVersion: task.Version})
+	assert.True(t, taskclient.IsPreconditionFailed(err), err)
+	err = c.DeleteTaskVersion(ctx, task.ID, task.Version)
+	assert.True(t, taskclient.IsPreconditionFailed(err), err)
+
+	require.NoError(t, c.DeleteTaskVersion(ctx, task.ID, updated.Version))
+	_, err = c.GetTask(ctx, task.ID)
+	assert.False(t, taskclient.IsPreconditionFailed(err))
+	var apiErr *taskclient.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.Status)
+}
+
+func TestClient_IdempotentCreate(t *testing.T) {
+	c, s := newServer(t)
+	ctx := context.Background()
+	req := taskclient.CreateTaskRequest{
+		Title:          "Купить хлеб",
+		Deadline:       taskclient.FormatDeadline(time.Now().Add(time.Hour)),
+		IdempotencyKey: "retry-1",
+	}
+
+	require.NoError(t, c.CreateTask(ctx, req))
+	require.NoError(t, c.CreateTask(ctx, req))
+	tasks, err := s.Repo.GetTasksByUserID(ctx, testUserID)
+	require.NoError(t, err)
+	assert.Len(t, tasks, 1)
+
+	req.Title = "Купить молоко"
+	err = c.CreateTask(ctx, req)
+	var apiErr *taskclient.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusConflict, apiErr.Status)
+}
+
+func TestClient_Batch(t *testing.T) {
+	c, _ := newServer(t)
+	ctx := context.Background()
+	title, deadline := "Купить хлеб", taskclient.FormatDeadline(time.Now().Add(time.Hour))
+
+	resp, err := c.Batch(ctx, taskclient.BatchRequest{
+		Mode: "best_effort",
+		Operations: []taskclient.BatchOperation{
+			{Op: "create", Title: &title, Deadline: &deadline},
+			{Op: "complete", ID: 999},
+		},
+	})
+	require.NoError(t, err)
+	assert.True(t, resp.Committed)
+	require.Len(t, resp.Results, 2)
+	assert.Equal(t, "ok", resp.Results[0].Status)
+	assert.NotZero(t, resp.Results[0].ID)
+	assert.Equal(t, "failed", resp.Results[1].Status)
+	require.NotNil(t, resp.Results[1].Error)
+	assert.Equal(t, http.StatusNotFound, resp.Results[1].Error.Status)
+}
+
+func TestClient_Errors(t *testing.T) {
+	c, _ := newServer(t)
+	ctx := context.Background()
+	c.Language = "ru"
+
+	err := c.CreateTask(ctx, taskclient.CreateTaskRequest{Title: "Купить хлеб", Deadline: "вчера"})
+	var apiErr *taskclient.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnprocessableEntity, apiErr.Status)
+	require.Len(t, apiErr.Errors, 1)
+	assert.Equal(t, "deadline", apiErr.Errors[0].Field)
+
+	err = c.DeleteTask(ctx, 404)
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.Status)
+	assert.Equal(t, "Задача не найдена", apiErr.Detail)
+
+	c.Token = ""
+	_, err = c.Stats(ctx, time.Time{}, time.Time{})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.Status)
+}
+
+func TestClient_RefreshAndStats(t *testing.T) {
+	c, s := newServer(t)
+	ctx := context.Background()
+
+	code, err := s.GenerateAuthCode(ctx, testUserID)
+	require.NoError(t, err)
+	tokens, err := c.Login(ctx, testUserID, code)
+	require.NoError(t, err)
+	refreshed, err := c.Refresh(ctx, tokens.RefreshToken)
+	require.NoError(t, err)
+	assert.NotEqual(t, tokens.RefreshToken, refreshed.RefreshToken)
+
+	_, err = c.Refresh(ctx, tokens.RefreshToken)
+	var apiErr *taskclient.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.Status)
+
+	to := time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC)
+	stats, err := c.Stats(ctx, to.AddDate(0, 0, -13), to)
+	require.NoError(t, err)
+	assert.Equal(t, to.AddDate(0, 0, 1), stats.To)
+	assert.Len(t, stats.Weeks, 3)
+}
+
+func TestClient_Webhooks(t *testing.T) {
+	c, s := newServer(t)
+	ctx := context.Background()
+
+	var secret string
+	verified := make(chan error, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		verified <- taskclient.VerifyWebhook(r.Header, body, secret, time.Minute)
+		// Чужой секрет подпись не проходит
+		assert.ErrorIs(t, taskclient.VerifyWebhook(r.Header, body, "another-secret-value", 0), taskclient.ErrWebhookSignature)
+	}))
+	defer receiver.Close()
+	s.Webhooks = service.NewWebhookDispatcher(s.Repo)
+	s.Webhooks.Client = receiver.Client()
+
+	hook, err := c.CreateWebhook(ctx, taskclient.CreateWebhookRequest{URL: receiver.URL})
+	require.NoError(t, err)
+	secret = hook.Secret
+	require.NotEmpty(t, secret)
+
+	d, err := c.PingWebhook(ctx, hook.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "delivered", d.Status)
+	assert.NoError(t, <-verified)
+
+	deliveries, err := c.WebhookDeliveries(ctx, hook.ID, 5)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, d.ID, deliveries[0].ID)
+
+	hooks, err := c.ListWebhooks(ctx)
+	require.NoError(t, err)
+	require.Len(t, hooks, 1)
+	assert.Empty(t, hooks[0].Secret)
+
+	require.NoError(t, c.DeleteWebhook(ctx, hook.ID))
+	var apiErr *taskclient.Error
+	_, err = c.PingWebhook(ctx, hook.ID)
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.Status)
+}
+
+func TestClient_RetryAfter(t *testing.T) {
+	c, _ := newServer(t)
+	ctx := context.Background()
+
+	// Каждый раз другой пользователь: проверяется блокировка адреса,
+	// а не лимит на пользователя
+	var apiErr *taskclient.Error
+	for i := range httpHandler.DefaultRateLimits.Auth.Limit {
+		_, err := c.Login(ctx, testUserID+int64(i), "000000")
+		require.ErrorAs(t, err, &apiErr)
+		if apiErr.Status == http.StatusTooManyRequests {
+			break
+		}
+	}
+	assert.Equal(t, http.StatusTooManyRequests, apiErr.Status)
+	assert.Equal(t, httpHandler.DefaultRateLimits.Auth.Lockout, apiErr.RetryAfter)
+}
@@ -0,0 +1,344 @@
+package taskclient_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	httpHandler "task-traker/internal/delivery/http"
+	"task-traker/internal/repository"
+	"task-traker/internal/repository/repotest"
+	"task-traker/internal/service"
+	"task-traker/pkg/taskclient"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Клиент написан вручную, поэтому эти тесты сверяют его с openapi.json
+// сервера: типы - со схемами, запросы - с операциями. Новая операция или
+// поле в спецификации без изменений клиента роняет тест.
+
+type spec struct {
+	Paths      map[string]map[string]specOperation `json:"paths"`
+	Components struct {
+		Schemas    map[string]specSchema `json:"schemas"`
+		Parameters map[string]specParam  `json:"parameters"`
+	} `json:"components"`
+}
+
+type specOperation struct {
+	Parameters  []specParam `json:"parameters"`
+	RequestBody *struct {
+		Content map[string]struct {
+			Schema struct {
+				Ref string `json:"$ref"`
+			} `json:"schema"`
+		} `json:"content"`
+	} `json:"requestBody"`
+	Responses map[string]json.RawMessage `json:"responses"`
+}
+
+type specParam struct {
+	Ref  string `json:"$ref"`
+	Name string `json:"name"`
+	In   string `json:"in"`
+}
+
+type specSchema struct {
+	Required   []string                   `json:"required"`
+	Properties map[string]json.RawMessage `json:"properties"`
+}
+
+// notInClient - операции, которые клиент намеренно не реализует: потоки
+// событий читаются своими средствами, а спецификация нужна только людям.
+var notInClient = map[string]bool{
+	"GET /events":       true,
+	"GET /ws":           true,
+	"GET /openapi.json": true,
+}
+
+// clientSchemas - схема openapi.json для каждого типа клиента.
+var clientSchemas = map[reflect.Type]string{
+	reflect.TypeFor[taskclient.TokenPair]():            "TokenPair",
+	reflect.TypeFor[taskclient.Task]():                 "Task",
+	reflect.TypeFor[taskclient.TaskPage]():             "TaskPage",
+	reflect.TypeFor[taskclient.CreateTaskRequest]():    "CreateTaskRequest",
+	reflect.TypeFor[taskclient.UpdateTaskRequest]():    "TaskPatch",
+	reflect.TypeFor[taskclient.BatchRequest]():         "BatchRequest",
+	reflect.TypeFor[taskclient.BatchOperation]():       "BatchOperation",
+	reflect.TypeFor[taskclient.BatchResponse]():        "BatchResponse",
+	reflect.TypeFor[taskclient.BatchResult]():          "BatchResult",
+	reflect.TypeFor[taskclient.Stats]():                "Stats",
+	reflect.TypeFor[taskclient.WeekStats]():            "WeekStats",
+	reflect.TypeFor[taskclient.CreateWebhookRequest](): "CreateWebhookRequest",
+	reflect.TypeFor[taskclient.Webhook]():              "Webhook",
+	reflect.TypeFor[taskclient.WebhookDelivery]():      "WebhookDelivery",
+	reflect.TypeFor[taskclient.Error]():                "Problem",
+	reflect.TypeFor[taskclient.FieldError]():           "ProblemField",
+}
+
+// recordedCall - запрос клиента и код ответа сервера.
+type recordedCall struct {
+	method, path string
+	query        []string
+	header       http.Header
+	body         []byte
+	status       int
+}
+
+// recorder - транспорт, который запоминает запросы клиента.
+type recorder struct {
+	next  http.RoundTripper
+	mu    sync.Mutex
+	calls []recordedCall
+}
+
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	call := recordedCall{method: req.Method, path: req.URL.Path, header: req.Header.Clone()}
+	for key := range req.URL.Query() {
+		call.query = append(call.query, key)
+	}
+	if req.Body != nil {
+		call.body, _ = io.ReadAll(req.Body)
+		req.Body = io.NopCloser(bytes.NewReader(call.body))
+	}
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	call.status = resp.StatusCode
+	r.mu.Lock()
+	r.calls = append(r.calls, call)
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// specServer запускает API на памяти и miniredis.
+func specServer(t *testing.T) (*httptest.Server, *service.TaskService) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	s := &service.TaskService{Repo: repotest.NewMemoryRepo(), Redis: repository.NewRedisRepo(mr.Addr())}
+	srv := httptest.NewServer(httpHandler.NewHandler(s).InitRouter())
+	t.Cleanup(srv.Close)
+	return srv, s
+}
+
+// loadSpec получает спецификацию у запущенного сервера.
+func loadSpec(t *testing.T, baseURL string) spec {
+	t.Helper()
+	resp, err := http.Get(baseURL + "/openapi.json")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var s spec
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&s))
+	return s
+}
+
+// jsonFields - имена полей типа в JSON.
+func jsonFields(typ reflect.Type) []string {
+	var fields []string
+	for i := range typ.NumField() {
+		f := typ.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		switch {
+		case name == "-" || !f.IsExported():
+		case name == "":
+			fields = append(fields, f.Name)
+		default:
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+func TestClientTypesMatchSpec(t *testing.T) {
+	srv, _ := specServer(t)
+	s := loadSpec(t, srv.URL)
+
+	for typ, name := range clientSchemas {
+		schema, ok := s.Components.Schemas[name]
+		require.True(t, ok, "schema %s for %s", name, typ)
+		fields := jsonFields(typ)
+		for _, field := range fields {
+			assert.Contains(t, schema.Properties, field, "%s.%s is not in schema %s", typ, field, name)
+		}
+		for _, field := range schema.Required {
+			if typ.Name() == "CreateTaskRequest" && field == "user_id" {
+				continue
+			}
+			assert.Contains(t, fields, field, "required %s.%s is missing in %s", name, field, typ)
+		}
+	}
+}
+
+func TestClientRequestsMatchSpec(t *testing.T) {
+	srv, s := specServer(t)
+	rec := &recorder{next: srv.Client().Transport}
+	c := taskclient.New(srv.URL, &http.Client{Transport: rec})
+	ctx := context.Background()
+
+	// Вызываем каждый метод клиента
+	code, err := s.GenerateAuthCode(ctx, testUserID)
+	require.NoError(t, err)
+	tokens, err := c.Login(ctx, testUserID, code)
+	require.NoError(t, err)
+	c.Token = tokens.AccessToken
+	tokens, err = c.Refresh(ctx, tokens.RefreshToken)
+	require.NoError(t, err)
+	c.Token = tokens.AccessToken
+
+	deadline := taskclient.FormatDeadline(time.Now().Add(2 * time.Hour))
+	require.NoError(t, c.CreateTask(ctx, taskclient.CreateTaskRequest{Title: "Купить хлеб", Deadline: deadline, Project: "дом", IdempotencyKey: "k1"}))
+	page, err := c.ListTasks(ctx, taskclient.ListParams{
+		Status: "all", DueAfter: time.Now(), DueBefore: time.Now().AddDate(0, 0, 1),
+		Query: "хлеб", Tag: "дом", Project: "дом", Sort: "-created", Limit: 1, Cursor: "",
+	})
+	require.NoError(t, err)
+	_, err = c.ListTasks(ctx, taskclient.ListParams{Limit: 1})
+	require.NoError(t, err)
+	page, err = c.ListTasks(ctx, taskclient.ListParams{})
+	require.NoError(t, err)
+	require.Len(t, page.Tasks, 1)
+	task, err := c.GetTask(ctx, page.Tasks[0].ID)
+	require.NoError(t, err)
+	title := "Купить батон"
+	task, err = c.UpdateTask(ctx, task.ID, taskclient.UpdateTaskRequest{Title: &title, IfVersion: task.Version})
+	require.NoError(t, err)
+	_, err = c.Batch(ctx, taskclient.BatchRequest{
+		IdempotencyKey: "k2",
+		Operations: []taskclient.BatchOperation{
+			{Op: "create", Title: &title, Deadline: &deadline},
+			{Op: "complete", ID: task.ID},
+		},
+	})
+	require.NoError(t, err)
+	task, err = c.GetTask(ctx, task.ID)
+	require.NoError(t, err)
+	require.NoError(t, c.DeleteTaskVersion(ctx, task.ID, task.Version))
+	page, err = c.ListTasks(ctx, taskclient.ListParams{})
+	require.NoError(t, err)
+	require.Len(t, page.Tasks, 1)
+	require.NoError(t, c.DeleteTask(ctx, page.Tasks[0].ID))
+	_, err = c.Stats(ctx, time.Now().AddDate(0, 0, -7), time.Now())
+	require.NoError(t, err)
+
+	hook, err := c.CreateWebhook(ctx, taskclient.CreateWebhookRequest{URL: "https://example.com/hook", Events: []string{"task.created"}})
+	require.NoError(t, err)
+	_, err = c.ListWebhooks(ctx)
+	require.NoError(t, err)
+	_, err = c.PingWebhook(ctx, hook.ID)
+	require.NoError(t, err)
+	_, err = c.WebhookDeliveries(ctx, hook.ID, 5)
+	require.NoError(t, err)
+	require.NoError(t, c.DeleteWebhook(ctx, hook.ID))
+	require.NoError(t, c.Logout(ctx, tokens.RefreshToken))
+
+	// Сверяем запросы со спецификацией
+	sp := loadSpec(t, srv.URL)
+	covered := make(map[string]bool)
+	for _, call := range rec.calls {
+		path, op, ok := sp.find(call.method, call.path)
+		if !assert.True(t, ok, "%s %s is not in openapi.json", call.method, call.path) {
+			continue
+		}
+		key := call.method + " " + path
+		covered[key] = true
+		assert.Contains(t, op.Responses, strconv.Itoa(call.status), "%s: response %d", key, call.status)
+
+		params := sp.params(op)
+		for _, name := range call.query {
+			assert.Equal(t, "query", params[name], "%s: query parameter %s", key, name)
+		}
+		for name := range call.header {
+			if standardHeaders[name] {
+				continue
+			}
+			assert.Equal(t, "header", params[strings.ToLower(name)], "%s: header %s", key, name)
+		}
+		if len(call.body) > 0 {
+			require.NotNil(t, op.RequestBody, "%s: request body", key)
+			ref := op.RequestBody.Content["application/json"].Schema.Ref
+			schema := sp.Components.Schemas[strings.TrimPrefix(ref, "#/components/schemas/")]
+			var body map[string]json.RawMessage
+			require.NoError(t, json.Unmarshal(call.body, &body))
+			for field := range body {
+				assert.Contains(t, schema.Properties, field, "%s: body field %s", key, field)
+			}
+		}
+	}
+
+	for path, ops := range sp.Paths {
+		for method := range ops {
+			key := strings.ToUpper(method) + " " + path
+			if !notInClient[key] {
+				assert.True(t, covered[key], "the client does not call %s", key)
+			}
+		}
+	}
+}
+
+// standardHeaders - заголовки, которые клиент ставит каждому запросу.
+var standardHeaders = map[string]bool{
+	"Authorization":   true,
+	"Content-Type":    true,
+	"Accept":          true,
+	"Accept-Language": true,
+	"User-Agent":      true,
+}
+
+// find ищет операцию для запроса. Путь без параметров важнее шаблона:
+// /tasks/batch, а не /tasks/{id}.
+func (s spec) find(method, path string) (string, specOperation, bool) {
+	method = strings.ToLower(method)
+	if op, ok := s.Paths[path][method]; ok {
+		return path, op, true
+	}
+	for template, ops := range s.Paths {
+		if op, ok := ops[method]; ok && matchTemplate(template, path) {
+			return template, op, true
+		}
+	}
+	return "", specOperation{}, false
+}
+
+// matchTemplate сравнивает путь с шаблоном по сегментам, {x} - любой сегмент.
+func matchTemplate(template, path string) bool {
+	want, got := strings.Split(template, "/"), strings.Split(path, "/")
+	if len(want) != len(got) {
+		return false
+	}
+	for i := range want {
+		if !strings.HasPrefix(want[i], "{") && want[i] != got[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// params - где передается каждый параметр операции: имя (заголовки в
+// нижнем регистре) -> query, header или path.
+func (s spec) params(op specOperation) map[string]string {
+	res := make(map[string]string)
+	for _, p := range op.Parameters {
+		if p.Ref != "" {
+			p = s.Components.Parameters[strings.TrimPrefix(p.Ref, "#/components/parameters/")]
+		}
+		name := p.Name
+		if p.In == "header" {
+			name = strings.ToLower(name)
+		}
+		res[name] = p.In
+	}
+	return res
+}
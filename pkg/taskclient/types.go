@@ -0,0 +1,217 @@
+package taskclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Типы запросов и ответов повторяют схемы из openapi.json сервера.
+
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Priority - приоритет задачи: -1 низкий, 0 обычный, 1 высокий.
+type Priority int
+
+const (
+	PriorityLow    Priority = -1
+	PriorityNormal Priority = 0
+	PriorityHigh   Priority = 1
+)
+
+type Task struct {
+	ID        int
+	UserID    int64
+	Title     string
+	Deadline  time.Time
+	Notified  bool
+	CreatedAt time.Time
+	Priority  Priority
+	// RemindBefore - за сколько минут до срока придет напоминание.
+	RemindBefore int
+	Tags         []string
+	CompletedAt  *time.Time
+	SourceURL    string
+	Project      string
+	// Version растет при каждом изменении задачи; ее можно передать
+	// в UpdateTaskRequest.IfVersion и DeleteTaskVersion.
+	Version   int
+	UpdatedAt time.Time
+}
+
+type TaskPage struct {
+	Tasks []Task `json:"tasks"`
+	// NextCursor пуст на последней странице.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// ListParams - фильтр GET /tasks. Пустые поля не передаются, и сервер
+// подставляет значения по умолчанию.
+type ListParams struct {
+	// Status - active (по умолчанию), completed или all.
+	Status    string
+	DueAfter  time.Time
+	DueBefore time.Time
+	Query     string
+	Tag       string
+	Project   string
+	// Sort - deadline (по умолчанию), -deadline, created или -created.
+	Sort   string
+	Limit  int
+	Cursor string
+}
+
+type CreateTaskRequest struct {
+	Title string `json:"title"`
+	// Deadline - срок в формате ДД.ММ.ГГГГ ЧЧ:ММ; его можно получить из
+	// time.Time через FormatDeadline.
+	Deadline string `json:"deadline"`
+	Project  string `json:"project,omitempty"`
+	// IdempotencyKey - необязательный ключ, с которым повтор запроса
+	// (например, после обрыва связи) не создаст вторую задачу.
+	IdempotencyKey string `json:"-"`
+}
+
+// UpdateTaskRequest - изменение задачи: nil-поля не меняются.
+type UpdateTaskRequest struct {
+	Title    *string   `json:"title,omitempty"`
+	Deadline *string   `json:"deadline,omitempty"`
+	Project  *string   `json:"project,omitempty"`
+	Priority *Priority `json:"priority,omitempty"`
+	// IfVersion - версия задачи (Task.Version), которую меняет запрос. Если
+	// задачу с тех пор изменили, сервер ответит 412, см. IsPreconditionFailed.
+	// 0 - изменить последнюю версию.
+	IfVersion int `json:"-"`
+}
+
+// DeadlineLayout - формат срока в CreateTaskRequest.
+const DeadlineLayout = "02.01.2006 15:04"
+
+// FormatDeadline переводит время в формат срока задачи.
+func FormatDeadline(t time.Time) string {
+	return t.Format(DeadlineLayout)
+}
+
+// BatchRequest - пакет операций над задачами.
+type BatchRequest struct {
+	// Mode - "atomic" (по умолчанию, все или ничего) или "best_effort".
+	Mode       string           `json:"mode,omitempty"`
+	Operations []BatchOperation `json:"operations"`
+	// IdempotencyKey - необязательный ключ идемпотентности, как в CreateTaskRequest.
+	IdempotencyKey string `json:"-"`
+}
+
+// BatchOperation - create (нужны Title и Deadline), update (меняются только
+// заданные поля), delete или complete (нужен ID).
+type BatchOperation struct {
+	Op       string    `json:"op"`
+	ID       int       `json:"id,omitempty"`
+	Title    *string   `json:"title,omitempty"`
+	Deadline *string   `json:"deadline,omitempty"`
+	Project  *string   `json:"project,omitempty"`
+	Priority *Priority `json:"priority,omitempty"`
+}
+
+type BatchResponse struct {
+	Mode string `json:"mode"`
+	// Committed - false, если пакет atomic откатился.
+	Committed bool          `json:"committed"`
+	Results   []BatchResult `json:"results"`
+}
+
+type BatchResult struct {
+	Index int `json:"index"`
+	// Status - ok, failed, rolled_back или skipped.
+	Status string `json:"status"`
+	ID     int    `json:"id,omitempty"`
+	Error  *Error `json:"error,omitempty"`
+}
+
+type Stats struct {
+	From               time.Time   `json:"from"`
+	To                 time.Time   `json:"to"`
+	Created            int         `json:"created"`
+	Completed          int         `json:"completed"`
+	CompletedOnTime    int         `json:"completed_on_time"`
+	OnTimeRate         float64     `json:"on_time_rate"`
+	AvgLatenessMinutes float64     `json:"avg_lateness_minutes"`
+	Overdue            int         `json:"overdue"`
+	CurrentStreak      int         `json:"current_streak"`
+	LongestStreak      int         `json:"longest_streak"`
+	Weeks              []WeekStats `json:"weeks"`
+}
+
+type WeekStats struct {
+	Start     time.Time `json:"start"`
+	Created   int       `json:"created"`
+	Completed int       `json:"completed"`
+}
+
+type CreateWebhookRequest struct {
+	URL string `json:"url"`
+	// Secret - ключ подписи, не короче 16 символов; "" - сервер создаст его сам.
+	Secret string `json:"secret,omitempty"`
+	// Events - типы событий (task.created, task.updated, task.completed,
+	// task.deleted, task.reminded); пустой список - все события.
+	Events []string `json:"events,omitempty"`
+}
+
+type Webhook struct {
+	ID     int      `json:"id"`
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	// Secret есть только в ответе CreateWebhook.
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WebhookDelivery struct {
+	ID    int64  `json:"id"`
+	Event string `json:"event"`
+	// Status - pending, delivered или failed.
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	ResponseCode  int             `json:"response_code,omitempty"`
+	Error         string          `json:"error,omitempty"`
+	NextAttemptAt *time.Time      `json:"next_attempt_at,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	DeliveredAt   *time.Time      `json:"delivered_at,omitempty"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+// Error - ответ сервера с ошибкой (RFC 7807).
+type Error struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Errors   []FieldError `json:"errors,omitempty"`
+	// RetryAfter - через сколько можно повторить запрос после 429.
+	RetryAfter time.Duration `json:"-"`
+}
+
+type FieldError struct {
+	Field  string `json:"field"`
+	Detail string `json:"detail"`
+}
+
+// IsPreconditionFailed сообщает, что задачу изменили после того, как была
+// получена версия из UpdateTaskRequest.IfVersion или DeleteTaskVersion.
+// Задачу стоит загрузить заново.
+func IsPreconditionFailed(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Status == http.StatusPreconditionFailed
+}
+
+func (e *Error) Error() string {
+	if e.Detail == "" {
+		return fmt.Sprintf("taskclient: %d %s", e.Status, e.Title)
+	}
+	return fmt.Sprintf("taskclient: %d %s: %s", e.Status, e.Title, e.Detail)
+}
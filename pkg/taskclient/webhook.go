@@ -0,0 +1,45 @@
+package taskclient
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrWebhookSignature - запрос вебхука не подписан секретом подписки или
+// его метка времени вне допустимого окна.
+var ErrWebhookSignature = errors.New("taskclient: invalid webhook signature")
+
+// VerifyWebhook проверяет запрос вебхука с телом body: подпись
+// X-Webhook-Signature секретом secret и то, что X-Webhook-Timestamp
+// отличается от текущего времени не больше чем на tolerance (0 - без
+// проверки времени). Подпись - HMAC-SHA256 от "<timestamp>.<body>" в hex.
+func VerifyWebhook(header http.Header, body []byte, secret string, tolerance time.Duration) error {
+	ts := header.Get("X-Webhook-Timestamp")
+	sig, ok := strings.CutPrefix(header.Get("X-Webhook-Signature"), "sha256=")
+	if !ok || ts == "" {
+		return ErrWebhookSignature
+	}
+	if tolerance > 0 {
+		unix, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil || time.Since(time.Unix(unix, 0)).Abs() > tolerance {
+			return ErrWebhookSignature
+		}
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return ErrWebhookSignature
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts + "."))
+	mac.Write(body)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return ErrWebhookSignature
+	}
+	return nil
+}
@@ -6,12 +6,49 @@ import (
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
+// Messenger - исходящие операции бота: отправка и редактирование
+// сообщений с клавиатурами и ответы на нажатия inline-кнопок.
+type Messenger interface {
+	SendMessage(chatID int64, text string) error
+	// SendWithKeyboard отправляет сообщение с клавиатурой
+	// (tgbotapi.ReplyKeyboardMarkup или tgbotapi.InlineKeyboardMarkup).
+	SendWithKeyboard(chatID int64, text string, keyboard any) error
+	EditMessageText(chatID int64, messageID int, text string, keyboard *tgbotapi.InlineKeyboardMarkup) error
+	// SendFormatted отправляет размеченное сообщение (см. Format);
+	// keyboard может быть nil.
+	SendFormatted(chatID int64, msg Formatted, keyboard any) error
+	EditFormatted(chatID int64, messageID int, msg Formatted, keyboard *tgbotapi.InlineKeyboardMarkup) error
+	AnswerCallback(callbackID, text string) error
+	// AnswerInlineQuery отвечает на inline-запрос (@bot текст) списком результатов.
+	AnswerInlineQuery(queryID string, results []any) error
+}
+
+// Bot - Messenger вместе с получением апдейтов (long polling или webhook).
+type Bot interface {
+	Messenger
+	GetUpdatesChan(config tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel
+	StopReceivingUpdates()
+	SetWebhook(url, secret string) error
+	DeleteWebhook() error
+	// SetMyCommands задает меню команд для области scope и языка
+	// languageCode ("" - для всех языков без отдельного меню).
+	SetMyCommands(scope tgbotapi.BotCommandScope, languageCode string, commands []tgbotapi.BotCommand) error
+}
+
 type Client struct {
 	bot *tgbotapi.BotAPI
 }
 
+var _ Bot = (*Client)(nil)
+
 func NewClient(tg_token string) (*Client, error) {
-	bot, err := tgbotapi.NewBotAPI(tg_token)
+	return NewClientWithEndpoint(tg_token, tgbotapi.APIEndpoint)
+}
+
+// NewClientWithEndpoint создает клиента для нестандартного адреса Bot API,
+// например локального сервера или фейкового сервера в тестах.
+func NewClientWithEndpoint(tg_token, endpoint string) (*Client, error) {
+	bot, err := tgbotapi.NewBotAPIWithAPIEndpoint(tg_token, endpoint)
 	if err != nil {
 		return nil, err
 	}
@@ -25,12 +62,87 @@ func (c *Client) SendMessage(chatID int64, text string) error {
 	return err
 }
 
-func (c *Client) SendMessageWithMarkup(chatID int64, msg tgbotapi.MessageConfig) {
+func (c *Client) SendWithKeyboard(chatID int64, text string, keyboard any) error {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = keyboard
 	if _, err := c.bot.Send(msg); err != nil {
 		slog.Error("error sending message with buttons", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (c *Client) EditMessageText(chatID int64, messageID int, text string, keyboard *tgbotapi.InlineKeyboardMarkup) error {
+	msg := tgbotapi.NewEditMessageText(chatID, messageID, text)
+	msg.ReplyMarkup = keyboard
+	_, err := c.bot.Send(msg)
+	return err
+}
+
+func (c *Client) SendFormatted(chatID int64, msg Formatted, keyboard any) error {
+	m := tgbotapi.NewMessage(chatID, msg.Text)
+	m.ParseMode = string(msg.Mode)
+	if keyboard != nil {
+		m.ReplyMarkup = keyboard
 	}
+	_, err := c.bot.Send(m)
+	return err
+}
+
+func (c *Client) EditFormatted(chatID int64, messageID int, msg Formatted, keyboard *tgbotapi.InlineKeyboardMarkup) error {
+	m := tgbotapi.NewEditMessageText(chatID, messageID, msg.Text)
+	m.ParseMode = string(msg.Mode)
+	m.ReplyMarkup = keyboard
+	_, err := c.bot.Send(m)
+	return err
+}
+
+func (c *Client) AnswerCallback(callbackID, text string) error {
+	_, err := c.bot.Request(tgbotapi.NewCallback(callbackID, text))
+	return err
+}
+
+// inlineCacheTime - сколько секунд телеграм кэширует ответ на inline-запрос.
+// Результаты зависят от задач пользователя, поэтому кэш короткий и личный.
+const inlineCacheTime = 5
+
+func (c *Client) AnswerInlineQuery(queryID string, results []any) error {
+	_, err := c.bot.Request(tgbotapi.InlineConfig{
+		InlineQueryID: queryID,
+		Results:       results,
+		CacheTime:     inlineCacheTime,
+		IsPersonal:    true,
+	})
+	return err
+}
+
+func (c *Client) GetUpdatesChan(config tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel {
+	return c.bot.GetUpdatesChan(config)
+}
+
+func (c *Client) StopReceivingUpdates() {
+	c.bot.StopReceivingUpdates()
 }
 
 func (c *Client) GetBotAPI() *tgbotapi.BotAPI {
 	return c.bot
 }
+
+// SetWebhook регистрирует webhook. Телеграм будет передавать secret
+// в заголовке X-Telegram-Bot-Api-Secret-Token каждого запроса.
+func (c *Client) SetWebhook(url, secret string) error {
+	params := tgbotapi.Params{"url": url}
+	params.AddNonEmpty("secret_token", secret)
+	_, err := c.bot.MakeRequest("setWebhook", params)
+	return err
+}
+
+func (c *Client) DeleteWebhook() error {
+	_, err := c.bot.Request(tgbotapi.DeleteWebhookConfig{})
+	return err
+}
+
+func (c *Client) SetMyCommands(scope tgbotapi.BotCommandScope, languageCode string, commands []tgbotapi.BotCommand) error {
+	_, err := c.bot.Request(tgbotapi.NewSetMyCommandsWithScopeAndLanguage(scope, languageCode, commands...))
+	return err
+}
@@ -0,0 +1,182 @@
+package telegram
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ParseMode - режим разметки сообщения. Пустой режим - обычный текст.
+type ParseMode string
+
+const (
+	ModePlain      ParseMode = ""
+	ModeHTML       ParseMode = tgbotapi.ModeHTML
+	ModeMarkdownV2 ParseMode = tgbotapi.ModeMarkdownV2
+)
+
+// Formatted - текст сообщения, размеченный в режиме Mode. Получается из
+// Format или Builder, поэтому пользовательский текст в нем уже экранирован.
+type Formatted struct {
+	Text string
+	Mode ParseMode
+}
+
+var (
+	htmlEscaper       = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	markdownEscaper   = newMarkdownEscaper("_*[]()~`>#+-=|{}.!\\")
+	markdownCodeEsc   = newMarkdownEscaper("`\\")
+	markdownURLEscape = newMarkdownEscaper(")\\")
+)
+
+func newMarkdownEscaper(chars string) *strings.Replacer {
+	pairs := make([]string, 0, 2*len(chars))
+	for _, c := range chars {
+		pairs = append(pairs, string(c), `\`+string(c))
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// Escape экранирует текст, чтобы Telegram показал его как есть.
+func (m ParseMode) Escape(s string) string {
+	switch m {
+	case ModeHTML:
+		return htmlEscaper.Replace(s)
+	case ModeMarkdownV2:
+		return markdownEscaper.Replace(s)
+	default:
+		return s
+	}
+}
+
+type spanStyle int
+
+const (
+	styleBold spanStyle = iota
+	styleItalic
+	styleCode
+	styleLink
+)
+
+// Span - фрагмент пользовательского текста со стилем. Текст экранируется
+// при выводе, поэтому в Span можно передавать что угодно.
+type Span struct {
+	style spanStyle
+	text  string
+	url   string
+}
+
+func Bold(s string) Span   { return Span{style: styleBold, text: s} }
+func Italic(s string) Span { return Span{style: styleItalic, text: s} }
+func Code(s string) Span   { return Span{style: styleCode, text: s} }
+
+// Link - ссылка с текстом s. В обычном тексте выводится как "s (url)".
+func Link(s, url string) Span { return Span{style: styleLink, text: s, url: url} }
+
+func (s Span) render(m ParseMode) string {
+	switch m {
+	case ModeHTML:
+		text := htmlEscaper.Replace(s.text)
+		switch s.style {
+		case styleBold:
+			return "<b>" + text + "</b>"
+		case styleItalic:
+			return "<i>" + text + "</i>"
+		case styleCode:
+			return "<code>" + text + "</code>"
+		default:
+			return `<a href="` + strings.ReplaceAll(htmlEscaper.Replace(s.url), `"`, "&quot;") + `">` + text + "</a>"
+		}
+	case ModeMarkdownV2:
+		switch s.style {
+		case styleBold:
+			return "*" + markdownEscaper.Replace(s.text) + "*"
+		case styleItalic:
+			return "_" + markdownEscaper.Replace(s.text) + "_"
+		case styleCode:
+			return "`" + markdownCodeEsc.Replace(s.text) + "`"
+		default:
+			return "[" + markdownEscaper.Replace(s.text) + "](" + markdownURLEscape.Replace(s.url) + ")"
+		}
+	default:
+		if s.style == styleLink && s.url != s.text {
+			return s.text + " (" + s.url + ")"
+		}
+		return s.text
+	}
+}
+
+// verbRe находит глаголы fmt в шаблоне. Индексы аргументов ("%[1]d")
+// и ширина через "*" не поддерживаются.
+var verbRe = regexp.MustCompile(`%[-+# 0]*\d*(?:\.\d+)?[a-zA-Z%]`)
+
+// Format подставляет args в шаблон format и возвращает сообщение в режиме m.
+// Шаблон и строки из args считаются обычным текстом и экранируются, Span
+// выводится со своим стилем, а Formatted того же режима вставляется как есть.
+// Поэтому шаблоном может служить переведенная строка без разметки.
+// Лишние аргументы отбрасываются, недостающие оставляют глагол в тексте.
+func Format(m ParseMode, format string, args ...any) Formatted {
+	var b strings.Builder
+	last, next := 0, 0
+	for _, loc := range verbRe.FindAllStringIndex(format, -1) {
+		b.WriteString(m.Escape(format[last:loc[0]]))
+		last = loc[1]
+		verb := format[loc[0]:loc[1]]
+		if verb == "%%" {
+			b.WriteString(m.Escape("%"))
+			continue
+		}
+		if next >= len(args) {
+			// Аргумента не хватило - оставляем глагол как есть
+			b.WriteString(m.Escape(verb))
+			continue
+		}
+		b.WriteString(formatArg(m, verb, args[next]))
+		next++
+	}
+	b.WriteString(m.Escape(format[last:]))
+	return Formatted{Text: b.String(), Mode: m}
+}
+
+func formatArg(m ParseMode, verb string, arg any) string {
+	switch v := arg.(type) {
+	case Span:
+		return v.render(m)
+	case Formatted:
+		if v.Mode == m {
+			return v.Text
+		}
+		return m.Escape(v.Text)
+	default:
+		return m.Escape(fmt.Sprintf(verb, arg))
+	}
+}
+
+// Builder собирает длинное сообщение по частям в одном режиме разметки.
+type Builder struct {
+	mode ParseMode
+	b    strings.Builder
+}
+
+func NewBuilder(mode ParseMode) *Builder {
+	return &Builder{mode: mode}
+}
+
+// Text добавляет обычный текст.
+func (b *Builder) Text(s string) *Builder {
+	b.b.WriteString(b.mode.Escape(s))
+	return b
+}
+
+// Format добавляет текст по шаблону (см. Format).
+func (b *Builder) Format(format string, args ...any) *Builder {
+	b.b.WriteString(Format(b.mode, format, args...).Text)
+	return b
+}
+
+// Message возвращает собранное сообщение.
+func (b *Builder) Message() Formatted {
+	return Formatted{Text: b.b.String(), Mode: b.mode}
+}
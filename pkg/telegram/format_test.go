@@ -0,0 +1,57 @@
+package telegram
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscape(t *testing.T) {
+	assert.Equal(t, "a &lt;b&gt; &amp; _c_", ModeHTML.Escape("a <b> & _c_"))
+	assert.Equal(t, `1\. a\_b \*c\* \(d\) \\`, ModeMarkdownV2.Escape(`1. a_b *c* (d) \`))
+	assert.Equal(t, "<b>", ModePlain.Escape("<b>"))
+}
+
+func TestFormat(t *testing.T) {
+	title := "<script> & *bold*_"
+
+	html := Format(ModeHTML, "Задача: %s, %d%%", Bold(title), 50)
+	assert.Equal(t, "Задача: <b>&lt;script&gt; &amp; *bold*_</b>, 50%", html.Text)
+	assert.Equal(t, ModeHTML, html.Mode)
+
+	md := Format(ModeMarkdownV2, "Задача: %s!", Bold(title))
+	assert.Equal(t, `Задача: *<script\> & \*bold\*\_*\!`, md.Text)
+
+	plain := Format(ModePlain, "%s", Link("site", "https://example.com"))
+	assert.Equal(t, "site (https://example.com)", plain.Text)
+}
+
+func TestFormat_Spans(t *testing.T) {
+	assert.Equal(t, `<a href="https://e.com/?a=1&amp;b=&quot;2&quot;">x&lt;y</a>`,
+		Format(ModeHTML, "%s", Link("x<y", `https://e.com/?a=1&b="2"`)).Text)
+	assert.Equal(t, `[x\.y](https://e.com/(1\))`,
+		Format(ModeMarkdownV2, "%s", Link("x.y", "https://e.com/(1)")).Text)
+	assert.Equal(t, "`a\\`b_c`", Format(ModeMarkdownV2, "%s", Code("a`b_c")).Text)
+	assert.Equal(t, "<i>a</i> <code>&lt;b&gt;</code>", Format(ModeHTML, "%s %s", Italic("a"), Code("<b>")).Text)
+}
+
+func TestFormat_NestedFormatted(t *testing.T) {
+	inner := Format(ModeHTML, "%s", Bold("a<b"))
+	assert.Equal(t, "[<b>a&lt;b</b>]", Format(ModeHTML, "[%s]", inner).Text)
+
+	// Сообщение другого режима вставляется как обычный текст
+	assert.Equal(t, `\*a\*`, Format(ModeMarkdownV2, "%s", Formatted{Text: "*a*"}).Text)
+}
+
+func TestFormat_ArgsMismatch(t *testing.T) {
+	assert.Equal(t, "a &lt; %s", Format(ModeHTML, "a < %s").Text)
+	assert.Equal(t, "a", Format(ModeHTML, "a", "extra").Text)
+}
+
+func TestBuilder(t *testing.T) {
+	msg := NewBuilder(ModeHTML).
+		Text("1 < 2\n").
+		Format("%s: %v", Bold("итого"), 3).
+		Message()
+	assert.Equal(t, Formatted{Text: "1 &lt; 2\n<b>итого</b>: 3", Mode: ModeHTML}, msg)
+}
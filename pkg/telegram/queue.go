@@ -0,0 +1,488 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Limits - ограничения частоты исходящих сообщений. Телеграм отвечает 429,
+// если бот отправляет больше ~30 сообщений в секунду всего, больше одного
+// сообщения в секунду в личный чат или больше 20 в минуту в группу.
+type Limits struct {
+	// Global - сообщений в секунду во все чаты вместе.
+	Global float64
+	// PerChat и PerGroup - сообщений в секунду в один личный чат и в одну группу.
+	PerChat  float64
+	PerGroup float64
+	// ChatBurst - сколько сообщений подряд можно отправить в чат без ожидания:
+	// ответ на нажатие кнопки часто состоит из нового сообщения и правки старого.
+	ChatBurst int
+	// MaxRetries - сколько раз повторять отправку после ответа 429.
+	MaxRetries int
+}
+
+var DefaultLimits = Limits{
+	Global:     30,
+	PerChat:    1,
+	PerGroup:   20.0 / 60,
+	ChatBurst:  3,
+	MaxRetries: 3,
+}
+
+// Priority - очередь, в которую попадает сообщение.
+type Priority int
+
+const (
+	// PriorityInteractive - ответы пользователю, который ждет реакции бота.
+	PriorityInteractive Priority = iota
+	// PriorityBulk - рассылки вроде напоминаний: их можно отложить.
+	PriorityBulk
+)
+
+// ErrQueueClosed возвращается отправкой после остановки очереди.
+var ErrQueueClosed = errors.New("telegram: send queue is closed")
+
+// ErrChatQueueFull возвращается отправкой через Async, если у чата уже
+// maxChatQueued неотправленных сообщений.
+var ErrChatQueueFull = errors.New("telegram: too many messages queued for the chat")
+
+// maxChatQueued - сколько сообщений Async может ждать отправки в один чат.
+// Ограничивает очередь, когда вызывающие не ждут отправки.
+const maxChatQueued = 32
+
+// Queue - Bot, который отправляет и редактирует сообщения через очередь с
+// учетом Limits. Отправка блокирует вызывающего до ответа телеграма, поэтому
+// ошибки возвращаются как раньше; Async отправляет, не дожидаясь очереди.
+// Сообщения одного чата уходят по порядку, а интерактивные ответы обгоняют
+// рассылку. Остальные методы Bot вызываются напрямую.
+//
+// Блокирующих отправок в очереди не больше, чем горутин, которые их ждут:
+// их число ограничивают вызывающие (отправители напоминаний). Отправки
+// Async ограничены maxChatQueued на чат.
+type Queue struct {
+	Bot
+	limits Limits
+
+	mu      sync.Mutex
+	pending [2][]*sendJob
+	chats   map[int64]*chatState
+	global  tokenBucket
+	closed  bool
+	swept   time.Time
+	wake    chan struct{}
+}
+
+var _ Bot = (*Queue)(nil)
+
+type sendJob struct {
+	chatID   int64
+	priority Priority
+	send     func() error
+	retries  int
+	// done получает результат отправки; nil - отправка через Async,
+	// ошибка только записывается в лог.
+	done chan error
+}
+
+type chatState struct {
+	bucket tokenBucket
+	// busy - сообщение в чат уже отправляется; следующее ждет ответа,
+	// чтобы не нарушить порядок.
+	busy bool
+	// blockedUntil - телеграм попросил не писать в чат до этого времени.
+	blockedUntil time.Time
+	// queued - сколько сообщений чата ждет в pending.
+	queued int
+}
+
+// NewQueue создает очередь поверх bot. Отправка начинается после запуска Run.
+func NewQueue(bot Bot, limits Limits) *Queue {
+	now := time.Now()
+	return &Queue{
+		Bot:    bot,
+		limits: limits,
+		chats:  make(map[int64]*chatState),
+		global: newTokenBucket(limits.Global, limits.Global, now),
+		swept:  now,
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// Run отправляет сообщения из очереди до отмены ctx. После остановки
+// ожидающие и новые отправки завершаются с ErrQueueClosed.
+func (q *Queue) Run(ctx context.Context) {
+	defer q.close()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		q.mu.Lock()
+		job, wait := q.next(time.Now())
+		q.mu.Unlock()
+		if job != nil {
+			go q.send(job)
+			continue
+		}
+
+		var tick <-chan time.Time
+		if wait > 0 {
+			timer.Reset(wait)
+			tick = timer.C
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.wake:
+		case <-tick:
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+	}
+}
+
+// Bulk возвращает Messenger, который отправляет сообщения с низким приоритетом.
+func (q *Queue) Bulk() Messenger {
+	return bulkMessenger{q}
+}
+
+// Async возвращает Bot, который ставит интерактивные сообщения в очередь
+// и сразу возвращается. Так обработчик апдейта не ждет, пока у чата
+// восстановится лимит, и не задерживает другие чаты своего воркера.
+// Ошибки отправки записываются в лог; сама Async-отправка возвращает только
+// ErrQueueClosed и ErrChatQueueFull.
+func (q *Queue) Async() Bot {
+	return asyncBot{q}
+}
+
+// Flush ждет, пока очередь отправит все сообщения, но не дольше, чем
+// позволяет ctx. Нужен перед остановкой, чтобы не потерять ответы Async.
+func (q *Queue) Flush(ctx context.Context) error {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		if q.idle() {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// idle - в очереди нет ни ожидающих, ни отправляемых сообщений.
+func (q *Queue) idle() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return true
+	}
+	for _, chat := range q.chats {
+		if chat.busy || chat.queued > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// enqueue ставит отправку в очередь и ждет ее результата.
+func (q *Queue) enqueue(priority Priority, chatID int64, send func() error) error {
+	job := &sendJob{chatID: chatID, priority: priority, send: send, done: make(chan error, 1)}
+	if err := q.push(job); err != nil {
+		return err
+	}
+	return <-job.done
+}
+
+// enqueueAsync ставит отправку в очередь без ожидания.
+func (q *Queue) enqueueAsync(chatID int64, send func() error) error {
+	return q.push(&sendJob{chatID: chatID, priority: PriorityInteractive, send: send})
+}
+
+func (q *Queue) push(job *sendJob) error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return ErrQueueClosed
+	}
+	chat := q.chat(job.chatID, time.Now())
+	if job.done == nil && chat.queued >= maxChatQueued {
+		q.mu.Unlock()
+		return ErrChatQueueFull
+	}
+	chat.queued++
+	q.pending[job.priority] = append(q.pending[job.priority], job)
+	q.mu.Unlock()
+	q.notify()
+	return nil
+}
+
+func (q *Queue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// next выбирает первое сообщение, которое можно отправить сейчас, начиная
+// с интерактивных. Если такого нет - возвращает, сколько ждать до следующей
+// проверки (0 - ждать, пока освободится чат или придет новое сообщение).
+func (q *Queue) next(now time.Time) (*sendJob, time.Duration) {
+	q.sweep(now)
+	if len(q.pending[PriorityInteractive])+len(q.pending[PriorityBulk]) == 0 {
+		return nil, 0
+	}
+	if wait := q.global.wait(now); wait > 0 {
+		return nil, wait
+	}
+
+	var minWait time.Duration
+	later := func(d time.Duration) {
+		if minWait == 0 || d < minWait {
+			minWait = d
+		}
+	}
+	for p := range q.pending {
+		for i, job := range q.pending[p] {
+			chat := q.chat(job.chatID, now)
+			if chat.busy {
+				continue
+			}
+			if chat.blockedUntil.After(now) {
+				later(chat.blockedUntil.Sub(now))
+				continue
+			}
+			if wait := chat.bucket.wait(now); wait > 0 {
+				later(wait)
+				continue
+			}
+			q.pending[p] = append(q.pending[p][:i], q.pending[p][i+1:]...)
+			chat.queued--
+			q.global.take()
+			chat.bucket.take()
+			chat.busy = true
+			return job, 0
+		}
+	}
+	return nil, minWait
+}
+
+func (q *Queue) chat(chatID int64, now time.Time) *chatState {
+	chat, ok := q.chats[chatID]
+	if !ok {
+		rate := q.limits.PerChat
+		if chatID < 0 {
+			rate = q.limits.PerGroup
+		}
+		chat = &chatState{bucket: newTokenBucket(rate, float64(q.limits.ChatBurst), now)}
+		q.chats[chatID] = chat
+	}
+	return chat
+}
+
+// sweep раз в минуту забывает чаты, которые уже могут получить ChatBurst
+// сообщений подряд: их состояние ничем не отличается от нового.
+func (q *Queue) sweep(now time.Time) {
+	if now.Sub(q.swept) < time.Minute {
+		return
+	}
+	q.swept = now
+	for id, chat := range q.chats {
+		if !chat.busy && chat.queued == 0 && !chat.blockedUntil.After(now) && chat.bucket.full(now) {
+			delete(q.chats, id)
+		}
+	}
+}
+
+// send выполняет отправку. Если телеграм ответил 429, чат блокируется на
+// retry_after, а сообщение возвращается в начало своей очереди.
+func (q *Queue) send(job *sendJob) {
+	err := job.send()
+
+	q.mu.Lock()
+	chat := q.chat(job.chatID, time.Now())
+	chat.busy = false
+	retry := retryAfter(err)
+	if retry > 0 && job.retries < q.limits.MaxRetries && !q.closed {
+		slog.Warn("Telegram rate limit exceeded", "chat_id", job.chatID, "retry_after", retry)
+		job.retries++
+		chat.blockedUntil = time.Now().Add(retry)
+		chat.queued++
+		q.pending[job.priority] = append([]*sendJob{job}, q.pending[job.priority]...)
+		job = nil
+	}
+	q.mu.Unlock()
+	q.notify()
+
+	switch {
+	case job == nil:
+	case job.done != nil:
+		job.done <- err
+	case err != nil:
+		slog.Error("Telegram send failed", "chat_id", job.chatID, "error", err)
+	}
+}
+
+func (q *Queue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	for p := range q.pending {
+		for _, job := range q.pending[p] {
+			if job.done != nil {
+				job.done <- ErrQueueClosed
+			} else {
+				slog.Warn("Telegram message dropped, send queue is closed", "chat_id", job.chatID)
+			}
+		}
+		q.pending[p] = nil
+	}
+}
+
+// retryAfter - сколько ждать по ответу 429, или 0 для других ошибок.
+func retryAfter(err error) time.Duration {
+	var apiErr *tgbotapi.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != 429 {
+		return 0
+	}
+	if apiErr.RetryAfter <= 0 {
+		return time.Second
+	}
+	return time.Duration(apiErr.RetryAfter) * time.Second
+}
+
+func (q *Queue) SendMessage(chatID int64, text string) error {
+	return q.enqueue(PriorityInteractive, chatID, func() error { return q.Bot.SendMessage(chatID, text) })
+}
+
+func (q *Queue) SendWithKeyboard(chatID int64, text string, keyboard any) error {
+	return q.enqueue(PriorityInteractive, chatID, func() error { return q.Bot.SendWithKeyboard(chatID, text, keyboard) })
+}
+
+func (q *Queue) EditMessageText(chatID int64, messageID int, text string, keyboard *tgbotapi.InlineKeyboardMarkup) error {
+	return q.enqueue(PriorityInteractive, chatID, func() error {
+		return q.Bot.EditMessageText(chatID, messageID, text, keyboard)
+	})
+}
+
+func (q *Queue) SendFormatted(chatID int64, msg Formatted, keyboard any) error {
+	return q.enqueue(PriorityInteractive, chatID, func() error { return q.Bot.SendFormatted(chatID, msg, keyboard) })
+}
+
+func (q *Queue) EditFormatted(chatID int64, messageID int, msg Formatted, keyboard *tgbotapi.InlineKeyboardMarkup) error {
+	return q.enqueue(PriorityInteractive, chatID, func() error {
+		return q.Bot.EditFormatted(chatID, messageID, msg, keyboard)
+	})
+}
+
+// asyncBot - Queue, которая не ждет отправки интерактивных сообщений.
+type asyncBot struct {
+	*Queue
+}
+
+func (a asyncBot) SendMessage(chatID int64, text string) error {
+	return a.enqueueAsync(chatID, func() error { return a.Bot.SendMessage(chatID, text) })
+}
+
+func (a asyncBot) SendWithKeyboard(chatID int64, text string, keyboard any) error {
+	return a.enqueueAsync(chatID, func() error { return a.Bot.SendWithKeyboard(chatID, text, keyboard) })
+}
+
+func (a asyncBot) EditMessageText(chatID int64, messageID int, text string, keyboard *tgbotapi.InlineKeyboardMarkup) error {
+	return a.enqueueAsync(chatID, func() error { return a.Bot.EditMessageText(chatID, messageID, text, keyboard) })
+}
+
+func (a asyncBot) SendFormatted(chatID int64, msg Formatted, keyboard any) error {
+	return a.enqueueAsync(chatID, func() error { return a.Bot.SendFormatted(chatID, msg, keyboard) })
+}
+
+func (a asyncBot) EditFormatted(chatID int64, messageID int, msg Formatted, keyboard *tgbotapi.InlineKeyboardMarkup) error {
+	return a.enqueueAsync(chatID, func() error { return a.Bot.EditFormatted(chatID, messageID, msg, keyboard) })
+}
+
+// bulkMessenger - отправка через Queue с приоритетом PriorityBulk. Ответы
+// на нажатия кнопок и inline-запросы не ограничиваются и уходят сразу.
+type bulkMessenger struct {
+	q *Queue
+}
+
+func (b bulkMessenger) SendMessage(chatID int64, text string) error {
+	return b.q.enqueue(PriorityBulk, chatID, func() error { return b.q.Bot.SendMessage(chatID, text) })
+}
+
+func (b bulkMessenger) SendWithKeyboard(chatID int64, text string, keyboard any) error {
+	return b.q.enqueue(PriorityBulk, chatID, func() error { return b.q.Bot.SendWithKeyboard(chatID, text, keyboard) })
+}
+
+func (b bulkMessenger) EditMessageText(chatID int64, messageID int, text string, keyboard *tgbotapi.InlineKeyboardMarkup) error {
+	return b.q.enqueue(PriorityBulk, chatID, func() error {
+		return b.q.Bot.EditMessageText(chatID, messageID, text, keyboard)
+	})
+}
+
+func (b bulkMessenger) SendFormatted(chatID int64, msg Formatted, keyboard any) error {
+	return b.q.enqueue(PriorityBulk, chatID, func() error { return b.q.Bot.SendFormatted(chatID, msg, keyboard) })
+}
+
+func (b bulkMessenger) EditFormatted(chatID int64, messageID int, msg Formatted, keyboard *tgbotapi.InlineKeyboardMarkup) error {
+	return b.q.enqueue(PriorityBulk, chatID, func() error {
+		return b.q.Bot.EditFormatted(chatID, messageID, msg, keyboard)
+	})
+}
+
+func (b bulkMessenger) AnswerCallback(callbackID, text string) error {
+	return b.q.AnswerCallback(callbackID, text)
+}
+
+func (b bulkMessenger) AnswerInlineQuery(queryID string, results []any) error {
+	return b.q.AnswerInlineQuery(queryID, results)
+}
+
+// tokenBucket - ограничитель частоты: rate токенов в секунду, не больше burst.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate, burst float64, now time.Time) tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return tokenBucket{rate: rate, burst: burst, tokens: burst, last: now}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	if now.After(b.last) {
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+	}
+}
+
+// wait - через сколько появится токен (0 - уже есть).
+func (b *tokenBucket) wait(now time.Time) time.Duration {
+	b.refill(now)
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+func (b *tokenBucket) take() {
+	b.tokens--
+}
+
+func (b *tokenBucket) full(now time.Time) bool {
+	b.refill(now)
+	return b.tokens >= b.burst
+}
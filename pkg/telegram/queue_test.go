@@ -0,0 +1,256 @@
+package telegram
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingBot записывает отправленные сообщения; send может подменить ответ.
+type recordingBot struct {
+	Bot
+
+	mu   sync.Mutex
+	sent []sentMessage
+	send func(chatID int64, text string) error
+}
+
+type sentMessage struct {
+	chatID int64
+	text   string
+	at     time.Time
+}
+
+func (b *recordingBot) SendMessage(chatID int64, text string) error {
+	b.mu.Lock()
+	b.sent = append(b.sent, sentMessage{chatID: chatID, text: text, at: time.Now()})
+	send := b.send
+	b.mu.Unlock()
+	if send != nil {
+		return send(chatID, text)
+	}
+	return nil
+}
+
+func (b *recordingBot) messages() []sentMessage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]sentMessage(nil), b.sent...)
+}
+
+func startQueue(t *testing.T, bot Bot, limits Limits) *Queue {
+	t.Helper()
+	q := NewQueue(bot, limits)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		q.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return q
+}
+
+func (q *Queue) pendingLen(p Priority) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending[p])
+}
+
+func waitPending(t *testing.T, q *Queue, p Priority, n int) {
+	t.Helper()
+	require.Eventually(t, func() bool { return q.pendingLen(p) == n }, time.Second, time.Millisecond)
+}
+
+func TestQueue_PerChatLimit(t *testing.T) {
+	bot := &recordingBot{}
+	q := startQueue(t, bot, Limits{Global: 1000, PerChat: 20, ChatBurst: 1})
+
+	var wg sync.WaitGroup
+	for _, chatID := range []int64{1, 1, 1, 2} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, q.SendMessage(chatID, "hi"))
+		}()
+	}
+	wg.Wait()
+
+	var first, last time.Time
+	for _, m := range bot.messages() {
+		if m.chatID != 1 {
+			continue
+		}
+		if first.IsZero() {
+			first = m.at
+		}
+		last = m.at
+	}
+	// Три сообщения в один чат при 20 в секунду - не быстрее 100 мс
+	assert.GreaterOrEqual(t, last.Sub(first), 90*time.Millisecond)
+}
+
+func TestQueue_KeepsChatOrder(t *testing.T) {
+	release := make(chan struct{})
+	bot := &recordingBot{send: func(chatID int64, text string) error {
+		if text == "1" {
+			<-release
+		}
+		return nil
+	}}
+	q := startQueue(t, bot, Limits{Global: 1000, PerChat: 1000, ChatBurst: 10})
+
+	var wg sync.WaitGroup
+	for i, text := range []string{"1", "2", "3"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.SendMessage(1, text)
+		}()
+		// Пока "1" отправляется, остальные ждут в очереди
+		if i == 0 {
+			require.Eventually(t, func() bool { return len(bot.messages()) == 1 }, time.Second, time.Millisecond)
+		} else {
+			waitPending(t, q, PriorityInteractive, i)
+		}
+	}
+	close(release)
+	wg.Wait()
+
+	var texts []string
+	for _, m := range bot.messages() {
+		texts = append(texts, m.text)
+	}
+	assert.Equal(t, []string{"1", "2", "3"}, texts)
+}
+
+func TestQueue_InteractiveBeforeBulk(t *testing.T) {
+	bot := &recordingBot{}
+	q := startQueue(t, bot, Limits{Global: 2, PerChat: 1000, ChatBurst: 1})
+	// Первые два сообщения забирают все токены, следующие ждут по 500 мс
+	require.NoError(t, q.Bulk().SendMessage(1, "reminder 0"))
+	require.NoError(t, q.Bulk().SendMessage(1, "reminder 1"))
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		q.Bulk().SendMessage(2, "reminder 2")
+	}()
+	waitPending(t, q, PriorityBulk, 1)
+	go func() {
+		defer wg.Done()
+		q.SendMessage(3, "reply")
+	}()
+	waitPending(t, q, PriorityInteractive, 1)
+	wg.Wait()
+
+	var texts []string
+	for _, m := range bot.messages() {
+		texts = append(texts, m.text)
+	}
+	assert.Equal(t, []string{"reminder 0", "reminder 1", "reply", "reminder 2"}, texts)
+}
+
+func TestQueue_RetryAfter(t *testing.T) {
+	bot := &recordingBot{}
+	calls := 0
+	bot.send = func(chatID int64, text string) error {
+		calls++
+		if calls == 1 {
+			return &tgbotapi.Error{Code: 429, Message: "Too Many Requests", ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 1}}
+		}
+		return nil
+	}
+	q := startQueue(t, bot, Limits{Global: 1000, PerChat: 1000, ChatBurst: 1, MaxRetries: 1})
+
+	require.NoError(t, q.SendMessage(1, "hi"))
+
+	sent := bot.messages()
+	require.Len(t, sent, 2)
+	assert.GreaterOrEqual(t, sent[1].at.Sub(sent[0].at), time.Second)
+}
+
+func TestQueue_ReturnsAPIError(t *testing.T) {
+	bot := &recordingBot{send: func(chatID int64, text string) error {
+		return &tgbotapi.Error{Code: 400, Message: "Bad Request"}
+	}}
+	q := startQueue(t, bot, DefaultLimits)
+
+	err := q.SendMessage(1, "hi")
+	assert.ErrorContains(t, err, "Bad Request")
+	assert.Len(t, bot.messages(), 1)
+}
+
+func TestQueue_Async(t *testing.T) {
+	bot := &recordingBot{send: func(chatID int64, text string) error {
+		if text == "fail" {
+			return &tgbotapi.Error{Code: 400, Message: "Bad Request"}
+		}
+		return nil
+	}}
+	q := startQueue(t, bot, Limits{Global: 1000, PerChat: 20, ChatBurst: 1})
+	async := q.Async()
+
+	// Отправка не ждет лимита чата, а ошибка не доходит до вызывающего
+	start := time.Now()
+	for _, text := range []string{"1", "fail", "3"} {
+		require.NoError(t, async.SendMessage(1, text))
+	}
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+
+	require.NoError(t, q.Flush(t.Context()))
+	var texts []string
+	for _, m := range bot.messages() {
+		texts = append(texts, m.text)
+	}
+	assert.Equal(t, []string{"1", "fail", "3"}, texts)
+}
+
+func TestQueue_AsyncChatLimit(t *testing.T) {
+	bot := &recordingBot{}
+	q := startQueue(t, bot, Limits{Global: 1000, PerChat: 0.001, ChatBurst: 1})
+	async := q.Async()
+
+	// Первое сообщение уходит сразу, следующие ждут лимита чата
+	require.NoError(t, async.SendMessage(1, "hi"))
+	require.Eventually(t, func() bool { return len(bot.messages()) == 1 }, time.Second, time.Millisecond)
+	for range maxChatQueued {
+		require.NoError(t, async.SendMessage(1, "hi"))
+	}
+	assert.ErrorIs(t, async.SendMessage(1, "hi"), ErrChatQueueFull)
+	assert.NoError(t, async.SendMessage(2, "hi"))
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, q.Flush(ctx), context.DeadlineExceeded)
+}
+
+func TestQueue_Closed(t *testing.T) {
+	q := NewQueue(&recordingBot{}, DefaultLimits)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	q.Run(ctx)
+
+	assert.ErrorIs(t, q.SendMessage(1, "hi"), ErrQueueClosed)
+}
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Now()
+	b := newTokenBucket(2, 2, now)
+	assert.Zero(t, b.wait(now))
+	b.take()
+	b.take()
+	assert.Equal(t, 500*time.Millisecond, b.wait(now))
+	assert.Zero(t, b.wait(now.Add(500*time.Millisecond)))
+	assert.False(t, b.full(now.Add(500*time.Millisecond)))
+	assert.True(t, b.full(now.Add(time.Second)))
+}
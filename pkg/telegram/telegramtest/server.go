@@ -0,0 +1,208 @@
+// Package telegramtest предоставляет фейковый сервер Telegram Bot API для
+// тестов: он записывает исходящие вызовы бота и отдает внедренные апдейты
+// через getUpdates.
+package telegramtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"task-traker/pkg/telegram"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	Token = "123456:TEST"
+	// longPollWait - сколько getUpdates ждет новых апдейтов перед пустым ответом.
+	longPollWait = 50 * time.Millisecond
+)
+
+// Call - записанный вызов метода Bot API.
+type Call struct {
+	Method string
+	Params url.Values
+}
+
+// Server - фейковый Bot API поверх httptest.Server.
+type Server struct {
+	*httptest.Server
+
+	mu            sync.Mutex
+	calls         []Call
+	updates       []tgbotapi.Update
+	nextUpdateID  int
+	nextMessageID int
+	notify        chan struct{}
+	// Responses позволяет переопределить ответ метода (например, вернуть ошибку 429).
+	Responses map[string]func(params url.Values) tgbotapi.APIResponse
+}
+
+func NewServer(t testing.TB) *Server {
+	s := &Server{
+		nextUpdateID:  1,
+		nextMessageID: 1,
+		notify:        make(chan struct{}, 1),
+		Responses:     make(map[string]func(url.Values) tgbotapi.APIResponse),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Endpoint возвращает шаблон адреса API для tgbotapi.
+func (s *Server) Endpoint() string {
+	return s.URL + "/bot%s/%s"
+}
+
+// Client создает настоящий telegram.Client, подключенный к фейковому серверу.
+func (s *Server) Client(t testing.TB) *telegram.Client {
+	c, err := telegram.NewClientWithEndpoint(Token, s.Endpoint())
+	if err != nil {
+		t.Fatalf("failed to create telegram client: %v", err)
+	}
+	return c
+}
+
+// InjectUpdate добавляет апдейт в очередь getUpdates.
+func (s *Server) InjectUpdate(u tgbotapi.Update) {
+	s.mu.Lock()
+	u.UpdateID = s.nextUpdateID
+	s.nextUpdateID++
+	s.updates = append(s.updates, u)
+	s.mu.Unlock()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Calls возвращает записанные вызовы метода.
+func (s *Server) Calls(method string) []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var res []Call
+	for _, c := range s.calls {
+		if c.Method == method {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// WaitCalls ждет, пока метод будет вызван не менее n раз.
+func (s *Server) WaitCalls(t testing.TB, method string, n int) []Call {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		calls := s.Calls(method)
+		if len(calls) >= n {
+			return calls
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d %s calls, got %d", n, method, len(calls))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	// /bot<token>/<method>
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if len(parts) != 2 || parts[0] != "bot"+Token {
+		writeJSON(w, tgbotapi.APIResponse{Ok: false, ErrorCode: 401, Description: "Unauthorized"})
+		return
+	}
+	method := parts[1]
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, tgbotapi.APIResponse{Ok: false, ErrorCode: 400, Description: err.Error()})
+		return
+	}
+
+	if method == "getUpdates" {
+		writeJSON(w, s.getUpdates(r.Form))
+		return
+	}
+
+	s.mu.Lock()
+	s.calls = append(s.calls, Call{Method: method, Params: r.Form})
+	override := s.Responses[method]
+	s.mu.Unlock()
+
+	if override != nil {
+		writeJSON(w, override(r.Form))
+		return
+	}
+
+	switch method {
+	case "getMe":
+		writeJSON(w, result(tgbotapi.User{ID: 1, IsBot: true, FirstName: "Test", UserName: "test_bot"}))
+	case "sendMessage", "editMessageText":
+		writeJSON(w, result(s.message(r.Form)))
+	default:
+		writeJSON(w, result(true))
+	}
+}
+
+func (s *Server) getUpdates(params url.Values) tgbotapi.APIResponse {
+	offset, _ := strconv.Atoi(params.Get("offset"))
+
+	pending := s.pending(offset)
+	if len(pending) == 0 {
+		select {
+		case <-s.notify:
+		case <-time.After(longPollWait):
+		}
+		pending = s.pending(offset)
+	}
+	return result(pending)
+}
+
+func (s *Server) pending(offset int) []tgbotapi.Update {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := []tgbotapi.Update{}
+	for _, u := range s.updates {
+		if u.UpdateID >= offset {
+			res = append(res, u)
+		}
+	}
+	return res
+}
+
+func (s *Server) message(params url.Values) tgbotapi.Message {
+	chatID, _ := strconv.ParseInt(params.Get("chat_id"), 10, 64)
+	messageID, _ := strconv.Atoi(params.Get("message_id"))
+
+	s.mu.Lock()
+	if messageID == 0 {
+		messageID = s.nextMessageID
+		s.nextMessageID++
+	}
+	s.mu.Unlock()
+
+	return tgbotapi.Message{
+		MessageID: messageID,
+		Chat:      &tgbotapi.Chat{ID: chatID, Type: "private"},
+		Date:      int(time.Now().Unix()),
+		Text:      params.Get("text"),
+	}
+}
+
+func result(v any) tgbotapi.APIResponse {
+	raw, _ := json.Marshal(v)
+	return tgbotapi.APIResponse{Ok: true, Result: raw}
+}
+
+func writeJSON(w http.ResponseWriter, resp tgbotapi.APIResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,66 @@
+package telegramtest
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf16"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+var lastMessageID atomic.Int64
+
+// TextMessage создает апдейт с сообщением пользователя в личном чате.
+// Текст, начинающийся с "/", размечается как команда.
+func TextMessage(userID int64, text string) tgbotapi.Update {
+	msg := &tgbotapi.Message{
+		MessageID: int(lastMessageID.Add(1)),
+		From:      &tgbotapi.User{ID: userID, FirstName: "User", UserName: "user", LanguageCode: "ru"},
+		Chat:      &tgbotapi.Chat{ID: userID, Type: "private"},
+		Date:      int(time.Now().Unix()),
+		Text:      text,
+	}
+	if strings.HasPrefix(text, "/") {
+		command, _, _ := strings.Cut(text, " ")
+		msg.Entities = []tgbotapi.MessageEntity{{
+			Type:   "bot_command",
+			Offset: 0,
+			Length: len(utf16.Encode([]rune(command))),
+		}}
+	}
+	return tgbotapi.Update{Message: msg}
+}
+
+// CallbackQuery создает апдейт с нажатием inline-кнопки под сообщением бота.
+func CallbackQuery(userID int64, messageID int, data string) tgbotapi.Update {
+	from := &tgbotapi.User{ID: userID, FirstName: "User", UserName: "user", LanguageCode: "ru"}
+	return tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID:   "cb-" + data,
+		From: from,
+		Message: &tgbotapi.Message{
+			MessageID: messageID,
+			Chat:      &tgbotapi.Chat{ID: userID, Type: "private"},
+		},
+		Data: data,
+	}}
+}
+
+// InlineQuery создает апдейт с inline-запросом "@bot <query>".
+func InlineQuery(userID int64, query string) tgbotapi.Update {
+	return tgbotapi.Update{InlineQuery: &tgbotapi.InlineQuery{
+		ID:    "iq-" + query,
+		From:  &tgbotapi.User{ID: userID, FirstName: "User", UserName: "user", LanguageCode: "ru"},
+		Query: query,
+	}}
+}
+
+// ChosenInlineResult создает апдейт о том, что пользователь выбрал
+// результат resultID в ответ на inline-запрос query.
+func ChosenInlineResult(userID int64, resultID, query string) tgbotapi.Update {
+	return tgbotapi.Update{ChosenInlineResult: &tgbotapi.ChosenInlineResult{
+		ResultID: resultID,
+		From:     &tgbotapi.User{ID: userID, FirstName: "User", UserName: "user", LanguageCode: "ru"},
+		Query:    query,
+	}}
+}